@@ -0,0 +1,352 @@
+/*
+Command umli makes a UML interaction diagram from a DSL script.
+
+Usage:
+
+	umli [-format fmt] [-page size] [-orientation o] [-config file] [-theme name] infile outfile
+
+The output format is chosen from the suffix of outfile, which must be one
+of .png, .jpg (or .jpeg), .svg, .pdf or .json. (The latter is the diagram's graphics
+model, for rendering by other software). Either file may be given as "-", to read
+the script from stdin, or write the diagram to stdout. When writing to stdout,
+the -format flag is required to say which format to use.
+
+The -page and -orientation flags say what page the diagram should be fitted
+to, for PDF output. Pages can be a4, a3, letter or legal, and orientations
+portrait or landscape.
+
+The -config flag names a JSON (.json) or YAML (.yaml or .yml) file of settings,
+such as overrides for the sizes used to lay out the diagram. E.g.
+
+	{"sizes": {"SelfLoopHeight": 4}}
+
+The -theme flag chooses the diagram's colour scheme: default, dark,
+high-contrast, monochrome-print or pastel. A theme statement in the script
+takes precedence.
+
+The exit code is 0 on success, and otherwise one of the exitXXX values
+defined below - so that scripts can tell a faulty DSL script apart from
+problems reading or writing files.
+*/
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/peterhoward42/umli"
+	"github.com/peterhoward42/umli/diag"
+	"github.com/peterhoward42/umli/diag/lifeline"
+	"github.com/peterhoward42/umli/graphics"
+	"github.com/peterhoward42/umli/parser"
+	"github.com/peterhoward42/umli/render"
+	"github.com/peterhoward42/umli/textmetrics"
+	"github.com/peterhoward42/umli/theme"
+)
+
+// Exit codes.
+const (
+	exitOK       = 0
+	exitUsage    = 1 // Malformed command line.
+	exitDSLError = 2 // The DSL script is faulty.
+	exitIOError  = 3 // Reading the script or writing the diagram failed.
+	exitInternal = 4 // The diagram could not be created or rendered.
+)
+
+// stdStream is the file name that means stdin or stdout.
+const stdStream = "-"
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run is the testable body of main. It returns the exit code.
+func run(args []string, stdin io.Reader, stdout io.Writer,
+	stderr io.Writer) int {
+	flags := flag.NewFlagSet("umli", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	format := flags.String("format", "",
+		"output format: png, jpg, svg, pdf or json (default: taken from outfile suffix)")
+	pageName := flags.String("page", "a4",
+		"page size for pdf output: a4, a3, letter or legal")
+	orientationName := flags.String("orientation", "landscape",
+		"page orientation for pdf output: portrait or landscape")
+	configFile := flags.String("config", "",
+		"JSON or YAML file of settings, such as size overrides")
+	themeName := flags.String("theme", "",
+		"colour scheme: "+strings.Join(theme.Names(), ", ")+
+			" (default: default, or as the script says)")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: umli [-format fmt] [-page size] "+
+			"[-orientation o] [-config file] [-theme name] infile outfile")
+		fmt.Fprintln(stderr, `Use "-" for infile or outfile to mean stdin or stdout.`)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return exitUsage
+	}
+	inFile := flags.Arg(0)
+	outFile := flags.Arg(1)
+
+	outFormat, err := chooseFormat(*format, outFile)
+	if err != nil {
+		fmt.Fprintf(stderr, "umli: %v\n", err)
+		return exitUsage
+	}
+	page, err := choosePage(*pageName, *orientationName)
+	if err != nil {
+		fmt.Fprintf(stderr, "umli: %v\n", err)
+		return exitUsage
+	}
+	creatorOptions := []diag.Option{}
+	if *themeName != "" {
+		thm, ok := theme.Named(*themeName)
+		if !ok {
+			fmt.Fprintf(stderr, "umli: Unknown theme: %s, expected one of: %s\n",
+				*themeName, strings.Join(theme.Names(), ", "))
+			return exitUsage
+		}
+		creatorOptions = append(creatorOptions, diag.WithTheme(thm))
+	}
+	var cfg *diag.Config
+	if *configFile != "" {
+		if cfg, err = readConfig(*configFile); err != nil {
+			fmt.Fprintf(stderr, "umli: %v\n", err)
+			var pathErr *os.PathError
+			if errors.As(err, &pathErr) {
+				return exitIOError
+			}
+			return exitUsage
+		}
+	}
+
+	script, err := readScript(inFile, stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "umli: %v\n", err)
+		return exitIOError
+	}
+
+	dslParser := parser.NewParser(string(script))
+	dslModel, err := dslParser.Parse()
+	if err != nil {
+		reportDSLProblems(stderr, err)
+		return exitDSLError
+	}
+	reportDSLProblems(stderr, dslParser.Warnings())
+
+	creator, err := diag.NewCreator(creatorOptions...)
+	if err != nil {
+		fmt.Fprintf(stderr, "umli: %v\n", err)
+		return exitInternal
+	}
+	if cfg != nil {
+		if err := creator.ApplyConfig(cfg); err != nil {
+			fmt.Fprintf(stderr, "umli: %v\n", err)
+			return exitUsage
+		}
+	}
+	graphicsModel, err := creator.Create(*dslModel)
+	if err != nil {
+		fmt.Fprintf(stderr, "umli: %v\n", err)
+		// Activity boxes being stopped when they have not been started
+		// is a fault in the script that the parser cannot detect.
+		var boxErr *lifeline.BoxStateError
+		if errors.As(err, &boxErr) {
+			return exitDSLError
+		}
+		return exitInternal
+	}
+
+	// Render into memory first, so that we do not leave a half-written
+	// output file behind when rendering fails.
+	var rendered bytes.Buffer
+	if err := renderModel(&rendered, outFormat, page, graphicsModel); err != nil {
+		fmt.Fprintf(stderr, "umli: %v\n", err)
+		return exitInternal
+	}
+
+	if err := writeDiagram(outFile, stdout, rendered.Bytes()); err != nil {
+		fmt.Fprintf(stderr, "umli: %v\n", err)
+		return exitIOError
+	}
+	return exitOK
+}
+
+// Values for the output format.
+const (
+	formatPNG  = "png"
+	formatJPG  = "jpg"
+	formatSVG  = "svg"
+	formatPDF  = "pdf"
+	formatJSON = "json"
+)
+
+// formatsBySuffix maps the file suffixes we recognize to output formats.
+var formatsBySuffix = map[string]string{
+	".png":  formatPNG,
+	".jpg":  formatJPG,
+	".jpeg": formatJPG,
+	".svg":  formatSVG,
+	".pdf":  formatPDF,
+	".json": formatJSON,
+}
+
+// chooseFormat decides the output format, preferring that specified
+// explicitly by the -format flag, and otherwise using outFile's suffix.
+func chooseFormat(explicit string, outFile string) (string, error) {
+	if explicit != "" {
+		format, ok := formatsBySuffix["."+strings.ToLower(explicit)]
+		if !ok {
+			return "", fmt.Errorf("Unrecognized format: %s", explicit)
+		}
+		return format, nil
+	}
+	if outFile == stdStream {
+		return "", fmt.Errorf(
+			"The -format flag is required when writing to stdout")
+	}
+	suffix := strings.ToLower(filepath.Ext(outFile))
+	format, ok := formatsBySuffix[suffix]
+	if !ok {
+		return "", fmt.Errorf(
+			"Cannot infer output format from file suffix: <%s>", suffix)
+	}
+	return format, nil
+}
+
+// pageSetup says what page to fit the diagram to, for paged output formats.
+type pageSetup struct {
+	size        render.PageSize
+	orientation render.Orientation
+}
+
+// orientationsByName maps the values of the -orientation flag to
+// orientations.
+var orientationsByName = map[string]render.Orientation{
+	"portrait":  render.Portrait,
+	"landscape": render.Landscape,
+}
+
+// choosePage interprets the -page and -orientation flags.
+func choosePage(pageName string, orientationName string) (pageSetup, error) {
+	size, ok := render.PageSizes[strings.ToLower(pageName)]
+	if !ok {
+		return pageSetup{}, fmt.Errorf("Unrecognized page size: %s", pageName)
+	}
+	orientation, ok := orientationsByName[strings.ToLower(orientationName)]
+	if !ok {
+		return pageSetup{}, fmt.Errorf(
+			"Unrecognized page orientation: %s", orientationName)
+	}
+	return pageSetup{size, orientation}, nil
+}
+
+/*
+reportDSLProblems writes a description of each problem in a DSL script to
+stderr, followed by the faulty line with the problem underlined. E.g.
+
+	umli: line 4: Error: Unknown lifeline: C
+	    full AC bar
+	          ^
+*/
+func reportDSLProblems(stderr io.Writer, err error) {
+	var problems umli.DSLErrorList
+	if !errors.As(err, &problems) {
+		fmt.Fprintf(stderr, "umli: %v\n", err)
+		return
+	}
+	for _, p := range problems {
+		fmt.Fprintf(stderr, "umli: line %d: %v: %s\n", p.Line, p.Severity,
+			p.Message)
+		// Reproduce any tabs before the problem, so that the underline
+		// lines up.
+		var indent strings.Builder
+		for _, r := range []rune(p.Source)[:p.StartCol-1] {
+			if r != '\t' {
+				r = ' '
+			}
+			indent.WriteRune(r)
+		}
+		fmt.Fprintf(stderr, "    %s\n    %s%s\n", p.Source, indent.String(),
+			strings.Repeat("^", p.EndCol-p.StartCol+1))
+	}
+}
+
+// readConfig reads the Creator's configuration from configFile, using its
+// suffix to decide if it is JSON or YAML.
+func readConfig(configFile string) (*diag.Config, error) {
+	f, err := os.Open(configFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	switch strings.ToLower(filepath.Ext(configFile)) {
+	case ".json":
+		return diag.DecodeConfigJSON(f)
+	case ".yaml", ".yml":
+		return diag.DecodeConfigYAML(f)
+	default:
+		return nil, fmt.Errorf(
+			"Config file must be .json, .yaml or .yml: <%s>", configFile)
+	}
+}
+
+// readScript reads the DSL script from inFile, or from stdin.
+func readScript(inFile string, stdin io.Reader) ([]byte, error) {
+	if inFile == stdStream {
+		return ioutil.ReadAll(stdin)
+	}
+	return ioutil.ReadFile(inFile)
+}
+
+// writeDiagram writes the rendered diagram to outFile, or to stdout.
+func writeDiagram(outFile string, stdout io.Writer, rendered []byte) error {
+	if outFile == stdStream {
+		_, err := stdout.Write(rendered)
+		return err
+	}
+	return ioutil.WriteFile(outFile, rendered, 0644)
+}
+
+// renderModel renders the graphics model into w using the given format.
+func renderModel(w io.Writer, format string, page pageSetup,
+	mdl *graphics.Model) error {
+	switch format {
+	case formatSVG:
+		return render.NewSVGCreator().Create(w, mdl)
+	case formatPDF:
+		// Embeds the same font that the diagram's text was measured with.
+		creator, err := render.NewPDFCreator(textmetrics.DefaultFontData(),
+			page.size, page.orientation)
+		if err != nil {
+			return fmt.Errorf("render.NewPDFCreator: %v", err)
+		}
+		return creator.Create(w, mdl)
+	case formatJSON:
+		return graphics.EncodeJSON(w, mdl)
+	case formatPNG, formatJPG:
+		// The same font that the diagram's text was measured with.
+		font, err := textmetrics.DefaultFont()
+		if err != nil {
+			return fmt.Errorf("textmetrics.DefaultFont: %v", err)
+		}
+		encoding := render.PNG
+		if format == formatJPG {
+			encoding = render.JPG
+		}
+		return render.NewImageFileCreator(font).Encode(w, encoding, mdl)
+	default:
+		return fmt.Errorf("Not implemented format: %s", format)
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/peterhoward42/umli/graphics"
+	"github.com/peterhoward42/umli/parser"
+)
+
+func TestStdinToStdoutAsSVG(t *testing.T) {
+	assert := assert.New(t)
+	stdin := strings.NewReader(parser.ReferenceInput)
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-format", "svg", "-", "-"}, stdin, &stdout, &stderr)
+	assert.Equal(exitOK, code)
+	assert.Empty(stderr.String())
+	assert.True(strings.HasPrefix(stdout.String(), "<svg"))
+}
+
+func TestFileToFileWithFormatFromSuffix(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "umli")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	inFile := filepath.Join(dir, "script.txt")
+	err = ioutil.WriteFile(inFile, []byte(parser.ReferenceInput), 0644)
+	assert.NoError(err)
+	outFile := filepath.Join(dir, "diagram.png")
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{inFile, outFile}, nil, &stdout, &stderr)
+	assert.Equal(exitOK, code)
+
+	f, err := os.Open(outFile)
+	assert.NoError(err)
+	defer f.Close()
+	_, err = png.Decode(f)
+	assert.NoError(err)
+}
+
+func TestExitCodeForDSLError(t *testing.T) {
+	assert := assert.New(t)
+	stdin := strings.NewReader("nonsense line")
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-format", "svg", "-", "-"}, stdin, &stdout, &stderr)
+	assert.Equal(exitDSLError, code)
+	assert.Contains(stderr.String(), "Unrecognized keyword: nonsense")
+	assert.Empty(stdout.String())
+
+	// Including those only found when the diagram is laid out.
+	stdin = strings.NewReader("life A foo\nstop A\n")
+	stderr.Reset()
+	code = run([]string{"-format", "svg", "-", "-"}, stdin, &stdout, &stderr)
+	assert.Equal(exitDSLError, code)
+	assert.Equal("umli: Cannot lay out the diagram: There is no box to terminate\n",
+		stderr.String())
+
+	// Including an empty script.
+	stdin = strings.NewReader("\n  \n")
+	stderr.Reset()
+	code = run([]string{"-format", "svg", "-", "-"}, stdin, &stdout, &stderr)
+	assert.Equal(exitDSLError, code)
+	assert.True(strings.HasPrefix(stderr.String(),
+		"umli: line 1: Error: There is no input text\n"))
+}
+
+func TestExitCodeForIOError(t *testing.T) {
+	assert := assert.New(t)
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"/no/such/file", "out.svg"}, nil, &stdout, &stderr)
+	assert.Equal(exitIOError, code)
+}
+
+func TestExitCodeForUsageErrors(t *testing.T) {
+	assert := assert.New(t)
+	var stdout, stderr bytes.Buffer
+
+	// Wrong number of arguments.
+	code := run([]string{"onlyone"}, nil, &stdout, &stderr)
+	assert.Equal(exitUsage, code)
+
+	// Unrecognized suffix.
+	code = run([]string{"in.txt", "out.gif"}, nil, &stdout, &stderr)
+	assert.Equal(exitUsage, code)
+
+	// Writing to stdout without saying which format.
+	code = run([]string{"in.txt", "-"}, nil, &stdout, &stderr)
+	assert.Equal(exitUsage, code)
+}
+
+func TestChooseFormat(t *testing.T) {
+	assert := assert.New(t)
+
+	format, err := chooseFormat("", "foo.JPEG")
+	assert.NoError(err)
+	assert.Equal(formatJPG, format)
+
+	// The flag wins over the suffix.
+	format, err = chooseFormat("svg", "foo.png")
+	assert.NoError(err)
+	assert.Equal(formatSVG, format)
+
+	_, err = chooseFormat("bmp", "-")
+	assert.EqualError(err, "Unrecognized format: bmp")
+}
+
+func TestJSONOutputCanBeDecoded(t *testing.T) {
+	assert := assert.New(t)
+	stdin := strings.NewReader(parser.ReferenceInput)
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-format", "json", "-", "-"}, stdin, &stdout, &stderr)
+	assert.Equal(exitOK, code)
+	mdl, err := graphics.DecodeJSON(&stdout)
+	assert.NoError(err)
+	assert.NotEmpty(mdl.Primitives.Lines)
+}
+
+func TestPDFOutputUsesPageFlags(t *testing.T) {
+	assert := assert.New(t)
+	stdin := strings.NewReader(parser.ReferenceInput)
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-format", "pdf", "-page", "letter", "-orientation",
+		"portrait", "-", "-"}, stdin, &stdout, &stderr)
+	assert.Equal(exitOK, code)
+	assert.True(strings.HasPrefix(stdout.String(), "%PDF-"))
+	assert.Contains(stdout.String(), "/MediaBox [0 0 612 792]")
+
+	code = run([]string{"-page", "a5", "in.txt", "out.pdf"}, nil, &stdout,
+		&stderr)
+	assert.Equal(exitUsage, code)
+	assert.Contains(stderr.String(), "Unrecognized page size: a5")
+}
+
+func TestEveryDSLProblemIsReportedAndUnderlined(t *testing.T) {
+	assert := assert.New(t)
+	stdin := strings.NewReader("life A foo\nfull AC bar\ntextsize big\n")
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-format", "svg", "-", "-"}, stdin, &stdout, &stderr)
+	assert.Equal(exitDSLError, code)
+	assert.Equal(
+		"umli: line 2: Error: Unknown lifeline: C\n"+
+			"    full AC bar\n"+
+			"          ^\n"+
+			"umli: line 3: Error: Text size must be a number\n"+
+			"    textsize big\n"+
+			"             ^^^\n", stderr.String())
+}
+
+func TestConfigFileIsApplied(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "umli")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	height := func(args ...string) float64 {
+		stdin := strings.NewReader(parser.ReferenceInput)
+		var stdout, stderr bytes.Buffer
+		args = append(args, "-format", "json", "-", "-")
+		code := run(args, stdin, &stdout, &stderr)
+		assert.Equal(exitOK, code, stderr.String())
+		mdl, err := graphics.DecodeJSON(&stdout)
+		assert.NoError(err)
+		return mdl.Height
+	}
+	configFile := filepath.Join(dir, "config.yaml")
+	err = ioutil.WriteFile(configFile, []byte("sizes:\n  DiagramPadB: 10\n"), 0644)
+	assert.NoError(err)
+	assert.InDelta(height()+9*20, height("-config", configFile), 0.001)
+
+	// Bad configs are reported.
+	err = ioutil.WriteFile(configFile, []byte("sizes:\n  Bogus: 10\n"), 0644)
+	assert.NoError(err)
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-config", configFile, "in.txt", "out.svg"}, nil,
+		&stdout, &stderr)
+	assert.Equal(exitUsage, code)
+	assert.Equal("umli: Invalid config: Unknown size: Bogus\n", stderr.String())
+}
+
+func TestThemeFlagIsApplied(t *testing.T) {
+	assert := assert.New(t)
+	stdin := strings.NewReader(parser.ReferenceInput)
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-theme", "dark", "-format", "json", "-", "-"},
+		stdin, &stdout, &stderr)
+	assert.Equal(exitOK, code, stderr.String())
+	mdl, err := graphics.DecodeJSON(&stdout)
+	assert.NoError(err)
+	assert.Equal(&graphics.Colour{R: 0x1e, G: 0x1e, B: 0x1e}, mdl.Background)
+
+	stdout.Reset()
+	stderr.Reset()
+	code = run([]string{"-theme", "neon", "in.txt", "out.svg"}, nil,
+		&stdout, &stderr)
+	assert.Equal(exitUsage, code)
+	assert.Equal("umli: Unknown theme: neon, expected one of: dark, default, "+
+		"high-contrast, monochrome-print, pastel\n", stderr.String())
+}
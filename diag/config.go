@@ -0,0 +1,72 @@
+package diag
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/peterhoward42/umli/sizer"
+)
+
+/*
+Config holds settings for a Creator that can be kept in a JSON or YAML file.
+For example:
+
+	{"sizes": {"SelfLoopHeight": 4, "NotePadT": 0.25}}
+
+or
+
+	sizes:
+	  SelfLoopHeight: 4
+	  NotePadT: 0.25
+
+Sizes overrides the sizer.CompleteSizer's built in sizes. (Size statements in
+a DSL script take precedence over these in turn).
+*/
+type Config struct {
+	Sizes sizer.Overrides `json:"sizes" yaml:"sizes"`
+}
+
+// Validate checks that the Config's contents are all valid. Its errors are
+// worded for the person who wrote the config.
+func (cfg *Config) Validate() error {
+	if err := cfg.Sizes.Validate(); err != nil {
+		return fmt.Errorf("Invalid config: %w", err)
+	}
+	return nil
+}
+
+// DecodeConfigJSON reads a Config in JSON form from r, and validates it.
+// Fields that Config does not have are reported as errors.
+func DecodeConfigJSON(r io.Reader) (*Config, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	cfg := &Config{}
+	if err := decoder.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("Cannot decode config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// DecodeConfigYAML reads a Config in YAML form from r, and validates it.
+// Fields that Config does not have are reported as errors.
+func DecodeConfigYAML(r io.Reader) (*Config, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("ioutil.ReadAll: %w", err)
+	}
+	cfg := &Config{}
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, fmt.Errorf("Cannot decode config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
@@ -0,0 +1,40 @@
+package diag
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigCanBeDecodedFromJSONAndYAML(t *testing.T) {
+	assert := assert.New(t)
+	cfg, err := DecodeConfigJSON(strings.NewReader(
+		`{"sizes": {"SelfLoopHeight": 4, "NotePadT": 0.25}}`))
+	assert.NoError(err)
+	assert.Equal(4.0, cfg.Sizes["SelfLoopHeight"])
+	assert.Equal(0.25, cfg.Sizes["NotePadT"])
+
+	cfg, err = DecodeConfigYAML(strings.NewReader(
+		"sizes:\n  SelfLoopHeight: 4\n  NotePadT: 0.25\n"))
+	assert.NoError(err)
+	assert.Equal(4.0, cfg.Sizes["SelfLoopHeight"])
+	assert.Equal(0.25, cfg.Sizes["NotePadT"])
+}
+
+func TestConfigIsValidated(t *testing.T) {
+	assert := assert.New(t)
+
+	// Unknown fields.
+	_, err := DecodeConfigJSON(strings.NewReader(`{"sises": {}}`))
+	assert.Error(err)
+	_, err = DecodeConfigYAML(strings.NewReader("sises: {}\n"))
+	assert.Error(err)
+
+	// Unknown sizes, and those out of range.
+	_, err = DecodeConfigJSON(strings.NewReader(`{"sizes": {"Bogus": 4}}`))
+	assert.EqualError(err, "Invalid config: Unknown size: Bogus")
+	_, err = DecodeConfigYAML(strings.NewReader("sizes:\n  ArrowLen: -1\n"))
+	assert.EqualError(err, "Invalid config: "+
+		"Size <ArrowLen> must be more than 0 and at most 50 (font heights)")
+}
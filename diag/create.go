@@ -3,12 +3,15 @@ package diag
 import (
 	"fmt"
 
+	"github.com/peterhoward42/umli"
 	"github.com/peterhoward42/umli/diag/frame"
 	"github.com/peterhoward42/umli/diag/interactions"
 	"github.com/peterhoward42/umli/diag/lifeline"
 	"github.com/peterhoward42/umli/dsl"
 	"github.com/peterhoward42/umli/graphics"
 	"github.com/peterhoward42/umli/sizer"
+	"github.com/peterhoward42/umli/textmetrics"
+	"github.com/peterhoward42/umli/theme"
 )
 
 /*
@@ -16,26 +19,105 @@ Creator is the type that provides the API and entry point for the diag package.
 It provides the main Create method that produces a diagram.
 */
 type Creator struct {
+	width    float64
+	measurer textmetrics.Measurer
+	newSizer sizerFactory
+	layout   LayoutStrategy
+	theme    theme.Theme
+	config   Config
 }
 
+// sizerFactory describes a function that can make a Sizer for a given font
+// height, that uses the overrides given in place of its built in sizes.
+type sizerFactory func(fontHeight float64, overrides sizer.Overrides) (
+	sizer.Sizer, error)
+
 /*
-NewCreator instantiates a Creator ready to use.
+NewCreator instantiates a Creator ready to use, configured by the options
+given. (See Option). Without options, it makes diagrams 2000 wide, measures
+text using the default font, sizes things with a sizer.CompleteSizer, and uses
+the uniform layout and the default theme.
 */
-func NewCreator() (*Creator, error) {
-	return nil, nil
+func NewCreator(opts ...Option) (*Creator, error) {
+	c := &Creator{
+		width:    defaultWidth,
+		newSizer: newCompleteSizer,
+		layout:   UniformLayout,
+		theme:    theme.Default,
+	}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+	// The default measurer is only made when it is needed, because
+	// parsing the font takes a while.
+	if c.measurer == nil {
+		measurer, err := textmetrics.Default()
+		if err != nil {
+			return nil, fmt.Errorf("textmetrics.Default: %w", err)
+		}
+		c.measurer = measurer
+	}
+	return c, nil
+}
+
+// ApplyConfig validates cfg, and then adopts the settings it contains for
+// subsequent calls to Create. (It returns the error from cfg.Validate).
+func (c *Creator) ApplyConfig(cfg *Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	c.config = *cfg
+	return nil
 }
 
 /*
 Create is the main API method which work out what the diagram should look like.
 It orchestrates the creation process which accumulates the graphics
 primitives required in its graphicsModel and then returns that model.
+The errors it returns are of type *LayoutError.
 */
 func (c *Creator) Create(dslModel dsl.Model) (*graphics.Model, error) {
+	graphicsModel, err := c.create(dslModel)
+	if err != nil {
+		return nil, &LayoutError{err}
+	}
+	return graphicsModel, nil
+}
+
+// create is the body of Create.
+func (c *Creator) create(dslModel dsl.Model) (*graphics.Model, error) {
 	// We need to establish two fundamental sizing drivers, and seek the
 	// the help of a sizer.Sizer that is initialised with these, before we do
 	// much else.
-	width, fontHeight := DrivingDimensions{}.WidthAndFontHeight(dslModel)
-	sizer := sizer.NewCompleteSizer(fontHeight)
+	width, fontHeight, err := DrivingDimensions{c.width}.WidthAndFontHeight(
+		dslModel)
+	if err != nil {
+		return nil, fmt.Errorf("WidthAndFontHeight: %w", err)
+	}
+	sizer, err := c.makeSizer(fontHeight, dslModel.SizeOverrides())
+	if err != nil {
+		return nil, fmt.Errorf("makeSizer: %w", err)
+	}
+	thm, err := c.chooseTheme(dslModel)
+	if err != nil {
+		return nil, fmt.Errorf("chooseTheme: %w", err)
+	}
+
+	// Seek help from another sizing/spacing component - this time, one that is
+	// knows how to spread lifelines across the diagram width-wise. When the
+	// spacing is to suit the labels, this may make the diagram wider.
+	lifelines := dslModel.LifelineStatements()
+	var lifelineSpacing *lifeline.Spacing
+	if c.variableSpacing(dslModel) {
+		lifelineSpacing = lifeline.NewVariableSpacing(sizer, c.measurer,
+			fontHeight, width, lifelines, dslModel.Statements())
+	} else {
+		lifelineSpacing = lifeline.NewSpacing(
+			sizer, c.measurer, fontHeight, width, lifelines)
+	}
+	width = lifelineSpacing.DiagramWidth()
 
 	// Initialise the graphics model that will be populated with lines, text,
 	// arrows etc.
@@ -47,22 +129,25 @@ func (c *Creator) Create(dslModel dsl.Model) (*graphics.Model, error) {
 
 	// Delegate to a specialised object to take responsibility for the graphics
 	// of the overall outer frame and title box.
-	frameMaker := frame.NewMaker(sizer, fontHeight, width, prims)
+	frameMaker := frame.NewMaker(sizer, c.measurer, fontHeight, width, prims)
+	mark := prims.Mark()
 	tideMark := frameMaker.InitFrameAndMakeTitleBox(dslModel.Title(),
 		sizer.Get("DiagramPadT"))
-
-	// Seek help from another sizing/spacing component - this time, one that is
-	// knows how to spread lifelines across the diagram width-wise.
-	lifelines := dslModel.LifelineStatements()
-	lifelineSpacing := lifeline.NewSpacing(sizer, fontHeight, width, lifelines)
+	titleOrigin := graphics.Origin{Kind: graphics.Frame}
+	if title, ok := dslModel.FirstStatementOfType(umli.Title); ok {
+		titleOrigin.SourceLine = title.LineNo
+	}
+	prims.TagSince(mark, titleOrigin)
 
 	// Still focussing on graphics that are conceptually anchored to the top
 	// of the diagram, we can delegate to a component that knows how to make
 	// the title boxes at the top of each lifeline.
-	titleBoxes := lifeline.NewTitleBoxes(sizer, lifelineSpacing, lifelines, fontHeight)
-	tideMark, bottomOfTitleBoxes, err := titleBoxes.Make(tideMark, prims)
+	titleBoxes := lifeline.NewTitleBoxes(
+		sizer, c.measurer, lifelineSpacing, lifelines, fontHeight)
+	tideMark, bottomOfTitleBoxes, err := titleBoxes.Make(
+		tideMark, dslModel.CreatedLifelines(), prims)
 	if err != nil {
-		return nil, fmt.Errorf("titleBoxes.Make: %v", err)
+		return nil, fmt.Errorf("titleBoxes.Make: %w", err)
 	}
 
 	// Now we're going to make the graphics for all the interaction lines,
@@ -79,29 +164,34 @@ func (c *Creator) Create(dslModel dsl.Model) (*graphics.Model, error) {
 	// Now construct the component that makes the interaction lines and their
 	// labels and arrows.
 	d := interactions.NewMakerDependencies(
-		fontHeight, lifelineSpacing, sizer, boxes)
+		fontHeight, lifelineSpacing, sizer, c.measurer, boxes, titleBoxes)
 	interactionsMaker := interactions.NewMaker(d, graphicsModel)
 
 	// And mandate it to do so.
 	tideMark, noGoZones, err := interactionsMaker.ScanInteractionStatements(
 		tideMark, dslModel.Statements())
 	if err != nil {
-		return nil, fmt.Errorf("interactionsMaker.ScanInteractionStatements: %v", err)
+		return nil, fmt.Errorf("interactionsMaker.ScanInteractionStatements: %w", err)
 	}
 
 	// Now we know how far south the diagram has grown, we can terminate and draw,
 	// any activity boxes that have not been closed explicity with a stop command.
 	for _, ll := range lifelines {
 		boxes := boxes[ll]
-		if err := boxes.TerminateAt(tideMark); err != nil {
-			return nil, fmt.Errorf("boxes.TerminateAt: %v", err)
+		for boxes.HasABoxInProgress() {
+			if err := boxes.TerminateAt(tideMark); err != nil {
+				return nil, fmt.Errorf("boxes.TerminateAt: %w", err)
+			}
 		}
 		lifeCoords, err := lifelineSpacing.CentreLine(ll)
 		if err != nil {
-			return nil, fmt.Errorf("lifelineSpacing.CentreLine: %v", err)
+			return nil, fmt.Errorf("lifelineSpacing.CentreLine: %w", err)
 		}
+		mark := prims.Mark()
 		lifeline.NewBoxDrawer(*boxes, lifeCoords.Centre,
-			sizer.Get("ActivityBoxWidth")).Draw(prims)
+			sizer.Get("ActivityBoxWidth"), thm.ActivityBoxFill).Draw(prims)
+		prims.TagSince(mark, graphics.Origin{
+			Kind: graphics.ActivityBox, SourceLine: ll.LineNo})
 	}
 
 	tideMark += sizer.Get("FinalizedActivityBoxesPadB")
@@ -109,22 +199,83 @@ func (c *Creator) Create(dslModel dsl.Model) (*graphics.Model, error) {
 	// Draw the lifelines from top to bottom, leaving gaps where there are
 	// activity boxes, or NoGoZone(s) in the way.
 	lifelineFinalizer := lifeline.NewFinalizer(
-		lifelines, lifelineSpacing, noGoZones, boxes, sizer)
+		lifelines, lifelineSpacing, noGoZones, boxes,
+		interactionsMaker.Lifespans(), sizer)
 	minSegLen := sizer.Get("MinLifelineSegLength")
 	err = lifelineFinalizer.Finalize(
 		bottomOfTitleBoxes, tideMark, minSegLen, graphicsModel.Primitives)
 	if err != nil {
-		return nil, fmt.Errorf("lifelineFinalizer.Finalize: %v", err)
+		return nil, fmt.Errorf("lifelineFinalizer.Finalize: %w", err)
 	}
 
 	tideMark += sizer.Get("LifelinePadB")
 
 	// Finish up by drawing the frame's enclosing rectangle.
+	mark = prims.Mark()
 	tideMark = frameMaker.FinalizeFrame(tideMark)
+	prims.TagSince(mark, graphics.Origin{Kind: graphics.Frame})
 
 	// Tell the graphicsModel what its resultant height is.
 	tideMark += sizer.Get("DiagramPadB")
 	graphicsModel.Height = tideMark
 
+	applyTheme(graphicsModel, thm)
+
 	return graphicsModel, nil
 }
+
+// variableSpacing decides whether to use variable lifeline spacing. A
+// spacing statement in the script wins over the Creator's layout strategy.
+func (c *Creator) variableSpacing(dslModel dsl.Model) bool {
+	if _, ok := dslModel.FirstStatementOfType(umli.Spacing); ok {
+		return dslModel.VariableSpacingRequested()
+	}
+	return c.layout == VariableLayout
+}
+
+// chooseTheme decides which theme to use. A theme statement in the script
+// wins over the Creator's theme.
+func (c *Creator) chooseTheme(dslModel dsl.Model) (theme.Theme, error) {
+	name, ok := dslModel.ThemeName()
+	if !ok {
+		return c.theme, nil
+	}
+	// The parser does not allow unknown themes, but models can be made by
+	// other means.
+	thm, ok := theme.Named(name)
+	if !ok {
+		return theme.Theme{}, fmt.Errorf("Unknown theme: %s", name)
+	}
+	return thm, nil
+}
+
+/*
+makeSizer makes the Sizer to use for the given font height. It overrides the
+built in sizes with those from the Creator's Config, and then those from the
+DSL script. A Sizer's Get method panics when it does not have a key, so this
+makes sure up front that it has all those required.
+*/
+func (c *Creator) makeSizer(fontHeight float64,
+	scriptOverrides map[string]float64) (sizer.Sizer, error) {
+	overrides := sizer.Overrides{}
+	for key, value := range c.config.Sizes {
+		overrides[key] = value
+	}
+	for key, value := range scriptOverrides {
+		overrides[key] = value
+	}
+	s, err := c.newSizer(fontHeight, overrides)
+	if err != nil {
+		return nil, fmt.Errorf("newSizer: %w", err)
+	}
+	if err := sizer.Validate(s, sizer.RequiredKeys()); err != nil {
+		return nil, fmt.Errorf("sizer.Validate: %w", err)
+	}
+	return s, nil
+}
+
+// newCompleteSizer is the Creator's default means of making a Sizer.
+func newCompleteSizer(fontHeight float64,
+	overrides sizer.Overrides) (sizer.Sizer, error) {
+	return sizer.NewCompleteSizerWithOverrides(fontHeight, overrides)
+}
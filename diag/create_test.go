@@ -1,9 +1,17 @@
 package diag
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
 	"testing"
 
+	"github.com/peterhoward42/umli/diag/lifeline"
+	"github.com/peterhoward42/umli/graphics"
 	"github.com/peterhoward42/umli/parser"
+	"github.com/peterhoward42/umli/sizer"
+	"github.com/peterhoward42/umli/theme"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -58,3 +66,579 @@ func TestMainOrchestrationStepsWereProperlyStrungTogether(t *testing.T) {
 	assert.True(right < graphicsModel.Width && right > 0.90*graphicsModel.Width)
 	assert.True(bottom < graphicsModel.Height && bottom > 0.90*graphicsModel.Height)
 }
+
+func TestBoxesStoppedExplicitlyAreNotTerminatedAgain(t *testing.T) {
+	assert := assert.New(t)
+	dslModel := parser.MustCompileParse(parser.ReferenceInput)
+	creator, err := NewCreator()
+	assert.NoError(err)
+	_, err = creator.Create(*dslModel)
+	assert.NoError(err)
+}
+
+/*
+Regression test. Only the activity boxes still in progress at the end of the
+diagram should be terminated there. Terminating again a box that was stopped
+explicitly, or terminating one on a lifeline that never had a box, used to
+fail the whole diagram.
+*/
+func TestOnlyBoxesInProgressAreTerminatedAtTheEnd(t *testing.T) {
+	assert := assert.New(t)
+	dslModel := parser.MustCompileParse(`
+		life A foo
+		life B bar
+		life C unused
+		full AB hello
+		stop B
+	`)
+	creator, err := NewCreator()
+	assert.NoError(err)
+	graphicsModel, err := creator.Create(*dslModel)
+	assert.NoError(err)
+
+	// A's box runs to the end, B's stops at the stop statement, and C has
+	// none.
+	boxBottom := func(lineNo int) float64 {
+		bottom := -1.0
+		for _, line := range graphicsModel.Primitives.ForSourceLine(lineNo).Lines {
+			if line.Origin.Kind == graphics.ActivityBox {
+				bottom = math.Max(bottom, math.Max(line.P1.Y, line.P2.Y))
+			}
+		}
+		return bottom
+	}
+	assert.True(boxBottom(2) > boxBottom(3))
+	assert.True(boxBottom(3) > 0)
+	assert.Equal(-1.0, boxBottom(4))
+}
+
+func TestVariableSpacingCanMakeTheDiagramWider(t *testing.T) {
+	assert := assert.New(t)
+	longLabel := strings.Repeat("label ", 100)
+	creator, err := NewCreator()
+	assert.NoError(err)
+
+	uniform, err := creator.Create(*parser.MustCompileParse(`
+		life A foo
+		life B bar
+		full AB ` + longLabel))
+	assert.NoError(err)
+	assert.Equal(2000.0, uniform.Width)
+
+	variable, err := creator.Create(*parser.MustCompileParse(`
+		spacing variable
+		life A foo
+		life B bar
+		full AB ` + longLabel))
+	assert.NoError(err)
+	assert.True(variable.Width > 2000.0)
+
+	// Everything should still fit inside the diagram.
+	_, _, right, _ := variable.Primitives.BoundingBoxOfLines()
+	assert.True(right < variable.Width)
+}
+
+/*
+Regression test. So many lifelines, that there is no room left for the text
+in their title boxes, used to make the title wrapping panic.
+*/
+func TestVeryManyLifelinesDoNotPanic(t *testing.T) {
+	assert := assert.New(t)
+	script := ""
+	for i := 1; i <= 60; i++ {
+		script += fmt.Sprintf("life L%d x\n", i)
+	}
+	creator, err := NewCreator()
+	assert.NoError(err)
+	assert.NotPanics(func() {
+		_, err = creator.Create(*parser.MustCompileParse(script))
+	})
+	assert.NoError(err)
+}
+
+func TestCreateErrorsAreLayoutErrorsWithoutStackStylePrefixes(t *testing.T) {
+	assert := assert.New(t)
+	dslModel := parser.MustCompileParse(`
+		life A foo
+		stop A
+	`)
+	creator, err := NewCreator()
+	assert.NoError(err)
+	_, err = creator.Create(*dslModel)
+	assert.EqualError(err,
+		"Cannot lay out the diagram: There is no box to terminate")
+
+	var layoutErr *LayoutError
+	assert.True(errors.As(err, &layoutErr))
+	var stateErr *lifeline.BoxStateError
+	assert.True(errors.As(err, &stateErr))
+	assert.True(errors.Is(err, lifeline.ErrNoBoxToTerminate))
+}
+
+func TestCreateReturnsAnErrorWhenTheSizerIsMissingKeys(t *testing.T) {
+	assert := assert.New(t)
+	dslModel := parser.MustCompileParse(`
+		life A foo
+	`)
+	creator, err := NewCreator()
+	assert.NoError(err)
+	creator.newSizer = func(fontHeight float64,
+		overrides sizer.Overrides) (sizer.Sizer, error) {
+		return sizer.NewLiteralSizer(map[string]float64{"ArrowLen": 1}), nil
+	}
+	_, err = creator.Create(*dslModel)
+	assert.Error(err)
+	var missing *sizer.MissingKeysError
+	assert.True(errors.As(err, &missing))
+	assert.NotContains(missing.Keys, "ArrowLen")
+	assert.Contains(missing.Keys, "ActivityBoxWidth")
+}
+
+func TestSizeOverridesFromConfigAndScriptAreUsed(t *testing.T) {
+	assert := assert.New(t)
+	diagramHeight := func(script string, cfg *Config) float64 {
+		creator, err := NewCreator()
+		assert.NoError(err)
+		if cfg != nil {
+			assert.NoError(creator.ApplyConfig(cfg))
+		}
+		graphicsModel, err := creator.Create(*parser.MustCompileParse(script))
+		assert.NoError(err)
+		return graphicsModel.Height
+	}
+	script := `
+		life A foo
+		self A bar
+	`
+	defaultHeight := diagramHeight(script, nil)
+	configHeight := diagramHeight(script, &Config{
+		Sizes: sizer.Overrides{"SelfLoopHeight": 6}})
+	scriptHeight := diagramHeight("size SelfLoopHeight 9\n"+script, &Config{
+		Sizes: sizer.Overrides{"SelfLoopHeight": 6}})
+
+	// The diagram grows by the extra self loop height. (The default is 3 font
+	// heights of 20).
+	assert.InDelta(defaultHeight+60, configHeight, 0.001)
+	assert.InDelta(defaultHeight+120, scriptHeight, 0.001)
+}
+
+func TestApplyConfigValidatesTheConfig(t *testing.T) {
+	assert := assert.New(t)
+	creator, err := NewCreator()
+	assert.NoError(err)
+	err = creator.ApplyConfig(&Config{Sizes: sizer.Overrides{"Bogus": 1}})
+	assert.EqualError(err, "Invalid config: Unknown size: Bogus")
+}
+
+func TestTheDefaultThemeLeavesThePrimitivesUnstyled(t *testing.T) {
+	assert := assert.New(t)
+	creator, err := NewCreator()
+	assert.NoError(err)
+	graphicsModel, err := creator.Create(*parser.MustCompileParse(optionsScript))
+	assert.NoError(err)
+	assert.Nil(graphicsModel.Background)
+	prims := graphicsModel.Primitives
+	for _, line := range prims.Lines {
+		assert.Nil(line.Style)
+	}
+	for _, poly := range prims.FilledPolys {
+		assert.Nil(poly.Style)
+	}
+	for _, polyline := range prims.Polylines {
+		assert.Nil(polyline.Style)
+	}
+	for _, label := range prims.Labels {
+		assert.Nil(label.Style)
+	}
+}
+
+func TestThemesStyleEachRole(t *testing.T) {
+	assert := assert.New(t)
+	creator, err := NewCreator()
+	assert.NoError(err)
+	graphicsModel, err := creator.Create(*parser.MustCompileParse(
+		"theme pastel\nreplies open\n" + optionsScript + "dash BA reply\n"))
+	assert.NoError(err)
+	pastel, _ := theme.Named("pastel")
+	assert.Equal(pastel.Background, graphicsModel.Background)
+
+	prims := graphicsModel.Primitives
+	counts := map[interface{}]int{}
+	for _, line := range prims.Lines {
+		counts[line.Style]++
+	}
+	for _, poly := range prims.FilledPolys {
+		counts[poly.Style]++
+	}
+	for _, polyline := range prims.Polylines {
+		counts[polyline.Style]++
+	}
+	for _, label := range prims.Labels {
+		counts[label.Style]++
+	}
+	// The title box and the outer frame.
+	assert.Equal(8, counts[pastel.Frame])
+	// The two activity boxes, the one filled arrow, and the one open arrow.
+	assert.Equal(2, counts[pastel.ActivityBoxFill])
+	assert.Equal(1, counts[pastel.Arrow])
+	assert.Equal(1, counts[pastel.OpenArrow])
+	assert.Equal(len(prims.Lines)-8, counts[pastel.Line])
+	assert.Equal(len(prims.Labels), counts[pastel.Text])
+}
+
+func TestThemeStatementWinsOverTheThemeOption(t *testing.T) {
+	assert := assert.New(t)
+	dark, _ := theme.Named("dark")
+	pastel, _ := theme.Named("pastel")
+	creator, err := NewCreator(WithTheme(dark))
+	assert.NoError(err)
+
+	graphicsModel, err := creator.Create(*parser.MustCompileParse(optionsScript))
+	assert.NoError(err)
+	assert.Equal(dark.Background, graphicsModel.Background)
+
+	graphicsModel, err = creator.Create(*parser.MustCompileParse(
+		"theme pastel\n" + optionsScript))
+	assert.NoError(err)
+	assert.Equal(pastel.Background, graphicsModel.Background)
+}
+
+func TestPrimitivesKnowWhichElementAndLineTheyCameFrom(t *testing.T) {
+	assert := assert.New(t)
+	dslModel := parser.MustCompileParse(`title My Title
+		life A foo
+		life B bar
+		full AB fibble
+		note A remark
+		loop forever
+		self B think
+		end
+	`)
+	creator, err := NewCreator()
+	assert.NoError(err)
+	graphicsModel, err := creator.Create(*dslModel)
+	assert.NoError(err)
+	prims := graphicsModel.Primitives
+
+	// Everything has a kind.
+	for _, line := range prims.Lines {
+		assert.NotEqual(graphics.ElementKind(""), line.Origin.Kind)
+	}
+	for _, poly := range prims.FilledPolys {
+		assert.NotEqual(graphics.ElementKind(""), poly.Origin.Kind)
+	}
+	for _, label := range prims.Labels {
+		assert.NotEqual(graphics.ElementKind(""), label.Origin.Kind)
+	}
+
+	// kindsOn provides the kinds of the lines, polygons and labels
+	// that came from the given line.
+	kindsOn := func(lineNo int) (lines, polys, labels []graphics.ElementKind) {
+		found := prims.ForSourceLine(lineNo)
+		for _, line := range found.Lines {
+			lines = append(lines, line.Origin.Kind)
+		}
+		for _, poly := range found.FilledPolys {
+			polys = append(polys, poly.Origin.Kind)
+		}
+		for _, label := range found.Labels {
+			labels = append(labels, label.Origin.Kind)
+		}
+		return
+	}
+	k := graphics.InteractionLine
+	lines, polys, labels := kindsOn(1)
+	assert.Equal([]graphics.ElementKind{graphics.Frame, graphics.Frame,
+		graphics.Frame, graphics.Frame}, lines)
+	assert.Nil(polys)
+	assert.Equal([]graphics.ElementKind{graphics.Frame}, labels)
+
+	// A lifeline's title box, activity box and lifeline.
+	lines, _, labels = kindsOn(2)
+	assert.Contains(lines, graphics.TitleBox)
+	assert.Contains(lines, graphics.ActivityBox)
+	assert.Contains(lines, graphics.Lifeline)
+	assert.NotEmpty(labels)
+	for _, kind := range labels {
+		assert.Equal(graphics.TitleBox, kind)
+	}
+
+	lines, polys, labels = kindsOn(4)
+	assert.Equal([]graphics.ElementKind{k}, lines)
+	assert.Equal([]graphics.ElementKind{graphics.ArrowHead}, polys)
+	assert.Equal([]graphics.ElementKind{k}, labels)
+
+	lines, _, labels = kindsOn(5)
+	assert.Len(lines, 7)
+	assert.Equal(graphics.Note, lines[0])
+	assert.Equal([]graphics.ElementKind{graphics.Note}, labels)
+
+	lines, _, labels = kindsOn(6)
+	assert.Equal(graphics.Fragment, lines[0])
+	assert.Equal(graphics.Fragment, labels[0])
+
+	lines, polys, _ = kindsOn(7)
+	assert.Equal([]graphics.ElementKind{k, k, k}, lines)
+	assert.Equal([]graphics.ElementKind{graphics.ArrowHead}, polys)
+
+	// The end statement makes nothing of its own.
+	lines, polys, labels = kindsOn(8)
+	assert.Nil(lines)
+	assert.Nil(polys)
+	assert.Nil(labels)
+}
+
+func TestCallbacksIntoABusyLifelineGetANestedActivityBox(t *testing.T) {
+	assert := assert.New(t)
+	dslModel := parser.MustCompileParse(`life A foo
+		life B bar
+		full AB request
+		activate A
+		full BA callback
+		self A think
+		deactivate A
+		dash BA reply
+	`)
+	creator, err := NewCreator()
+	assert.NoError(err)
+	graphicsModel, err := creator.Create(*dslModel)
+	assert.NoError(err)
+	prims := graphicsModel.Primitives
+
+	// Lifeline A has an outer box (with its right hand edge broken
+	// by the nested box), and a nested box.
+	boxRight := 0.0
+	numBoxLines := 0
+	for _, line := range prims.ForSourceLine(1).Lines {
+		if line.Origin.Kind == graphics.ActivityBox {
+			numBoxLines++
+			boxRight = math.Max(boxRight, math.Max(line.P1.X, line.P2.X))
+		}
+	}
+	assert.Equal(9, numBoxLines)
+
+	// The callback arrives at the nested box, not the outer one.
+	callback := prims.ForSourceLine(5).Lines[0]
+	assert.InDelta(boxRight, math.Min(callback.P1.X, callback.P2.X), 0.001)
+
+	// As does a self message, whose label is centred over its loop.
+	self := prims.ForSourceLine(6)
+	loopTop := self.Lines[0]
+	assert.InDelta(boxRight, math.Min(loopTop.P1.X, loopTop.P2.X), 0.001)
+	assert.InDelta(0.5*(loopTop.P1.X+loopTop.P2.X), self.Labels[0].Anchor.X, 0.001)
+
+	// Whereas the reply, after the deactivation, arrives at the outer one.
+	reply := prims.ForSourceLine(8).Lines[0]
+	assert.True(math.Min(reply.P1.X, reply.P2.X) < boxRight-1)
+}
+
+func TestAsynchronousMessagesHaveOpenArrowHeads(t *testing.T) {
+	assert := assert.New(t)
+	dslModel := parser.MustCompileParse(`life A foo
+		life B bar
+		full AB call
+		async AB notify
+		dash BA reply
+	`)
+	creator, err := NewCreator()
+	assert.NoError(err)
+	graphicsModel, err := creator.Create(*dslModel)
+	assert.NoError(err)
+	prims := graphicsModel.Primitives
+
+	full := prims.ForSourceLine(3)
+	assert.Len(full.FilledPolys, 1)
+	assert.Len(full.Polylines, 0)
+	assert.Equal(graphics.ArrowHead, full.FilledPolys[0].Origin.Kind)
+
+	// The asynchronous message is a solid line with an open head.
+	async := prims.ForSourceLine(4)
+	assert.Len(async.FilledPolys, 0)
+	assert.Len(async.Polylines, 1)
+	assert.Len(async.Polylines[0].Vertices, 3)
+	assert.Equal(graphics.ArrowHead, async.Polylines[0].Origin.Kind)
+	assert.False(async.Lines[0].Dashed)
+
+	// Replies keep their filled heads, unless open ones are asked for.
+	reply := prims.ForSourceLine(5)
+	assert.Len(reply.FilledPolys, 1)
+	assert.Len(reply.Polylines, 0)
+	assert.True(reply.Lines[0].Dashed)
+}
+
+func TestRepliesHaveOpenArrowHeadsWhenAskedFor(t *testing.T) {
+	assert := assert.New(t)
+	dslModel := parser.MustCompileParse(`replies open
+		life A foo
+		life B bar
+		full AB call
+		dash BA reply
+	`)
+	creator, err := NewCreator()
+	assert.NoError(err)
+	graphicsModel, err := creator.Create(*dslModel)
+	assert.NoError(err)
+	prims := graphicsModel.Primitives
+
+	full := prims.ForSourceLine(4)
+	assert.Len(full.FilledPolys, 1)
+	assert.Len(full.Polylines, 0)
+
+	reply := prims.ForSourceLine(5)
+	assert.Len(reply.FilledPolys, 0)
+	assert.Len(reply.Polylines, 1)
+	assert.Equal(graphics.ArrowHead, reply.Polylines[0].Origin.Kind)
+	assert.True(reply.Lines[0].Dashed)
+}
+
+func TestCreateLabelsSitJustAboveTheLineWhenTheTitleBoxIsTall(t *testing.T) {
+	assert := assert.New(t)
+	dslModel := parser.MustCompileParse(`life A foo
+		life B <<database>> bar
+		create AB new
+	`)
+	creator, err := NewCreator()
+	assert.NoError(err)
+	graphicsModel, err := creator.Create(*dslModel)
+	assert.NoError(err)
+
+	created := graphicsModel.Primitives.ForSourceLine(3)
+	createLine := created.Lines[0]
+	label := created.Labels[0]
+	labelBottom := label.Anchor.Y + label.FontHeight
+	assert.True(labelBottom < createLine.P1.Y)
+	assert.True(labelBottom > createLine.P1.Y-label.FontHeight)
+}
+
+func TestCreatedAndDestroyedLifelinesAreCutToTheirLifespan(t *testing.T) {
+	assert := assert.New(t)
+	dslModel := parser.MustCompileParse(`life A foo
+		life B bar
+		create AB new
+		full AB hello
+		destroy B
+		life C baz
+		full AC later
+	`)
+	creator, err := NewCreator()
+	assert.NoError(err)
+	graphicsModel, err := creator.Create(*dslModel)
+	assert.NoError(err)
+	prims := graphicsModel.Primitives
+
+	// extent provides the vertical extent of lines from lifeline statement
+	// lineNo, of the given kind.
+	extent := func(lineNo int, kind graphics.ElementKind) (top, bottom float64) {
+		top, bottom = math.Inf(1), math.Inf(-1)
+		for _, line := range prims.ForSourceLine(lineNo).Lines {
+			if line.Origin.Kind == kind {
+				top = math.Min(top, math.Min(line.P1.Y, line.P2.Y))
+				bottom = math.Max(bottom, math.Max(line.P1.Y, line.P2.Y))
+			}
+		}
+		return top, bottom
+	}
+	topOfA, _ := extent(1, graphics.TitleBox)
+	topOfB, bottomOfB := extent(2, graphics.TitleBox)
+
+	// B's title box is centred on the create line, below A's title box.
+	assert.True(topOfB > topOfA+1)
+	createLine := prims.ForSourceLine(3).Lines[0]
+	assert.True(createLine.Dashed)
+	assert.InDelta(0.5*(topOfB+bottomOfB), createLine.P1.Y, 0.001)
+	assert.Len(prims.ForSourceLine(3).Polylines, 1)
+
+	// The X is made of two lines.
+	destroyed := prims.ForSourceLine(5).Lines
+	assert.Len(destroyed, 2)
+	xTop, xBottom := extent(5, graphics.Lifeline)
+	xCentre := 0.5 * (xTop + xBottom)
+
+	// B's lifeline runs from its title box to the X. (A's runs further).
+	lifelineTop, lifelineBottom := extent(2, graphics.Lifeline)
+	assert.InDelta(bottomOfB, lifelineTop, 0.001)
+	assert.True(lifelineBottom <= xCentre+0.001)
+	_, bottomOfA := extent(1, graphics.Lifeline)
+	assert.True(bottomOfA > xBottom)
+
+	// And its activity box ends there too.
+	_, boxBottom := extent(2, graphics.ActivityBox)
+	assert.InDelta(xCentre, boxBottom, 0.001)
+}
+
+func TestParticipantIconsAreStyledLikeLines(t *testing.T) {
+	assert := assert.New(t)
+	dslModel := parser.MustCompileParse(`theme dark
+		life A <<database>> store
+		life B bar
+		async BA save
+	`)
+	creator, err := NewCreator()
+	assert.NoError(err)
+	graphicsModel, err := creator.Create(*dslModel)
+	assert.NoError(err)
+	dark, _ := theme.Named("dark")
+	icon := graphicsModel.Primitives.ForSourceLine(2).Polylines
+	assert.Len(icon, 2)
+	for _, polyline := range icon {
+		assert.Equal(dark.Line, polyline.Style)
+	}
+	arrow := graphicsModel.Primitives.ForSourceLine(4).Polylines
+	assert.Len(arrow, 1)
+	assert.Equal(dark.OpenArrow, arrow[0].Style)
+}
+
+func TestAutonumberedMessagesAreLabelledAndTaggedWithTheirNumber(t *testing.T) {
+	assert := assert.New(t)
+	dslModel := parser.MustCompileParse(`autonumber 1 1 [01]
+		life A foo
+		life B bar
+		full AB hello
+		dash BA done
+	`)
+	creator, err := NewCreator()
+	assert.NoError(err)
+	graphicsModel, err := creator.Create(*dslModel)
+	assert.NoError(err)
+	prims := graphicsModel.Primitives
+
+	hello := prims.ForSourceLine(4)
+	assert.Equal("[01] hello", hello.Labels[0].TheString)
+	reply := prims.ForSourceLine(5)
+	assert.Equal("[02] done", reply.Labels[0].TheString)
+	for _, line := range hello.Lines {
+		assert.Equal("[01]", line.Origin.SequenceNumber)
+	}
+	assert.Equal("[01]", hello.FilledPolys[0].Origin.SequenceNumber)
+	assert.Equal("[02]", reply.FilledPolys[0].Origin.SequenceNumber)
+}
+
+/*
+Regression test. The self loop width is a proportion of the lifeline pitch,
+and used to be scaled by the font height as well, like the lengths are. Which
+made self loops many times wider than the gap between the lifelines.
+*/
+func TestSelfLoopsAreAProportionOfTheLifelinePitchWide(t *testing.T) {
+	assert := assert.New(t)
+	dslModel := parser.MustCompileParse(`
+		life A foo
+		life B bar
+		self A loop
+	`)
+	creator, err := NewCreator()
+	assert.NoError(err)
+	graphicsModel, err := creator.Create(*dslModel)
+	assert.NoError(err)
+	prims := graphicsModel.Primitives
+	lifelineX := func(lineNo int) (x float64) {
+		for _, line := range prims.ForSourceLine(lineNo).Lines {
+			if line.Origin.Kind == graphics.Lifeline {
+				x = line.P1.X
+			}
+		}
+		return x
+	}
+	pitch := lifelineX(3) - lifelineX(2)
+	loopTop := prims.ForSourceLine(4).Lines[0]
+	assert.InDelta(0.7*pitch, loopTop.P2.X-loopTop.P1.X, 0.001)
+}
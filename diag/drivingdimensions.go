@@ -1,33 +1,48 @@
 package diag
 
-import "github.com/peterhoward42/umli/dsl"
+import (
+	"fmt"
+
+	"github.com/peterhoward42/umli/dsl"
+)
 
 /*
 DrivingDimensions knows how to calculate the diagram width and the font height
 that the diagram creation process will use as the fundamental sizes
 from which all other sizing and spacing is derived.
+
+The zero value uses the default diagram width.
 */
-type DrivingDimensions struct{}
+type DrivingDimensions struct {
+	Width float64 // The diagram width, or zero for the default.
+}
 
-// WidthAndFontHeight provides the diagram width and font height.
+// WidthAndFontHeight provides the diagram width and font height. It returns
+// an error if the model asks for a text size that is not positive.
 func (dd DrivingDimensions) WidthAndFontHeight(dslModel dsl.Model) (
-	diagWidth, fontHeight float64) {
+	diagWidth, fontHeight float64, err error) {
 	// The diagram width is in a sense arbitrary, because the contract of
 	// of the diag package is that it will choose an arbitrary diagram
 	// width that is convenient to itself, and produce a graphics.Model
 	// accordingly. Renderers of a graphics.Model are obliged to scale the
 	// coordinates to suit their rendering needs.
 
-	// We choose 2000 because its easy to reason about during debugging if
-	// you think of it as pixels.
-	width := 2000.0
+	// The default of 2000 is chosen because its easy to reason about
+	// during debugging if you think of it as pixels.
+	width := dd.Width
+	if width == 0 {
+		width = defaultWidth
+	}
 
 	const defaultTextHeightRatio = 1.0 / 100.0 // Works  well empirically.
 	textHeightRatio := defaultTextHeightRatio
 	sizeValue, ok := dslModel.SizeFromTextStatement()
 	if ok {
-		if sizeValue == 0 {
-			panic("Developer error, this must not be allowed to happen")
+		// The parser does not allow this, but models can be made by
+		// other means.
+		if sizeValue <= 0 {
+			return 0, 0, fmt.Errorf(
+				"Text size must be greater than zero, not %v", sizeValue)
 		}
 		// 5  -> 0.005
 		// 10 -> 0.010
@@ -35,5 +50,5 @@ func (dd DrivingDimensions) WidthAndFontHeight(dslModel dsl.Model) (
 		textHeightRatio = sizeValue / 1000.0
 	}
 	fontHeight = width * textHeightRatio
-	return width, fontHeight
+	return width, fontHeight, nil
 }
@@ -11,7 +11,8 @@ import (
 func TestCorrectResultsWhenNoTextSizeStatement(t *testing.T) {
 	assert := assert.New(t)
 	mdl := dsl.Model{}
-	diagWidth, fontHeight := DrivingDimensions{}.WidthAndFontHeight(mdl)
+	diagWidth, fontHeight, err := DrivingDimensions{}.WidthAndFontHeight(mdl)
+	assert.NoError(err)
 	assert.Equal(diagWidth, 2000.0)
 	assert.Equal(fontHeight, 20.0)
 }
@@ -23,7 +24,27 @@ func TestCorrectResultsWhenTextSizeStatementPresent(t *testing.T) {
 	s.Keyword = umli.TextSize
 	s.TextSize = 20.0
 	mdl.Append(s)
-	diagWidth, fontHeight := DrivingDimensions{}.WidthAndFontHeight(mdl)
+	diagWidth, fontHeight, err := DrivingDimensions{}.WidthAndFontHeight(mdl)
+	assert.NoError(err)
 	assert.Equal(diagWidth, 2000.0)
 	assert.Equal(fontHeight, 40.0)
 }
+
+func TestErrorWhenTextSizeIsZero(t *testing.T) {
+	assert := assert.New(t)
+	mdl := dsl.Model{}
+	s := dsl.NewStatement()
+	s.Keyword = umli.TextSize
+	mdl.Append(s)
+	_, _, err := DrivingDimensions{}.WidthAndFontHeight(mdl)
+	assert.EqualError(err, "Text size must be greater than zero, not 0")
+}
+
+func TestFontHeightScalesWithWidthWhenWidthIsGiven(t *testing.T) {
+	assert := assert.New(t)
+	mdl := dsl.Model{}
+	diagWidth, fontHeight, err := DrivingDimensions{Width: 1000}.WidthAndFontHeight(mdl)
+	assert.NoError(err)
+	assert.Equal(diagWidth, 1000.0)
+	assert.Equal(fontHeight, 10.0)
+}
@@ -0,0 +1,30 @@
+package diag
+
+import (
+	"errors"
+)
+
+/*
+LayoutError is the error Creator.Create returns when it cannot lay out a
+diagram. Its message is that of the root cause only, so that it is fit to show
+to the person who wrote the DSL script. The full chain of wrapped errors, which
+says where the failure happened, remains available using errors.Unwrap, and so
+errors.Is and errors.As can be used to find out more. For example to see if the
+cause was a lifeline.BoxStateError.
+*/
+type LayoutError struct {
+	Err error
+}
+
+func (e *LayoutError) Error() string {
+	cause := e.Err
+	for next := errors.Unwrap(cause); next != nil; next = errors.Unwrap(cause) {
+		cause = next
+	}
+	return "Cannot lay out the diagram: " + cause.Error()
+}
+
+// Unwrap provides the error that caused the layout to fail.
+func (e *LayoutError) Unwrap() error {
+	return e.Err
+}
@@ -1,8 +1,11 @@
 package frame
 
 import (
+	"math"
+
 	"github.com/peterhoward42/umli/graphics"
 	"github.com/peterhoward42/umli/sizer"
+	"github.com/peterhoward42/umli/textmetrics"
 )
 
 /*
@@ -11,6 +14,7 @@ graphics.Primitives structure.
 */
 type Maker struct {
 	sizer      sizer.Sizer
+	measurer   textmetrics.Measurer
 	frameTop   float64
 	fontHeight float64
 	diagWidth  float64
@@ -18,10 +22,11 @@ type Maker struct {
 }
 
 // NewMaker provides a lifelineBoxes ready to use.
-func NewMaker(
-	s sizer.Sizer, fontHeight float64, diagWidth float64, prims *graphics.Primitives) *Maker {
+func NewMaker(s sizer.Sizer, measurer textmetrics.Measurer,
+	fontHeight float64, diagWidth float64, prims *graphics.Primitives) *Maker {
 	return &Maker{
 		sizer:      s,
+		measurer:   measurer,
 		diagWidth:  diagWidth,
 		prims:      prims,
 		fontHeight: fontHeight,
@@ -32,7 +37,7 @@ func NewMaker(
 InitFrameAndMakeTitleBox is responsible capturing the Y coordinate at which
 the diagram's frame rectangle should start, and then drawing the diagram title
 in an enclosing rectangle just below it. Then advancing the tidemark
-accordingly.
+accordingly. The rectangle is made wider than usual when the title needs it.
 */
 func (fm *Maker) InitFrameAndMakeTitleBox(titleSegments []string,
 	frameTop float64) (newTideMark float64) {
@@ -45,7 +50,11 @@ func (fm *Maker) InitFrameAndMakeTitleBox(titleSegments []string,
 		fm.fontHeight, graphics.Left, titleSegments)
 	tideMark += float64(len(titleSegments)) * fm.fontHeight
 	tideMark += fm.sizer.Get("FrameTitleTextPadB")
-	rightOfBox := leftOfBox + fm.diagWidth*0.3 // Nowhere other good home for this constant.
+	titleWidth := textmetrics.MaxWidth(fm.measurer, titleSegments, fm.fontHeight)
+	boxWidth := math.Max(
+		fm.diagWidth*0.3, // Nowhere other good home for this constant.
+		leftOfText-leftOfBox+titleWidth+fm.sizer.Get("FrameTitleTextPadR"))
+	rightOfBox := leftOfBox + boxWidth
 	fm.prims.AddRect(leftOfBox, fm.frameTop, rightOfBox, tideMark)
 	tideMark += fm.sizer.Get("FrameTitleRectPadB")
 	return tideMark
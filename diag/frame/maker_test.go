@@ -5,6 +5,7 @@ import (
 
 	"github.com/peterhoward42/umli/graphics"
 	"github.com/peterhoward42/umli/sizer"
+	"github.com/peterhoward42/umli/textmetrics"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -24,13 +25,14 @@ func TestInitializeStep(t *testing.T) {
 		"FramePadLR":         11,
 		"FrameTitleRectPadB": 2,
 		"FrameTitleTextPadL": 4,
+		"FrameTitleTextPadR": 3,
 		"FrameTitleTextPadB": 7,
 		"FrameTitleTextPadT": 5,
 	})
 	prims := graphics.NewPrimitives()
 	fontHeight := 6.0
 	diagWidth := 2000.0
-	maker := NewMaker(sizer, fontHeight, diagWidth, prims)
+	maker := NewMaker(sizer, textmetrics.NewFixedPitchMeasurer(0.5), fontHeight, diagWidth, prims)
 	frameTop := 5.0
 	title := "My title"
 	tideMark := maker.InitFrameAndMakeTitleBox([]string{title}, frameTop)
@@ -73,7 +75,7 @@ func TestFinalizeStep(t *testing.T) {
 	prims := graphics.NewPrimitives()
 	unusedFontHeight := 9999999999.0
 	diagWidth := 2000.0
-	maker := NewMaker(sizer, unusedFontHeight, diagWidth, prims)
+	maker := NewMaker(sizer, textmetrics.NewFixedPitchMeasurer(0.5), unusedFontHeight, diagWidth, prims)
 	initialTideMark := 200.0
 
 	maker.frameTop = 10 // Simulates this state having been set in earlier step.
@@ -88,3 +90,26 @@ func TestFinalizeStep(t *testing.T) {
 	// Tidemark
 	assert.Equal(float64(205), tideMark)
 }
+
+func TestTitleBoxIsWidenedToHoldWideTitles(t *testing.T) {
+	assert := assert.New(t)
+	sizer := sizer.NewLiteralSizer(map[string]float64{
+		"FramePadLR":         11,
+		"FrameTitleRectPadB": 2,
+		"FrameTitleTextPadL": 4,
+		"FrameTitleTextPadB": 7,
+		"FrameTitleTextPadT": 5,
+		"FrameTitleTextPadR": 3,
+	})
+	prims := graphics.NewPrimitives()
+	fontHeight := 10.0
+	diagWidth := 200.0
+	maker := NewMaker(sizer, textmetrics.NewFixedPitchMeasurer(0.5),
+		fontHeight, diagWidth, prims)
+	maker.InitFrameAndMakeTitleBox([]string{"A title of 28 characters....", "x"}, 5)
+
+	// 28 characters at 5 units each, plus the padding either side.
+	tl := graphics.NewPoint(11, 5)
+	br := graphics.NewPoint(11+4+140+3, 5+5+2*10+7)
+	assert.True(prims.ContainsRect(tl, br))
+}
@@ -0,0 +1,151 @@
+package interactions
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/peterhoward42/umli/diag/nogozone"
+	"github.com/peterhoward42/umli/dsl"
+	"github.com/peterhoward42/umli/geom"
+)
+
+/*
+found makes the graphics for a found message. That is one whose sender is
+unknown, and so is drawn as an interaction line that starts at a filled dot
+near the left hand edge of the diagram, and ends at the lifeline it refers to.
+*/
+func (mkr *Maker) found(
+	tidemark float64, s *dsl.Statement) (newTidemark float64, err error) {
+	dep := mkr.dependencies
+	destLifeline := s.ReferencedLifelines[0]
+	lifelineXCoords, err := dep.spacer.CentreLine(destLifeline)
+	if err != nil {
+		return -1, fmt.Errorf("spacer.CentreLine: %w", err)
+	}
+	radius := dep.sizer.Get("FoundLostDotRadius")
+	dotX := dep.sizer.Get("FramePadLR") + dep.sizer.Get("FoundLostDotPadLR") +
+		radius
+	y := mkr.edgeLabel(tidemark, s, dotX, lifelineXCoords.Centre)
+	boxes := dep.boxes[destLifeline]
+	if !boxes.HasABoxInProgress() {
+		if err := boxes.AddStartingAt(y); err != nil {
+			return -1, fmt.Errorf("boxes.AddStartingAt: %w", err)
+		}
+	}
+	toX := lifelineXCoords.Centre + mkr.boxOffset(destLifeline) -
+		0.5*dep.sizer.Get("ActivityBoxWidth")
+	mkr.edgeLine(dotX, toX, dotX, y, s)
+	mkr.extendOpenFragments(dotX - radius)
+	newTidemark = y + dep.sizer.Get("InteractionLinePadB")
+	err = mkr.addEdgeNoGoZones(
+		geom.NewSegment(tidemark, newTidemark), destLifeline, true)
+	if err != nil {
+		return -1, fmt.Errorf("mkr.addEdgeNoGoZones: %w", err)
+	}
+	return newTidemark, nil
+}
+
+/*
+lost makes the graphics for a lost message. That is one whose receiver is
+unknown, and so is drawn as an interaction line that leaves the lifeline it
+refers to, and ends at a filled dot near the right hand edge of the diagram.
+*/
+func (mkr *Maker) lost(
+	tidemark float64, s *dsl.Statement) (newTidemark float64, err error) {
+	dep := mkr.dependencies
+	sourceLifeline := s.ReferencedLifelines[0]
+	lifelineXCoords, err := dep.spacer.CentreLine(sourceLifeline)
+	if err != nil {
+		return -1, fmt.Errorf("spacer.CentreLine: %w", err)
+	}
+	radius := dep.sizer.Get("FoundLostDotRadius")
+	dotX := dep.spacer.DiagramWidth() - dep.sizer.Get("FramePadLR") -
+		dep.sizer.Get("FoundLostDotPadLR") - radius
+	y := mkr.edgeLabel(tidemark, s, lifelineXCoords.Centre, dotX)
+	if _, err := mkr.startFromBox(y, s); err != nil {
+		return -1, fmt.Errorf("mkr.startFromBox: %w", err)
+	}
+	fromX := lifelineXCoords.Centre + mkr.boxOffset(sourceLifeline) +
+		0.5*dep.sizer.Get("ActivityBoxWidth")
+	mkr.edgeLine(fromX, dotX-radius, dotX, y, s)
+	mkr.extendOpenFragments(dotX + radius)
+	newTidemark = y + dep.sizer.Get("InteractionLinePadB")
+	err = mkr.addEdgeNoGoZones(
+		geom.NewSegment(tidemark, newTidemark), sourceLifeline, false)
+	if err != nil {
+		return -1, fmt.Errorf("mkr.addEdgeNoGoZones: %w", err)
+	}
+	return newTidemark, nil
+}
+
+// edgeLabel writes the label for a found or lost message, word-wrapped to
+// fit between the lifeline and the dot. It returns the Y coordinate at which
+// the line should be drawn, beneath the label.
+func (mkr *Maker) edgeLabel(tidemark float64, s *dsl.Statement,
+	fromX, toX float64) (lineY float64) {
+	dep := mkr.dependencies
+	available := math.Abs(toX-fromX) - 0.5*dep.sizer.Get("ActivityBoxWidth") -
+		dep.sizer.Get("FoundLostDotRadius") -
+		2*dep.sizer.Get("InteractionLabelPadLR")
+	lines := mkr.wrap(s.NumberedLabel(), available)
+	labelX, horizJustification := NewLabelPosn(fromX, toX).Get()
+	mkr.graphicsModel.Primitives.RowOfStrings(
+		labelX, tidemark, dep.fontHt, horizJustification, lines)
+	return tidemark + float64(len(lines))*dep.fontHt +
+		dep.sizer.Get("InteractionLineTextPadB")
+}
+
+// edgeLine draws the line, arrow and dot for a found or lost message. The line
+// travels from fromX to toX, and the dot is centred on dotX.
+func (mkr *Maker) edgeLine(fromX, toX, dotX, y float64, s *dsl.Statement) {
+	dep := mkr.dependencies
+	prims := mkr.graphicsModel.Primitives
+	notDashed := false
+	prims.AddLine(fromX, y, toX, y, notDashed)
+	prims.AddFilledPoly(geom.MakeDot(dotX, y, dep.sizer.Get("FoundLostDotRadius")))
+	arrow := geom.MakeArrow(fromX, toX, y,
+		dep.sizer.Get("ArrowLen"), dep.sizer.Get("ArrowWidth"))
+	mkr.addArrow(arrow, s)
+}
+
+/*
+addEdgeNoGoZones registers the no go zones for a found or lost message that
+travels between the given lifeline and the left or right edge of the diagram.
+Every lifeline beyond the given one is crossed, including the outermost one.
+Neither those lifelines, nor their activity boxes, are drawn where the message
+crosses them.
+*/
+func (mkr *Maker) addEdgeNoGoZones(height geom.Segment,
+	lifelineStatement *dsl.Statement, toLeftEdge bool) error {
+	dep := mkr.dependencies
+	leftmost, rightmost := dep.spacer.Outermost()
+	outermost := rightmost
+	if toLeftEdge {
+		outermost = leftmost
+	}
+	if outermost == nil || lifelineStatement == outermost {
+		return nil
+	}
+	mkr.noGoZones = append(mkr.noGoZones,
+		nogozone.NewNoGoZone(height, lifelineStatement, outermost),
+		nogozone.NewInclusiveNoGoZone(height, outermost, outermost))
+
+	from, err := dep.spacer.CentreLine(lifelineStatement)
+	if err != nil {
+		return fmt.Errorf("spacer.CentreLine: %w", err)
+	}
+	for ll, boxes := range dep.boxes {
+		coords, err := dep.spacer.CentreLine(ll)
+		if err != nil {
+			return fmt.Errorf("spacer.CentreLine: %w", err)
+		}
+		crossed := coords.Centre > from.Centre
+		if toLeftEdge {
+			crossed = coords.Centre < from.Centre
+		}
+		if crossed {
+			boxes.AddGap(height)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package interactions
+
+import (
+	"math"
+	"testing"
+
+	"github.com/peterhoward42/umli/diag/lifeline"
+	"github.com/peterhoward42/umli/geom"
+	"github.com/peterhoward42/umli/graphics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFoundMessageComesFromADotAtTheLeftEdge(t *testing.T) {
+	assert := assert.New(t)
+	rig := newScriptTestRig(`
+		life A foo
+		life B bar
+		life C baz
+		found B fibble
+	`)
+	newTidemark, noGoZones, err := rig.maker.ScanInteractionStatements(
+		30.0, rig.dslModel.Statements())
+	assert.NoError(err)
+
+	prims := rig.model.Primitives
+	assert.Len(prims.Labels, 1)
+	assert.Equal("fibble", prims.Labels[0].TheString)
+	assert.InDelta(30.0, prims.Labels[0].Anchor.Y, tolerance)
+
+	// The line is drawn beneath the label, from the centre of the dot, to the
+	// activity box started on B.
+	y := 30.0 + rigFontHt + 5.0
+	dotX := 10.0 + 10.0 + 4.0
+	assert.Len(prims.Lines, 1)
+	line := prims.Lines[0]
+	assert.Equal(graphics.NewPoint(dotX, y), line.P1)
+	assert.InDelta(rig.centre(t, 1)-20.0, line.P2.X, tolerance)
+	assert.InDelta(y, line.P2.Y, tolerance)
+	boxes := rig.maker.dependencies.boxes[rig.lifelines[1]]
+	assert.True(boxes.HasABoxInProgress())
+	assert.InDelta(y, boxes.AsSegments()[0].Start, tolerance)
+
+	// The dot and a filled arrow head.
+	assert.Len(prims.FilledPolys, 2)
+	assert.Equal(geom.MakeDot(dotX, y, 4.0), prims.FilledPolys[0].Vertices)
+	assert.Equal(graphics.ArrowHead, prims.FilledPolys[1].Origin.Kind)
+	assert.Equal(line.P2, prims.FilledPolys[1].Vertices[1])
+
+	// Lifeline A is crossed, but B and C are not.
+	assert.InDelta(y+4.0, newTidemark, tolerance)
+	assert.Len(noGoZones, 2)
+	for _, zone := range noGoZones {
+		assert.Equal(geom.NewSegment(30.0, newTidemark), zone.Height)
+	}
+	assert.Equal(rig.lifelines[1], noGoZones[0].OneEndLifeline)
+	assert.Equal(rig.lifelines[0], noGoZones[0].OtherEndLifeline)
+	assert.False(noGoZones[0].Inclusive)
+	assert.Equal(rig.lifelines[0], noGoZones[1].OneEndLifeline)
+	assert.Equal(rig.lifelines[0], noGoZones[1].OtherEndLifeline)
+	assert.True(noGoZones[1].Inclusive)
+}
+
+func TestLostMessageGoesToADotAtTheRightEdge(t *testing.T) {
+	assert := assert.New(t)
+	rig := newScriptTestRig(`
+		life A foo
+		life B bar
+		life C baz
+		lost A fibble
+	`)
+	newTidemark, noGoZones, err := rig.maker.ScanInteractionStatements(
+		30.0, rig.dslModel.Statements())
+	assert.NoError(err)
+
+	// The line leaves an activity box started on A, and its arrow head
+	// touches the dot.
+	prims := rig.model.Primitives
+	y := 30.0 + rigFontHt + 5.0
+	dotX := 2000.0 - 10.0 - 10.0 - 4.0
+	assert.Len(prims.Lines, 1)
+	line := prims.Lines[0]
+	assert.InDelta(rig.centre(t, 0)+20.0, line.P1.X, tolerance)
+	assert.Equal(graphics.NewPoint(dotX-4.0, y), line.P2)
+	boxes := rig.maker.dependencies.boxes[rig.lifelines[0]]
+	assert.True(boxes.HasABoxInProgress())
+	assert.InDelta(y-5.0, boxes.AsSegments()[0].Start, tolerance)
+	assert.Len(prims.FilledPolys, 2)
+	assert.Equal(geom.MakeDot(dotX, y, 4.0), prims.FilledPolys[0].Vertices)
+
+	// Lifelines B and C are crossed.
+	assert.Len(noGoZones, 2)
+	assert.Equal(rig.lifelines[0], noGoZones[0].OneEndLifeline)
+	assert.Equal(rig.lifelines[2], noGoZones[0].OtherEndLifeline)
+	assert.Equal(rig.lifelines[2], noGoZones[1].OneEndLifeline)
+	assert.True(noGoZones[1].Inclusive)
+	assert.InDelta(y+4.0, newTidemark, tolerance)
+}
+
+func TestFoundAndLostMessagesAtTheOutermostLifelinesCrossNoOthers(t *testing.T) {
+	assert := assert.New(t)
+	rig := newScriptTestRig(`
+		life A foo
+		life B bar
+		found A fibble
+		lost B wibble
+	`)
+	_, noGoZones, err := rig.maker.ScanInteractionStatements(
+		30.0, rig.dslModel.Statements())
+	assert.NoError(err)
+	assert.Len(noGoZones, 0)
+}
+
+func TestFoundAndLostMessagesAreEnclosedByTheirFragment(t *testing.T) {
+	assert := assert.New(t)
+	rig := newScriptTestRig(`
+		life A foo
+		life B bar
+		loop
+		  found A fibble
+		  lost B wibble
+		end
+	`)
+	_, _, err := rig.maker.ScanInteractionStatements(
+		30.0, rig.dslModel.Statements())
+	assert.NoError(err)
+
+	frame := rig.model.Primitives.ForSourceLine(4).Lines[:4]
+	left, _, right, _ := lineExtents(frame)
+	foundDotLeft := 10.0 + 10.0
+	lostDotRight := 2000.0 - 10.0 - 10.0
+	assert.InDelta(foundDotLeft-9.0, left, tolerance)
+	assert.InDelta(lostDotRight+9.0, right, tolerance)
+}
+
+func TestLostMessagesInterruptTheActivityBoxesTheyCross(t *testing.T) {
+	assert := assert.New(t)
+	rig := newScriptTestRig(`
+		life A foo
+		life B bar
+		life C baz
+		full BC fibble
+		lost A wibble
+	`)
+	_, _, err := rig.maker.ScanInteractionStatements(
+		30.0, rig.dslModel.Statements())
+	assert.NoError(err)
+	y := rig.model.Primitives.ForSourceLine(6).Lines[0].P1.Y
+
+	for _, ll := range rig.lifelines[1:] {
+		boxes := rig.maker.dependencies.boxes[ll]
+		assert.NoError(boxes.TerminateAt(y + 50.0))
+		prims := graphics.NewPrimitives()
+		lifeline.NewBoxDrawer(*boxes, 0, 40.0, nil).Draw(prims)
+		for _, line := range prims.Lines {
+			upper := math.Min(line.P1.Y, line.P2.Y)
+			lower := math.Max(line.P1.Y, line.P2.Y)
+			assert.False(upper < y && lower > y)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package interactions
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/peterhoward42/umli/diag/nogozone"
+	"github.com/peterhoward42/umli/dsl"
+	"github.com/peterhoward42/umli/geom"
+	"github.com/peterhoward42/umli/graphics"
+)
+
+/*
+openFragment keeps track of a combined fragment (alt, loop etc.) while the
+statements inside it are being processed.
+
+None of its graphics can be drawn until the fragment is ended, because it is
+only then that we know how deep it is, and how much wider than its lifelines
+it must be made to enclose any fragments nested inside it. So in the
+meantime, it remembers the vertical positions that have been decided.
+*/
+type openFragment struct {
+	statement   *dsl.Statement
+	top         float64
+	tabBottom   float64
+	elses       []elseSeparator
+	innerLevels int     // How many levels of fragments are nested inside.
+	leftmost    float64 // Left hand extent of things like found messages.
+	rightmost   float64 // Right hand extent of things like self loops.
+	boxRight    float64 // Right hand edge of any activity box beside the tab.
+}
+
+// elseSeparator is the dashed line that separates the operands of an alt
+// or par fragment, along with the guard text that goes beneath it.
+type elseSeparator struct {
+	y        float64
+	guard    []string
+	boxRight float64 // Right hand edge of any activity box beside the guard.
+}
+
+// fragmentStart processes a statement that opens a combined fragment.
+// It claims the vertical space for the fragment's tab and guard.
+func (mkr *Maker) fragmentStart(
+	tidemark float64, s *dsl.Statement) (newTidemark float64, err error) {
+	dep := mkr.dependencies
+	top := tidemark + dep.sizer.Get("FragmentPadT")
+	tabBottom := top + mkr.tabHeight(s.LabelSegments)
+	mkr.openFragments = append(mkr.openFragments, &openFragment{
+		statement: s,
+		top:       top,
+		tabBottom: tabBottom,
+		leftmost:  math.Inf(1),
+		rightmost: math.Inf(-1),
+		boxRight:  mkr.boxRightEdge(s),
+	})
+	newTidemark = tabBottom + dep.sizer.Get("FragmentHeaderPadB")
+	return newTidemark, nil
+}
+
+// fragmentElse processes an else statement inside an alt or par fragment.
+// It claims the vertical space for the separator and its guard.
+func (mkr *Maker) fragmentElse(
+	tidemark float64, s *dsl.Statement) (newTidemark float64, err error) {
+	dep := mkr.dependencies
+	fragment, err := mkr.innermostFragment()
+	if err != nil {
+		return -1, err
+	}
+	y := tidemark + dep.sizer.Get("FragmentElsePadT")
+	fragment.elses = append(fragment.elses, elseSeparator{
+		y, s.LabelSegments, mkr.boxRightEdge(fragment.statement)})
+	newTidemark = y + mkr.guardHeight(s.LabelSegments) +
+		dep.sizer.Get("FragmentElsePadB")
+	return newTidemark, nil
+}
+
+// fragmentEnd processes an end statement. Now that the fragment's extent
+// is known, it draws the fragment's graphics.
+func (mkr *Maker) fragmentEnd(
+	tidemark float64, s *dsl.Statement) (newTidemark float64, err error) {
+	dep := mkr.dependencies
+	fragment, err := mkr.innermostFragment()
+	if err != nil {
+		return -1, err
+	}
+	mkr.openFragments = mkr.openFragments[:len(mkr.openFragments)-1]
+
+	bottom := tidemark + dep.sizer.Get("FragmentPadB")
+	left, right, err := mkr.fragmentLeftRight(fragment)
+	if err != nil {
+		return -1, fmt.Errorf("mkr.fragmentLeftRight: %w", err)
+	}
+	prims := mkr.graphicsModel.Primitives
+	mark := prims.Mark()
+	mkr.drawFragment(fragment, left, right, bottom)
+	prims.TagSince(mark, graphics.Origin{Kind: graphics.Fragment,
+		SourceLine:     fragment.statement.LineNo,
+		SequenceNumber: fragment.statement.SequenceNumber})
+
+	// The fragment that encloses this one (if any), must be made wide
+	// enough to enclose it.
+	if len(mkr.openFragments) != 0 {
+		parent := mkr.openFragments[len(mkr.openFragments)-1]
+		if fragment.innerLevels+1 > parent.innerLevels {
+			parent.innerLevels = fragment.innerLevels + 1
+		}
+		parent.leftmost = math.Min(parent.leftmost, fragment.leftmost)
+		parent.rightmost = math.Max(parent.rightmost, fragment.rightmost)
+	}
+	return bottom + dep.sizer.Get("FragmentBottomPadB"), nil
+}
+
+// drawFragment makes the graphics for a fragment, now that its extents are
+// known.
+func (mkr *Maker) drawFragment(
+	fragment *openFragment, left, right, bottom float64) {
+	dep := mkr.dependencies
+	prims := mkr.graphicsModel.Primitives
+	s := fragment.statement
+	top := fragment.top
+
+	// The enclosing rectangle.
+	prims.AddRect(left, top, right, bottom)
+
+	// The pentagon shaped tab in the top left corner that holds the
+	// fragment's operator. (The frame provides its top and left edges).
+	// The operator is kept clear of any activity box on the leftmost
+	// lifeline, and the tab is widened if need be to hold it.
+	labelPad := dep.sizer.Get("FragmentTabLabelPadL")
+	labelLeft := math.Max(left, fragment.boxRight) + labelPad
+	cut := dep.sizer.Get("FragmentTabCornerCut")
+	tabRight := math.Max(left+dep.sizer.Get("FragmentTabWidth"),
+		labelLeft+dep.measurer.Width(s.Keyword, dep.fontHt)+labelPad+cut)
+	notDashed := false
+	prims.AddLine(left, fragment.tabBottom, tabRight-cut, fragment.tabBottom,
+		notDashed)
+	prims.AddLine(tabRight-cut, fragment.tabBottom, tabRight,
+		fragment.tabBottom-cut, notDashed)
+	prims.AddLine(tabRight, fragment.tabBottom-cut, tabRight, top, notDashed)
+	textTop := top + dep.sizer.Get("FragmentTabLabelPadTB")
+	prims.AddLabel(s.Keyword, dep.fontHt, labelLeft, textTop,
+		graphics.Left, graphics.Top)
+
+	// The guard goes to the right of the tab.
+	guardLeft := tabRight + dep.sizer.Get("FragmentGuardPadL")
+	prims.RowOfStrings(guardLeft, textTop, dep.fontHt, graphics.Left,
+		s.LabelSegments)
+	mkr.addFragmentNoGoZone(s, top, fragment.tabBottom)
+
+	// The else separators and their guards.
+	dashed := true
+	for _, sep := range fragment.elses {
+		prims.AddLine(left, sep.y, right, sep.y, dashed)
+		guardTop := sep.y + dep.sizer.Get("FragmentTabLabelPadTB")
+		guardLeft := math.Max(left, sep.boxRight) +
+			dep.sizer.Get("FragmentGuardPadL")
+		prims.RowOfStrings(guardLeft, guardTop, dep.fontHt, graphics.Left,
+			sep.guard)
+		mkr.addFragmentNoGoZone(s, sep.y, sep.y+mkr.guardHeight(sep.guard))
+	}
+}
+
+// addFragmentNoGoZone prevents the lifelines spanned by fragment statement s
+// from being drawn through the text that spans them between top and bottom.
+func (mkr *Maker) addFragmentNoGoZone(s *dsl.Statement, top, bottom float64) {
+	leftmost, rightmost := mkr.outermostLifelines(s.ReferencedLifelines)
+	mkr.noGoZones = append(mkr.noGoZones, nogozone.NewInclusiveNoGoZone(
+		geom.NewSegment(top, bottom), leftmost, rightmost))
+}
+
+/*
+fragmentLeftRight works out the horizontal extent of a fragment's frame.
+It encloses the lifelines that the fragment's statements refer to, and
+anything that protrudes beyond them, like self loops, notes, and the dots of
+found and lost messages. It is widened further to enclose fragments nested
+inside it.
+*/
+func (mkr *Maker) fragmentLeftRight(fragment *openFragment) (
+	left, right float64, err error) {
+	dep := mkr.dependencies
+	leftmost, rightmost := mkr.outermostLifelines(
+		fragment.statement.ReferencedLifelines)
+	if leftmost == nil {
+		return -1, -1, errors.New("fragment spans no lifelines")
+	}
+	leftX, rightX, err := mkr.LifelineCentres(leftmost, rightmost)
+	if err != nil {
+		return -1, -1, fmt.Errorf("mkr.LifelineCentres: %w", err)
+	}
+	pad := dep.sizer.Get("FragmentPadLR")
+	protrusionPad := dep.sizer.Get("FragmentSelfLoopPadR")
+	left = math.Min(leftX-pad, fragment.leftmost-protrusionPad)
+	right = math.Max(rightX+pad, fragment.rightmost+protrusionPad)
+	inset := float64(fragment.innerLevels) * dep.sizer.Get("FragmentNestingInset")
+	return left - inset, right + inset, nil
+}
+
+/*
+boxRightEdge provides the X coordinate of the right hand edge of the
+innermost activity box in progress on the leftmost of the lifelines that
+fragment statement s spans. Or minus infinity when there is no such box.
+*/
+func (mkr *Maker) boxRightEdge(s *dsl.Statement) float64 {
+	dep := mkr.dependencies
+	leftmost, _ := mkr.outermostLifelines(s.ReferencedLifelines)
+	if leftmost == nil || !dep.boxes[leftmost].HasABoxInProgress() {
+		return math.Inf(-1)
+	}
+	coords, err := dep.spacer.CentreLine(leftmost)
+	if err != nil {
+		return math.Inf(-1)
+	}
+	return coords.Centre + mkr.boxOffset(leftmost) +
+		0.5*dep.sizer.Get("ActivityBoxWidth")
+}
+
+/*
+outermostLifelines provides from among the given lifelines, the ones that
+are leftmost and rightmost in the diagram. (Or nils when lifelines is
+empty).
+*/
+func (mkr *Maker) outermostLifelines(lifelines []*dsl.Statement) (
+	leftmost, rightmost *dsl.Statement) {
+	leftX := math.Inf(1)
+	rightX := math.Inf(-1)
+	for _, lifeline := range lifelines {
+		coords, err := mkr.dependencies.spacer.CentreLine(lifeline)
+		if err != nil {
+			continue
+		}
+		if coords.Centre < leftX {
+			leftX = coords.Centre
+			leftmost = lifeline
+		}
+		if coords.Centre > rightX {
+			rightX = coords.Centre
+			rightmost = lifeline
+		}
+	}
+	return leftmost, rightmost
+}
+
+// innermostFragment provides the most recently opened fragment that has not
+// yet been ended.
+func (mkr *Maker) innermostFragment() (*openFragment, error) {
+	if len(mkr.openFragments) == 0 {
+		return nil, errors.New("there is no fragment open")
+	}
+	return mkr.openFragments[len(mkr.openFragments)-1], nil
+}
+
+// extendOpenFragments makes sure that all the fragments currently open
+// will be made wide enough to enclose the X coordinate x.
+func (mkr *Maker) extendOpenFragments(x float64) {
+	for _, fragment := range mkr.openFragments {
+		fragment.leftmost = math.Min(fragment.leftmost, x)
+		fragment.rightmost = math.Max(fragment.rightmost, x)
+	}
+}
+
+// tabHeight provides the height of a fragment's tab, which must also be
+// tall enough to hold the guard written beside it.
+func (mkr *Maker) tabHeight(guard []string) float64 {
+	nRows := math.Max(1, float64(len(guard)))
+	return nRows*mkr.dependencies.fontHt +
+		2*mkr.dependencies.sizer.Get("FragmentTabLabelPadTB")
+}
+
+// guardHeight provides the height needed for the guard beneath an else
+// separator.
+func (mkr *Maker) guardHeight(guard []string) float64 {
+	return float64(len(guard))*mkr.dependencies.fontHt +
+		mkr.dependencies.sizer.Get("FragmentTabLabelPadTB")
+}
@@ -0,0 +1,269 @@
+package interactions
+
+import (
+	"testing"
+
+	"github.com/peterhoward42/umli/diag/lifeline"
+	"github.com/peterhoward42/umli/dsl"
+	"github.com/peterhoward42/umli/graphics"
+	"github.com/peterhoward42/umli/parser"
+	"github.com/peterhoward42/umli/sizer"
+	"github.com/peterhoward42/umli/textmetrics"
+	"github.com/stretchr/testify/assert"
+)
+
+// scriptTestRig is a DRY test helper that prepares a Maker for the
+// given script, using a sizer with easy to reason about values.
+type scriptTestRig struct {
+	maker     *Maker
+	model     *graphics.Model
+	spacer    *lifeline.Spacing
+	sizer     sizer.Sizer
+	lifelines []*dsl.Statement
+	dslModel  *dsl.Model
+}
+
+const rigFontHt = 10.0
+
+func newScriptTestRig(dslScript string) *scriptTestRig {
+	dslModel := parser.MustCompileParse(dslScript)
+	width := 2000.0
+	sizer := sizer.NewLiteralSizer(map[string]float64{
+		"ActivityBoxVerticalOverlap": 5.0,
+		"ActivityBoxWidth":           40.0,
+		"ArrowLen":                   10.0,
+		"ArrowWidth":                 4.0,
+		"IdealLifelineTitleBoxWidth": 300.0,
+		"TitleBoxLabelPadLR":         1.0,
+		"InteractionLinePadB":        4.0,
+		"InteractionLabelPadLR":      1.0,
+		"InteractionLineTextPadB":    5.0,
+		"SelfLoopHeight":             30.0,
+		"SelfLoopWidthFactor":        0.7,
+		"FragmentPadT":               3.0,
+		"FragmentPadB":               4.0,
+		"FragmentBottomPadB":         6.0,
+		"FragmentPadLR":              20.0,
+		"FragmentNestingInset":       5.0,
+		"FragmentTabWidth":           50.0,
+		"FragmentTabCornerCut":       5.0,
+		"FragmentTabLabelPadL":       5.0,
+		"FragmentTabLabelPadTB":      2.0,
+		"FragmentHeaderPadB":         7.0,
+		"FragmentGuardPadL":          8.0,
+		"FragmentElsePadT":           3.0,
+		"FragmentElsePadB":           4.0,
+		"FragmentSelfLoopPadR":       9.0,
+		"NotePadT":                   3.0,
+		"NotePadB":                   4.0,
+		"NoteTextPadTB":              2.0,
+		"NoteTextPadLR":              6.0,
+		"NoteDogEar":                 8.0,
+		"NoteOverhang":               15.0,
+		"NoteBesideGap":              7.0,
+		"NoteWidthFactor":            0.6,
+		"FramePadLR":                 10.0,
+		"FoundLostDotRadius":         4.0,
+		"FoundLostDotPadLR":          10.0,
+	})
+	lifelines := dslModel.LifelineStatements()
+	spacer := lifeline.NewSpacing(sizer, textmetrics.NewFixedPitchMeasurer(0.5),
+		rigFontHt, width, lifelines)
+	graphicsModel := graphics.NewModel(width, rigFontHt, 5.0, 1.0)
+	boxes := map[*dsl.Statement]*lifeline.BoxTracker{}
+	for _, ll := range lifelines {
+		boxes[ll] = lifeline.NewBoxTracker()
+	}
+	makerDependencies := NewMakerDependencies(
+		rigFontHt, spacer, sizer, textmetrics.NewFixedPitchMeasurer(0.5), boxes, nil)
+	return &scriptTestRig{
+		maker:     NewMaker(makerDependencies, graphicsModel),
+		model:     graphicsModel,
+		spacer:    spacer,
+		sizer:     sizer,
+		lifelines: lifelines,
+		dslModel:  dslModel,
+	}
+}
+
+func (rig *scriptTestRig) centre(t *testing.T, i int) float64 {
+	coords, err := rig.spacer.CentreLine(rig.lifelines[i])
+	assert.NoError(t, err)
+	return coords.Centre
+}
+
+func TestAltFragmentWithElseProducesCorrectGraphics(t *testing.T) {
+	assert := assert.New(t)
+	rig := newScriptTestRig(`
+		life A foo
+		life B bar
+		life C baz
+		alt [x]
+		  full AB fibble
+		else [y]
+		  dash BA fobble
+		end
+	`)
+	tideMark := 30.0
+	updatedTideMark, noGoZones, err := rig.maker.ScanInteractionStatements(
+		tideMark, rig.dslModel.Statements())
+	assert.NoError(err)
+	prims := rig.model.Primitives
+
+	// The frame should enclose lifelines A and B, (but not C), and start
+	// just below the initial tidemark.
+	top := tideMark + 3.0
+	left := rig.centre(t, 0) - 20.0
+	right := rig.centre(t, 1) + 20.0
+
+	// The tab is one row of text high, plus padding above and below.
+	tabBottom := top + 2.0 + rigFontHt + 2.0
+	assert.True(prims.ContainsLine(graphics.Line{
+		P1:     graphics.NewPoint(left, tabBottom),
+		P2:     graphics.NewPoint(left+50.0-5.0, tabBottom),
+		Dashed: false,
+	}))
+	assert.True(prims.ContainsLabel(graphics.Label{
+		TheString:  "alt",
+		FontHeight: rigFontHt,
+		Anchor:     graphics.NewPoint(left+5.0, top+2.0),
+		HJust:      graphics.Left,
+		VJust:      graphics.Top,
+	}))
+	assert.True(prims.ContainsLabel(graphics.Label{
+		TheString:  "[x]",
+		FontHeight: rigFontHt,
+		Anchor:     graphics.NewPoint(left+50.0+8.0, top+2.0),
+		HJust:      graphics.Left,
+		VJust:      graphics.Top,
+	}))
+
+	// The else separator should be a dashed line right across the frame,
+	// just below the first operand's interaction line. Its guard is kept
+	// clear of the activity box that the first operand started on A.
+	firstLineY := prims.Lines[0].P1.Y
+	elseY := firstLineY + 4.0 + 3.0
+	assert.True(prims.ContainsLine(graphics.Line{
+		P1:     graphics.NewPoint(left, elseY),
+		P2:     graphics.NewPoint(right, elseY),
+		Dashed: true,
+	}))
+	assert.True(prims.ContainsLabel(graphics.Label{
+		TheString:  "[y]",
+		FontHeight: rigFontHt,
+		Anchor:     graphics.NewPoint(rig.centre(t, 0)+20.0+8.0, elseY+2.0),
+		HJust:      graphics.Left,
+		VJust:      graphics.Top,
+	}))
+
+	// The frame's bottom should sit just below the second operand's
+	// interaction line, and the tidemark should be just below that.
+	secondLineY := prims.Lines[1].P1.Y
+	bottom := secondLineY + 4.0 + 4.0
+	assert.True(prims.ContainsRect(
+		graphics.NewPoint(left, top), graphics.NewPoint(right, bottom)))
+	assert.True(graphics.ValEqualIsh(bottom+6.0, updatedTideMark))
+
+	// The lifelines should not be drawn through the tab and guards.
+	inclusive := 0
+	for _, zone := range noGoZones {
+		if zone.Inclusive {
+			inclusive++
+			assert.Equal(rig.lifelines[0], zone.OneEndLifeline)
+			assert.Equal(rig.lifelines[1], zone.OtherEndLifeline)
+		}
+	}
+	assert.Equal(2, inclusive)
+}
+
+func TestFragmentLabelsAreKeptClearOfActivityBoxes(t *testing.T) {
+	assert := assert.New(t)
+	rig := newScriptTestRig(`
+		life A foo
+		life B bar
+		full AB fibble
+		activate A
+		par
+		  full AB fobble
+		else [y]
+		  full AB fubble
+		end
+	`)
+	_, _, err := rig.maker.ScanInteractionStatements(
+		30.0, rig.dslModel.Statements())
+	assert.NoError(err)
+	prims := rig.model.Primitives
+
+	// A has a nested box in progress throughout the fragment.
+	boxRight := rig.centre(t, 0) + lifeline.NestingOffset(1, 40.0) + 20.0
+	operator := prims.ForSourceLine(6).Labels
+	assert.Equal("par", operator[0].TheString)
+	assert.InDelta(boxRight+5.0, operator[0].Anchor.X, tolerance)
+	guard := operator[1]
+	assert.Equal("[y]", guard.TheString)
+	assert.InDelta(boxRight+8.0, guard.Anchor.X, tolerance)
+
+	// The tab is widened to hold the operator.
+	_, _, tabRight, _ := lineExtents(prims.ForSourceLine(6).Lines[4:7])
+	operatorWidth := textmetrics.NewFixedPitchMeasurer(0.5).Width(
+		"par", rigFontHt)
+	assert.InDelta(boxRight+5.0+operatorWidth+5.0+5.0, tabRight, tolerance)
+}
+
+func TestNestedFragmentsAreInsetInsideTheirParents(t *testing.T) {
+	assert := assert.New(t)
+	rig := newScriptTestRig(`
+		life A foo
+		life B bar
+		opt
+		  loop
+		    full AB fibble
+		  end
+		end
+	`)
+	_, _, err := rig.maker.ScanInteractionStatements(
+		30.0, rig.dslModel.Statements())
+	assert.NoError(err)
+	prims := rig.model.Primitives
+
+	// Each fragment draws its rectangle when it ends, so the inner one
+	// comes first.
+	innerLeft := rig.centre(t, 0) - 20.0
+	innerRight := rig.centre(t, 1) + 20.0
+	outerLeft := innerLeft - 5.0
+	outerRight := innerRight + 5.0
+	left, _, right, _ := lineExtents(prims.Lines[1:5])
+	assert.InDelta(innerLeft, left, tolerance)
+	assert.InDelta(innerRight, right, tolerance)
+
+	n := len(prims.Lines)
+	left, _, right, _ = lineExtents(prims.Lines[n-7 : n-3])
+	assert.InDelta(outerLeft, left, tolerance)
+	assert.InDelta(outerRight, right, tolerance)
+}
+
+func TestFragmentsEncloseSelfLoops(t *testing.T) {
+	assert := assert.New(t)
+	rig := newScriptTestRig(`
+		life A foo
+		life B bar
+		opt
+		  self B fibble
+		end
+	`)
+	_, _, err := rig.maker.ScanInteractionStatements(
+		30.0, rig.dslModel.Statements())
+	assert.NoError(err)
+	prims := rig.model.Primitives
+
+	loopRight := rig.centre(t, 1) + 0.5*40.0 + 0.7*rig.spacer.LifelinePitch()
+	_, _, right, _ := lineExtents(prims.Lines[3:7])
+	assert.InDelta(loopRight+9.0, right, tolerance)
+}
+
+// lineExtents provides the bounding box of the given lines.
+func lineExtents(lines []graphics.Line) (left, top, right, bottom float64) {
+	prims := graphics.NewPrimitives()
+	prims.Lines = lines
+	return prims.BoundingBoxOfLines()
+}
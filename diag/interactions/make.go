@@ -2,6 +2,7 @@ package interactions
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/peterhoward42/umli"
 	"github.com/peterhoward42/umli/diag/lifeline"
@@ -10,6 +11,7 @@ import (
 	"github.com/peterhoward42/umli/geom"
 	"github.com/peterhoward42/umli/graphics"
 	"github.com/peterhoward42/umli/sizer"
+	"github.com/peterhoward42/umli/textmetrics"
 )
 
 /*
@@ -20,6 +22,9 @@ type Maker struct {
 	dependencies  *MakerDependencies
 	graphicsModel *graphics.Model
 	noGoZones     []nogozone.NoGoZone
+	openFragments []*openFragment
+	lifespans     map[*dsl.Statement]lifeline.Lifespan
+	openReplies   bool
 }
 
 /*
@@ -28,21 +33,28 @@ process at the time that the Make method is called. And includes all
 the things the Maker needs from the outside to do its job.
 */
 type MakerDependencies struct {
-	boxes  map[*dsl.Statement]*lifeline.BoxTracker
-	fontHt float64
-	sizer  sizer.Sizer
-	spacer *lifeline.Spacing
+	boxes      map[*dsl.Statement]*lifeline.BoxTracker
+	fontHt     float64
+	sizer      sizer.Sizer
+	measurer   textmetrics.Measurer
+	spacer     *lifeline.Spacing
+	titleBoxes *lifeline.TitleBoxes
 }
 
-// NewMakerDependencies makes a MakerDependencies ready to use.
+// NewMakerDependencies makes a MakerDependencies ready to use. The
+// titleBoxes are needed only to draw lifelines that are created part way down
+// the diagram, and so may be nil for scripts that have no create statements.
 func NewMakerDependencies(fontHt float64, spacer *lifeline.Spacing,
-	sizer sizer.Sizer,
-	boxes map[*dsl.Statement]*lifeline.BoxTracker) *MakerDependencies {
+	sizer sizer.Sizer, measurer textmetrics.Measurer,
+	boxes map[*dsl.Statement]*lifeline.BoxTracker,
+	titleBoxes *lifeline.TitleBoxes) *MakerDependencies {
 	return &MakerDependencies{
-		boxes:  boxes,
-		fontHt: fontHt,
-		sizer:  sizer,
-		spacer: spacer,
+		boxes:      boxes,
+		fontHt:     fontHt,
+		sizer:      sizer,
+		measurer:   measurer,
+		spacer:     spacer,
+		titleBoxes: titleBoxes,
 	}
 }
 
@@ -53,9 +65,18 @@ func NewMaker(d *MakerDependencies, gm *graphics.Model) *Maker {
 	return &Maker{
 		dependencies:  d,
 		graphicsModel: gm,
+		lifespans:     map[*dsl.Statement]lifeline.Lifespan{},
 	}
 }
 
+/*
+Lifespans provides the lifespans of the lifelines that are created or
+destroyed part way down the diagram, as found by ScanInteractionStatements.
+*/
+func (mkr *Maker) Lifespans() map[*dsl.Statement]lifeline.Lifespan {
+	return mkr.lifespans
+}
+
 /*
 ScanInteractionStatements goes through the DSL statements in order, and
 works out what graphics are required to represent interaction lines, and
@@ -67,6 +88,8 @@ func (mkr *Maker) ScanInteractionStatements(
 	statements []*dsl.Statement) (newTidemark float64,
 	noGoZones []nogozone.NoGoZone, err error) {
 
+	mkr.openReplies = openRepliesRequested(statements)
+
 	// Build a list of actions to execute depending on the statement
 	// keyword.
 	actions := []dispatch{}
@@ -76,7 +99,7 @@ func (mkr *Maker) ScanInteractionStatements(
 			actions = append(actions, dispatch{mkr.interactionLabel, s})
 			actions = append(actions, dispatch{mkr.startToBox, s})
 			actions = append(actions, dispatch{mkr.interactionLine, s})
-		case umli.Full:
+		case umli.Full, umli.Async:
 			actions = append(actions, dispatch{mkr.interactionLabel, s})
 			actions = append(actions, dispatch{mkr.startFromBox, s})
 			actions = append(actions, dispatch{mkr.startToBox, s})
@@ -85,24 +108,63 @@ func (mkr *Maker) ScanInteractionStatements(
 			actions = append(actions, dispatch{mkr.selfLabel, s})
 			actions = append(actions, dispatch{mkr.startFromBox, s})
 			actions = append(actions, dispatch{mkr.selfLines, s})
-		case umli.Stop:
+		case umli.Found:
+			actions = append(actions, dispatch{mkr.found, s})
+		case umli.Lost:
+			actions = append(actions, dispatch{mkr.lost, s})
+		case umli.Stop, umli.Deactivate:
 			actions = append(actions, dispatch{mkr.endBox, s})
+		case umli.Activate:
+			actions = append(actions, dispatch{mkr.activate, s})
+		case umli.Create:
+			actions = append(actions, dispatch{mkr.creation, s})
+		case umli.Destroy:
+			actions = append(actions, dispatch{mkr.destruction, s})
+		case umli.Note:
+			actions = append(actions, dispatch{mkr.note, s})
+		case umli.Alt, umli.Opt, umli.Loop, umli.Par, umli.Break, umli.Critical:
+			actions = append(actions, dispatch{mkr.fragmentStart, s})
+		case umli.Else:
+			actions = append(actions, dispatch{mkr.fragmentElse, s})
+		case umli.End:
+			actions = append(actions, dispatch{mkr.fragmentEnd, s})
 		}
 	}
 	var prevTidemark float64 = tidemark
 	var updatedTidemark float64
+	prims := mkr.graphicsModel.Primitives
 	for _, action := range actions {
+		mark := prims.Mark()
 		updatedTidemark, err = action.fn(prevTidemark, action.statement)
 		if err != nil {
-			return -1, nil, fmt.Errorf("actionFn: %v", err)
+			return -1, nil, fmt.Errorf("actionFn: %w", err)
 		}
+		prims.TagSince(mark, graphics.Origin{
+			Kind:           elementKinds[action.statement.Keyword],
+			SourceLine:     action.statement.LineNo,
+			SequenceNumber: action.statement.SequenceNumber})
 		prevTidemark = updatedTidemark
 	}
 	return updatedTidemark, mkr.noGoZones, nil
 }
 
+// elementKinds says which kind of diagram element each statement keyword
+// makes. (Fragments are tagged when they are drawn, because that is when
+// the end statement is processed).
+var elementKinds = map[string]graphics.ElementKind{
+	umli.Full:    graphics.InteractionLine,
+	umli.Async:   graphics.InteractionLine,
+	umli.Dash:    graphics.InteractionLine,
+	umli.Create:  graphics.InteractionLine,
+	umli.Destroy: graphics.Lifeline,
+	umli.Self:    graphics.InteractionLine,
+	umli.Found:   graphics.InteractionLine,
+	umli.Lost:    graphics.InteractionLine,
+	umli.Note:    graphics.Note,
+}
+
 // interactionLabel creates the graphics label that belongs to an interaction
-// line.
+// line. The label is word-wrapped to fit between the lifelines.
 func (mkr *Maker) interactionLabel(
 	tidemark float64, s *dsl.Statement) (newTidemark float64, err error) {
 	dep := mkr.dependencies
@@ -110,12 +172,15 @@ func (mkr *Maker) interactionLabel(
 	destLifeline := s.ReferencedLifelines[1]
 	fromX, toX, err := mkr.LifelineCentres(sourceLifeline, destLifeline)
 	if err != nil {
-		return -1, fmt.Errorf("mkr.LifelineCentres: %v", err)
+		return -1, fmt.Errorf("mkr.LifelineCentres: %w", err)
 	}
+	available := math.Abs(toX-fromX) - dep.sizer.Get("ActivityBoxWidth") -
+		2*dep.sizer.Get("InteractionLabelPadLR")
+	lines := mkr.wrap(s.NumberedLabel(), available)
 	labelX, horizJustification := NewLabelPosn(fromX, toX).Get()
 	mkr.graphicsModel.Primitives.RowOfStrings(
-		labelX, tidemark, dep.fontHt, horizJustification, s.LabelSegments)
-	newTidemark = tidemark + float64(len(s.LabelSegments))*
+		labelX, tidemark, dep.fontHt, horizJustification, lines)
+	newTidemark = tidemark + float64(len(lines))*
 		dep.fontHt + dep.sizer.Get("InteractionLineTextPadB")
 	noGoZone := nogozone.NewNoGoZone(
 		geom.NewSegment(tidemark, newTidemark),
@@ -125,20 +190,22 @@ func (mkr *Maker) interactionLabel(
 }
 
 // selfLabel creates the graphics label that belongs to a self interaction
-// line.
+// line. The label is word-wrapped to fit above the loop.
 func (mkr *Maker) selfLabel(
 	tidemark float64, s *dsl.Statement) (newTidemark float64, err error) {
 	dep := mkr.dependencies
 	lifelineXCoords, err := dep.spacer.CentreLine(s.ReferencedLifelines[0])
 	if err != nil {
-		return -1, fmt.Errorf("spacer.CentreLine: %v", err)
+		return -1, fmt.Errorf("spacer.CentreLine: %w", err)
 	}
-	lineStartX := lifelineXCoords.Centre + 0.5*dep.sizer.Get("ActivityBoxWidth")
+	lineStartX := lifelineXCoords.Centre + 0.5*dep.sizer.Get("ActivityBoxWidth") +
+		mkr.boxOffset(s.ReferencedLifelines[0])
 	lineEndX := lineStartX + dep.sizer.Get("SelfLoopWidthFactor")*dep.spacer.LifelinePitch()
 	labelX := 0.5 * (lineStartX + lineEndX)
+	lines := mkr.wrap(s.NumberedLabel(), lineEndX-lineStartX)
 	mkr.graphicsModel.Primitives.RowOfStrings(
-		labelX, tidemark, dep.fontHt, graphics.Centre, s.LabelSegments)
-	htOfLabels := float64(len(s.LabelSegments)) * dep.fontHt
+		labelX, tidemark, dep.fontHt, graphics.Centre, lines)
+	htOfLabels := float64(len(lines)) * dep.fontHt
 	newTidemark = tidemark + htOfLabels + dep.sizer.Get("InteractionLineTextPadB")
 	return newTidemark, nil
 }
@@ -151,8 +218,10 @@ func (mkr *Maker) interactionLine(
 	destLifeline := s.ReferencedLifelines[1]
 	fromX, toX, err := mkr.LifelineCentres(sourceLifeline, destLifeline)
 	if err != nil {
-		return -1, fmt.Errorf("mkr.LifelineCentres: %v", err)
+		return -1, fmt.Errorf("mkr.LifelineCentres: %w", err)
 	}
+	fromX += mkr.boxOffset(sourceLifeline)
+	toX += mkr.boxOffset(destLifeline)
 	halfActivityBoxWidth := 0.5 * dep.sizer.Get("ActivityBoxWidth")
 	geom.ShortenLineBy(halfActivityBoxWidth, &fromX, &toX)
 	y := tidemark
@@ -161,7 +230,7 @@ func (mkr *Maker) interactionLine(
 	arrowLen := dep.sizer.Get("ArrowLen")
 	arrowWidth := dep.sizer.Get("ArrowWidth")
 	arrow := geom.MakeArrow(fromX, toX, y, arrowLen, arrowWidth)
-	mkr.graphicsModel.Primitives.AddFilledPoly(arrow)
+	mkr.addArrow(arrow, s)
 	newTidemark = tidemark + dep.sizer.Get("InteractionLinePadB")
 	noGoZone := nogozone.NewNoGoZone(
 		geom.NewSegment(tidemark, newTidemark),
@@ -177,9 +246,10 @@ func (mkr *Maker) selfLines(
 	dep := mkr.dependencies
 	lifelineXCoords, err := dep.spacer.CentreLine(s.ReferencedLifelines[0])
 	if err != nil {
-		return -1, fmt.Errorf("spacer.CentreLine: %v", err)
+		return -1, fmt.Errorf("spacer.CentreLine: %w", err)
 	}
-	lineStartX := lifelineXCoords.Centre + 0.5*dep.sizer.Get("ActivityBoxWidth")
+	lineStartX := lifelineXCoords.Centre + 0.5*dep.sizer.Get("ActivityBoxWidth") +
+		mkr.boxOffset(s.ReferencedLifelines[0])
 	lineEndX := lineStartX + dep.sizer.Get("SelfLoopWidthFactor")*dep.spacer.LifelinePitch()
 	y := tidemark
 	notDashed := false
@@ -190,11 +260,158 @@ func (mkr *Maker) selfLines(
 	arrowLen := dep.sizer.Get("ArrowLen")
 	arrowWidth := dep.sizer.Get("ArrowWidth")
 	arrow := geom.MakeArrow(lineEndX, lineStartX, bottom, arrowLen, arrowWidth)
-	mkr.graphicsModel.Primitives.AddFilledPoly(arrow)
+	mkr.addArrow(arrow, s)
+	mkr.extendOpenFragments(lineEndX)
 	newTidemark = bottom + dep.sizer.Get("InteractionLinePadB")
 	return newTidemark, nil
 }
 
+// arrowHeadStyles says how the arrow heads for each statement keyword are
+// drawn. Asynchronous messages have open heads, and the others filled ones.
+// Except that replies have open heads too, when a replies statement asks for
+// them.
+var arrowHeadStyles = map[string]graphics.ArrowHeadStyle{
+	umli.Full:   graphics.FilledArrowHead,
+	umli.Self:   graphics.FilledArrowHead,
+	umli.Found:  graphics.FilledArrowHead,
+	umli.Lost:   graphics.FilledArrowHead,
+	umli.Async:  graphics.OpenArrowHead,
+	umli.Dash:   graphics.FilledArrowHead,
+	umli.Create: graphics.OpenArrowHead,
+}
+
+/*
+creation makes the graphics for a create statement. That is the label, the
+title box for the lifeline being created, and the (dashed) interaction line
+that points at the side of that title box. The title box is centred vertically
+on the line, and the lifeline begins at the bottom of it.
+*/
+func (mkr *Maker) creation(
+	tidemark float64, s *dsl.Statement) (newTidemark float64, err error) {
+	dep := mkr.dependencies
+	sourceLifeline := s.ReferencedLifelines[0]
+	createdLifeline := s.ReferencedLifelines[1]
+	fromX, toX, err := mkr.LifelineCentres(sourceLifeline, createdLifeline)
+	if err != nil {
+		return -1, fmt.Errorf("mkr.LifelineCentres: %w", err)
+	}
+	createdXCoords, err := dep.spacer.CentreLine(createdLifeline)
+	if err != nil {
+		return -1, fmt.Errorf("spacer.CentreLine: %w", err)
+	}
+	edgeX := createdXCoords.Left
+	if toX < fromX {
+		edgeX = createdXCoords.Right
+	}
+	fromX += mkr.boxOffset(sourceLifeline)
+	halfActivityBoxWidth := 0.5 * dep.sizer.Get("ActivityBoxWidth")
+
+	// The label goes between the source lifeline and the title box.
+	available := math.Abs(edgeX-fromX) - halfActivityBoxWidth -
+		2*dep.sizer.Get("InteractionLabelPadLR")
+	lines := mkr.wrap(s.NumberedLabel(), available)
+	labelHeight := float64(len(lines))*dep.fontHt +
+		dep.sizer.Get("InteractionLineTextPadB")
+
+	// The title box must not reach up above the tidemark. And when it is
+	// the taller of the two, the label sits just above the line, not up at
+	// the tidemark.
+	boxHeight, labelsHeight := dep.titleBoxes.Height()
+	y := math.Max(tidemark+labelHeight, tidemark+0.5*boxHeight)
+	labelX, horizJustification := NewLabelPosn(fromX, edgeX).Get()
+	prims := mkr.graphicsModel.Primitives
+	prims.RowOfStrings(
+		labelX, y-labelHeight, dep.fontHt, horizJustification, lines)
+	topOfBox := y - 0.5*boxHeight
+	err = dep.titleBoxes.MakeOne(
+		createdLifeline, topOfBox, boxHeight, labelsHeight, prims)
+	if err != nil {
+		return -1, fmt.Errorf("titleBoxes.MakeOne: %w", err)
+	}
+	lifespan := mkr.lifespans[createdLifeline]
+	lifespan.Created = true
+	lifespan.Top = topOfBox + boxHeight
+	mkr.lifespans[createdLifeline] = lifespan
+
+	if _, err := mkr.startFromBox(y, s); err != nil {
+		return -1, fmt.Errorf("mkr.startFromBox: %w", err)
+	}
+	if edgeX > fromX {
+		fromX += halfActivityBoxWidth
+	} else {
+		fromX -= halfActivityBoxWidth
+	}
+	dashed := true
+	prims.AddLine(fromX, y, edgeX, y, dashed)
+	arrow := geom.MakeArrow(fromX, edgeX, y,
+		dep.sizer.Get("ArrowLen"), dep.sizer.Get("ArrowWidth"))
+	mkr.addArrow(arrow, s)
+	newTidemark = topOfBox + boxHeight + dep.sizer.Get("TitleBoxPadB")
+	noGoZone := nogozone.NewNoGoZone(
+		geom.NewSegment(tidemark, y+dep.sizer.Get("InteractionLinePadB")),
+		sourceLifeline, createdLifeline)
+	mkr.noGoZones = append(mkr.noGoZones, noGoZone)
+	return newTidemark, nil
+}
+
+/*
+destruction makes the graphics for a destroy statement. That is the X that
+marks the end of the lifeline. It also terminates any activity boxes that are
+in progress on the lifeline.
+*/
+func (mkr *Maker) destruction(
+	tidemark float64, s *dsl.Statement) (newTidemark float64, err error) {
+	dep := mkr.dependencies
+	destroyedLifeline := s.ReferencedLifelines[0]
+	lifelineXCoords, err := dep.spacer.CentreLine(destroyedLifeline)
+	if err != nil {
+		return -1, fmt.Errorf("spacer.CentreLine: %w", err)
+	}
+	half := 0.5 * dep.sizer.Get("DestroyMarkSize")
+	x := lifelineXCoords.Centre
+	y := tidemark + half
+	boxes := dep.boxes[destroyedLifeline]
+	for boxes.HasABoxInProgress() {
+		if err := boxes.TerminateAt(y); err != nil {
+			return -1, fmt.Errorf("boxes.TerminateAt: %w", err)
+		}
+	}
+	notDashed := false
+	prims := mkr.graphicsModel.Primitives
+	prims.AddLine(x-half, y-half, x+half, y+half, notDashed)
+	prims.AddLine(x-half, y+half, x+half, y-half, notDashed)
+	lifespan := mkr.lifespans[destroyedLifeline]
+	lifespan.Destroyed = true
+	lifespan.Bottom = y
+	mkr.lifespans[destroyedLifeline] = lifespan
+	return y + half + dep.sizer.Get("DestroyMarkPadB"), nil
+}
+
+// addArrow adds an arrow head with the given vertices, tagged as having come
+// from statement s. In the style that suits the statement.
+func (mkr *Maker) addArrow(vertices []graphics.Point, s *dsl.Statement) {
+	prims := mkr.graphicsModel.Primitives
+	mark := prims.Mark()
+	style := arrowHeadStyles[s.Keyword]
+	if s.Keyword == umli.Dash && mkr.openReplies {
+		style = graphics.OpenArrowHead
+	}
+	prims.AddArrowHead(vertices, style)
+	prims.TagSince(mark, graphics.Origin{Kind: graphics.ArrowHead,
+		SourceLine: s.LineNo, SequenceNumber: s.SequenceNumber})
+}
+
+// openRepliesRequested returns true if the first of any replies statements
+// asks for replies to be drawn with open arrow heads.
+func openRepliesRequested(statements []*dsl.Statement) bool {
+	for _, s := range statements {
+		if s.Keyword == umli.Replies {
+			return s.OpenReplies
+		}
+	}
+	return false
+}
+
 // startToBox registers with a lifeline.BoxTracker that an activity box
 // on a lifeline should be started ready for an interaction line to arrive at
 // the top of it. (If a box is not already in progress for this lifeline.)
@@ -207,7 +424,7 @@ func (mkr *Maker) startToBox(
 		return tidemark, nil
 	}
 	if err := boxes.AddStartingAt(tidemark); err != nil {
-		return -1, fmt.Errorf("boxes.AddStartingAt: %v", err)
+		return -1, fmt.Errorf("boxes.AddStartingAt: %w", err)
 	}
 	// Return an unchanged tidemark.
 	return tidemark, nil
@@ -229,13 +446,36 @@ func (mkr *Maker) startFromBox(
 	// line label is sufficient.
 	backTrackToStart := dep.sizer.Get("ActivityBoxVerticalOverlap")
 	if err := boxes.AddStartingAt(tidemark - backTrackToStart); err != nil {
-		return -1, fmt.Errorf("boxes.AddStartingAt: %v", err)
+		return -1, fmt.Errorf("boxes.AddStartingAt: %w", err)
 	}
 	// Return an unchanged tidemark.
 	return tidemark, nil
 }
 
-// endBox processes an explicit "stop" statement.
+// activate processes an explicit "activate" statement, by starting a new
+// activity box - nested inside any that are already in progress.
+func (mkr *Maker) activate(
+	tidemark float64, s *dsl.Statement) (newTidemark float64, err error) {
+	boxes := mkr.dependencies.boxes[s.ReferencedLifelines[0]]
+	boxes.AddNestedStartingAt(tidemark)
+	// Return an unchanged tidemark.
+	return tidemark, nil
+}
+
+// boxOffset provides how far to the right of the given lifeline, the
+// innermost activity box in progress on it is drawn. Interaction lines
+// should meet that box rather than the lifeline.
+func (mkr *Maker) boxOffset(lifelineStatement *dsl.Statement) float64 {
+	boxes := mkr.dependencies.boxes[lifelineStatement]
+	if boxes.Depth() < 2 {
+		return 0
+	}
+	return lifeline.NestingOffset(
+		boxes.Depth()-1, mkr.dependencies.sizer.Get("ActivityBoxWidth"))
+}
+
+// endBox processes an explicit "stop" or "deactivate" statement, by
+// terminating the innermost activity box in progress.
 func (mkr *Maker) endBox(
 	tidemark float64, s *dsl.Statement) (newTidemark float64, err error) {
 	dep := mkr.dependencies
@@ -243,7 +483,7 @@ func (mkr *Maker) endBox(
 	boxes := dep.boxes[fromLifeline]
 	err = boxes.TerminateAt(tidemark)
 	if err != nil {
-		return -1, fmt.Errorf("boxes.TerminateAt: %v", err)
+		return -1, fmt.Errorf("boxes.TerminateAt: %w", err)
 	}
 	tidemark += dep.sizer.Get("IndividualStoppedBoxPadB")
 	return tidemark, nil
@@ -263,6 +503,13 @@ type dispatch struct {
 	statement *dsl.Statement
 }
 
+// wrap word-wraps the given lines of label text to fit into the available
+// width.
+func (mkr *Maker) wrap(lines []string, available float64) []string {
+	dep := mkr.dependencies
+	return textmetrics.Wrap(dep.measurer, lines, dep.fontHt, available)
+}
+
 /*
 LifelineCentres evaluates the X coordinates for the lifelines between which
 an interaction line travels.
@@ -271,11 +518,11 @@ func (mkr *Maker) LifelineCentres(
 	sourceLifeline, destLifeline *dsl.Statement) (fromX, toX float64, err error) {
 	fromCoords, err := mkr.dependencies.spacer.CentreLine(sourceLifeline)
 	if err != nil {
-		return -1.0, -1.0, fmt.Errorf("space.CentreLine: %v", err)
+		return -1.0, -1.0, fmt.Errorf("space.CentreLine: %w", err)
 	}
 	toCoords, err := mkr.dependencies.spacer.CentreLine(destLifeline)
 	if err != nil {
-		return -1.0, -1.0, fmt.Errorf("space.CentreLine: %v", err)
+		return -1.0, -1.0, fmt.Errorf("space.CentreLine: %w", err)
 	}
 	return fromCoords.Centre, toCoords.Centre, nil
 }
@@ -1,6 +1,7 @@
 package interactions
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/peterhoward42/umli/diag/lifeline"
@@ -8,6 +9,7 @@ import (
 	"github.com/peterhoward42/umli/graphics"
 	"github.com/peterhoward42/umli/parser"
 	"github.com/peterhoward42/umli/sizer"
+	"github.com/peterhoward42/umli/textmetrics"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -28,11 +30,14 @@ func TestForSingleInteractionLineItProducesCorrectGraphicsAndSideEffects(t *test
 		"ArrowLen":                   10.0,
 		"ArrowWidth":                 4.0,
 		"IdealLifelineTitleBoxWidth": 300.0,
+		"TitleBoxLabelPadLR":         1.0,
 		"InteractionLinePadB":        4.0,
+		"InteractionLabelPadLR":      1.0,
 		"InteractionLineTextPadB":    5.0,
 	})
 	lifelines := dslModel.LifelineStatements()
-	spacer := lifeline.NewSpacing(sizer, fontHt, width, lifelines)
+	spacer := lifeline.NewSpacing(sizer, textmetrics.NewFixedPitchMeasurer(0.5),
+		fontHt, width, lifelines)
 	dashLineDashLength := 5.0
 	dashLineGapLength := 1.0
 	graphicsModel := graphics.NewModel(
@@ -43,7 +48,7 @@ func TestForSingleInteractionLineItProducesCorrectGraphicsAndSideEffects(t *test
 		boxes[ll] = lifeline.NewBoxTracker()
 	}
 	makerDependencies := NewMakerDependencies(
-		fontHt, spacer, sizer, boxes)
+		fontHt, spacer, sizer, textmetrics.NewFixedPitchMeasurer(0.5), boxes, nil)
 	interactionsMaker := NewMaker(makerDependencies, graphicsModel)
 	tideMark := 30.0
 	updatedTideMark, noGoZones, err := interactionsMaker.ScanInteractionStatements(
@@ -151,11 +156,14 @@ func TestForSmallDifferencesInDashVsFullInteractions(t *testing.T) {
 		"ArrowLen":                   10.0,
 		"ArrowWidth":                 4.0,
 		"IdealLifelineTitleBoxWidth": 300.0,
+		"TitleBoxLabelPadLR":         1.0,
 		"InteractionLinePadB":        4.0,
+		"InteractionLabelPadLR":      1.0,
 		"InteractionLineTextPadB":    5.0,
 	})
 	lifelines := dslModel.LifelineStatements()
-	spacer := lifeline.NewSpacing(sizer, fontHt, width, lifelines)
+	spacer := lifeline.NewSpacing(sizer, textmetrics.NewFixedPitchMeasurer(0.5),
+		fontHt, width, lifelines)
 	dashLineDashLength := 5.0
 	dashLineGapLength := 1.0
 	graphicsModel := graphics.NewModel(
@@ -166,7 +174,7 @@ func TestForSmallDifferencesInDashVsFullInteractions(t *testing.T) {
 		boxes[ll] = lifeline.NewBoxTracker()
 	}
 	makerDependencies := NewMakerDependencies(
-		fontHt, spacer, sizer, boxes)
+		fontHt, spacer, sizer, textmetrics.NewFixedPitchMeasurer(0.5), boxes, nil)
 	interactionsMaker := NewMaker(makerDependencies, graphicsModel)
 	tideMark := 30.0
 	_, _, err := interactionsMaker.ScanInteractionStatements(
@@ -206,13 +214,16 @@ func TestSelfInteraction(t *testing.T) {
 		"ArrowLen":                   10.0,
 		"ArrowWidth":                 4.0,
 		"IdealLifelineTitleBoxWidth": 300.0,
+		"TitleBoxLabelPadLR":         1.0,
 		"InteractionLinePadB":        4.0,
+		"InteractionLabelPadLR":      1.0,
 		"InteractionLineTextPadB":    5.0,
 		"SelfLoopHeight":             30.0,
 		"SelfLoopWidthFactor":        0.7,
 	})
 	lifelines := dslModel.LifelineStatements()
-	spacer := lifeline.NewSpacing(sizer, fontHt, width, lifelines)
+	spacer := lifeline.NewSpacing(sizer, textmetrics.NewFixedPitchMeasurer(0.5),
+		fontHt, width, lifelines)
 	dashLineDashLength := 5.0
 	dashLineGapLength := 1.0
 	graphicsModel := graphics.NewModel(
@@ -223,7 +234,7 @@ func TestSelfInteraction(t *testing.T) {
 		boxes[ll] = lifeline.NewBoxTracker()
 	}
 	makerDependencies := NewMakerDependencies(
-		fontHt, spacer, sizer, boxes)
+		fontHt, spacer, sizer, textmetrics.NewFixedPitchMeasurer(0.5), boxes, nil)
 	interactionsMaker := NewMaker(makerDependencies, graphicsModel)
 	tideMark := 30.0
 	updatedTideMark, noGoZones, err := interactionsMaker.ScanInteractionStatements(
@@ -328,12 +339,15 @@ func TestWhenExplicitStopIsPresent(t *testing.T) {
 		"ArrowLen":                   10.0,
 		"ArrowWidth":                 4.0,
 		"IdealLifelineTitleBoxWidth": 300.0,
+		"TitleBoxLabelPadLR":         1.0,
 		"IndividualStoppedBoxPadB":   3.0,
 		"InteractionLinePadB":        4.0,
+		"InteractionLabelPadLR":      1.0,
 		"InteractionLineTextPadB":    5.0,
 	})
 	lifelines := dslModel.LifelineStatements()
-	spacer := lifeline.NewSpacing(sizer, fontHt, width, lifelines)
+	spacer := lifeline.NewSpacing(sizer, textmetrics.NewFixedPitchMeasurer(0.5),
+		fontHt, width, lifelines)
 	dashLineDashLength := 5.0
 	dashLineGapLength := 1.0
 	graphicsModel := graphics.NewModel(
@@ -344,7 +358,7 @@ func TestWhenExplicitStopIsPresent(t *testing.T) {
 		boxes[ll] = lifeline.NewBoxTracker()
 	}
 	makerDependencies := NewMakerDependencies(
-		fontHt, spacer, sizer, boxes)
+		fontHt, spacer, sizer, textmetrics.NewFixedPitchMeasurer(0.5), boxes, nil)
 	interactionsMaker := NewMaker(makerDependencies, graphicsModel)
 	tideMark := 30.0
 	updatedTideMark, _, err := interactionsMaker.ScanInteractionStatements(
@@ -368,3 +382,31 @@ func TestWhenExplicitStopIsPresent(t *testing.T) {
 }
 
 const tolerance = 0.001
+
+func TestLongInteractionLabelsAreWrappedAndClaimMoreSpace(t *testing.T) {
+	assert := assert.New(t)
+	rig := newScriptTestRig(`
+		life A foo
+		life B bar
+		full AB ` + strings.Repeat("word ", 40))
+	tideMark := 30.0
+	updatedTideMark, _, err := rig.maker.ScanInteractionStatements(
+		tideMark, rig.dslModel.Statements())
+	assert.NoError(err)
+	prims := rig.model.Primitives
+
+	// The space available between the lifelines is the pitch, less the
+	// activity box width, and the label padding. Every character is half a
+	// font height wide, so that allows 29 words on the first line.
+	assert.Len(prims.Labels, 2)
+	assert.Equal(strings.TrimSpace(strings.Repeat("word ", 29)),
+		prims.Labels[0].TheString)
+	assert.Equal(strings.TrimSpace(strings.Repeat("word ", 11)),
+		prims.Labels[1].TheString)
+	available := rig.spacer.LifelinePitch() - 40.0 - 2*1.0
+	assert.True(float64(len(prims.Labels[0].TheString))*5.0 <= available)
+
+	// The interaction line sits below both rows of text.
+	assert.Equal(tideMark+2*rigFontHt+5.0, prims.Lines[0].P1.Y)
+	assert.Equal(tideMark+2*rigFontHt+5.0+4.0, updatedTideMark)
+}
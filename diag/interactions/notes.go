@@ -0,0 +1,106 @@
+package interactions
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/peterhoward42/umli/diag/nogozone"
+	"github.com/peterhoward42/umli/dsl"
+	"github.com/peterhoward42/umli/geom"
+	"github.com/peterhoward42/umli/graphics"
+)
+
+/*
+note makes the graphics for a note statement. The note is drawn as a box with
+a dog-eared top right corner, holding the note's (word-wrapped) text. It
+either sits to the right of a single lifeline, or spans the lifelines it refers
+to. Neither the lifelines nor their activity boxes are drawn through it.
+*/
+func (mkr *Maker) note(
+	tidemark float64, s *dsl.Statement) (newTidemark float64, err error) {
+	dep := mkr.dependencies
+	left, right, err := mkr.noteLeftRight(s)
+	if err != nil {
+		return -1, fmt.Errorf("mkr.noteLeftRight: %w", err)
+	}
+	textPadTB := dep.sizer.Get("NoteTextPadTB")
+	textPadLR := dep.sizer.Get("NoteTextPadLR")
+	lines := mkr.wrap(s.LabelSegments, right-left-2*textPadLR)
+	top := tidemark + dep.sizer.Get("NotePadT")
+	bottom := top + float64(len(lines))*dep.fontHt + 2*textPadTB
+	mkr.dogEaredBox(left, top, right, bottom)
+	mkr.graphicsModel.Primitives.RowOfStrings(
+		left+textPadLR, top+textPadTB, dep.fontHt, graphics.Left, lines)
+
+	// A note that spans lifelines must not have them drawn through it.
+	if s.NoteOver {
+		leftmost, rightmost := mkr.outermostLifelines(s.ReferencedLifelines)
+		mkr.noGoZones = append(mkr.noGoZones, nogozone.NewInclusiveNoGoZone(
+			geom.NewSegment(top, bottom), leftmost, rightmost))
+	}
+	for ll, boxes := range dep.boxes {
+		coords, err := dep.spacer.CentreLine(ll)
+		if err != nil {
+			return -1, fmt.Errorf("spacer.CentreLine: %w", err)
+		}
+		if coords.Centre >= left && coords.Centre <= right {
+			boxes.AddGap(geom.NewSegment(top, bottom))
+		}
+	}
+	mkr.extendOpenFragments(left)
+	mkr.extendOpenFragments(right)
+	return bottom + dep.sizer.Get("NotePadB"), nil
+}
+
+/*
+noteLeftRight works out the horizontal extent of a note. A note beside a
+lifeline starts just clear of its (innermost) activity box. A note over
+lifelines overhangs the outermost of them, but is never made narrower than a
+note beside a lifeline would be. Either is cut short if need be, to keep it
+inside the frame.
+*/
+func (mkr *Maker) noteLeftRight(s *dsl.Statement) (left, right float64,
+	err error) {
+	dep := mkr.dependencies
+	leftmost, rightmost := mkr.outermostLifelines(s.ReferencedLifelines)
+	if leftmost == nil {
+		return -1, -1, errors.New("note refers to no lifelines")
+	}
+	leftX, rightX, err := mkr.LifelineCentres(leftmost, rightmost)
+	if err != nil {
+		return -1, -1, fmt.Errorf("mkr.LifelineCentres: %w", err)
+	}
+	width := dep.sizer.Get("NoteWidthFactor") * dep.spacer.LifelinePitch()
+	if s.NoteOver {
+		overhang := dep.sizer.Get("NoteOverhang")
+		width = math.Max(width, rightX-leftX+2*overhang)
+		middle := 0.5 * (leftX + rightX)
+		left, right = middle-0.5*width, middle+0.5*width
+	} else {
+		left = leftX + mkr.boxOffset(leftmost) +
+			0.5*dep.sizer.Get("ActivityBoxWidth") + dep.sizer.Get("NoteBesideGap")
+		right = left + width
+	}
+	margin := dep.sizer.Get("FramePadLR") + dep.sizer.Get("NoteBesideGap")
+	left = math.Max(left, margin)
+	right = math.Min(right, dep.spacer.DiagramWidth()-margin)
+	return left, right, nil
+}
+
+// dogEaredBox draws the outline of a note, with its top right corner folded
+// over.
+func (mkr *Maker) dogEaredBox(left, top, right, bottom float64) {
+	prims := mkr.graphicsModel.Primitives
+	ear := mkr.dependencies.sizer.Get("NoteDogEar")
+	notDashed := false
+	prims.AddLine(left, top, right-ear, top, notDashed)
+	prims.AddLine(right-ear, top, right, top+ear, notDashed)
+	prims.AddLine(right, top+ear, right, bottom, notDashed)
+	prims.AddLine(right, bottom, left, bottom, notDashed)
+	prims.AddLine(left, bottom, left, top, notDashed)
+
+	// The fold.
+	prims.AddLine(right-ear, top, right-ear, top+ear, notDashed)
+	prims.AddLine(right-ear, top+ear, right, top+ear, notDashed)
+}
@@ -0,0 +1,169 @@
+package interactions
+
+import (
+	"math"
+	"testing"
+
+	"github.com/peterhoward42/umli/diag/lifeline"
+	"github.com/peterhoward42/umli/graphics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNoteBesideALifelineProducesCorrectGraphics(t *testing.T) {
+	assert := assert.New(t)
+	rig := newScriptTestRig(`
+		life A foo
+		life B bar
+		note A fibble | fobble
+	`)
+	tideMark := 30.0
+	updatedTideMark, noGoZones, err := rig.maker.ScanInteractionStatements(
+		tideMark, rig.dslModel.Statements())
+	assert.NoError(err)
+	prims := rig.model.Primitives
+
+	left := rig.centre(t, 0) + 0.5*40.0 + 7.0
+	right := left + 0.6*rig.spacer.LifelinePitch()
+	top := tideMark + 3.0
+	bottom := top + 2.0 + 2*rigFontHt + 2.0
+
+	// The outline, with its dog ear.
+	assert.Len(prims.Lines, 7)
+	assert.True(prims.ContainsLine(graphics.Line{
+		P1:     graphics.NewPoint(left, top),
+		P2:     graphics.NewPoint(right-8.0, top),
+		Dashed: false,
+	}))
+	assert.True(prims.ContainsLine(graphics.Line{
+		P1:     graphics.NewPoint(right-8.0, top),
+		P2:     graphics.NewPoint(right, top+8.0),
+		Dashed: false,
+	}))
+	assert.True(prims.ContainsLine(graphics.Line{
+		P1:     graphics.NewPoint(right, bottom),
+		P2:     graphics.NewPoint(left, bottom),
+		Dashed: false,
+	}))
+
+	assert.True(prims.ContainsLabel(graphics.Label{
+		TheString:  "fobble",
+		FontHeight: rigFontHt,
+		Anchor:     graphics.NewPoint(left+6.0, top+2.0+rigFontHt),
+		HJust:      graphics.Left,
+		VJust:      graphics.Top,
+	}))
+	assert.True(graphics.ValEqualIsh(bottom+4.0, updatedTideMark))
+
+	// It does not sit on any lifeline, so need not interrupt them.
+	assert.Len(noGoZones, 0)
+}
+
+func TestNoteOverLifelinesSpansThemAndInterruptsThem(t *testing.T) {
+	assert := assert.New(t)
+	rig := newScriptTestRig(`
+		life A foo
+		life B bar
+		life C baz
+		note over A..C fibble
+	`)
+	tideMark := 30.0
+	_, noGoZones, err := rig.maker.ScanInteractionStatements(
+		tideMark, rig.dslModel.Statements())
+	assert.NoError(err)
+	prims := rig.model.Primitives
+
+	left, top, right, bottom := lineExtents(prims.Lines)
+	assert.InDelta(rig.centre(t, 0)-15.0, left, tolerance)
+	assert.InDelta(rig.centre(t, 2)+15.0, right, tolerance)
+
+	assert.Len(noGoZones, 1)
+	zone := noGoZones[0]
+	assert.True(zone.Inclusive)
+	assert.Equal(rig.lifelines[0], zone.OneEndLifeline)
+	assert.Equal(rig.lifelines[2], zone.OtherEndLifeline)
+	assert.InDelta(top, zone.Height.Start, tolerance)
+	assert.InDelta(bottom, zone.Height.End, tolerance)
+}
+
+func TestNoteOverOneLifelineIsCentredOnIt(t *testing.T) {
+	assert := assert.New(t)
+	rig := newScriptTestRig(`
+		life A foo
+		life B bar
+		note over B fibble
+	`)
+	_, _, err := rig.maker.ScanInteractionStatements(
+		30.0, rig.dslModel.Statements())
+	assert.NoError(err)
+
+	left, _, right, _ := lineExtents(rig.model.Primitives.Lines)
+	halfWidth := 0.5 * 0.6 * rig.spacer.LifelinePitch()
+	assert.InDelta(rig.centre(t, 1)-halfWidth, left, tolerance)
+	assert.InDelta(rig.centre(t, 1)+halfWidth, right, tolerance)
+}
+
+func TestNoteBesideABusyLifelineIsClearOfItsNestedBox(t *testing.T) {
+	assert := assert.New(t)
+	rig := newScriptTestRig(`
+		life A foo
+		life B bar
+		full AB fibble
+		activate A
+		note A fobble
+	`)
+	_, _, err := rig.maker.ScanInteractionStatements(
+		30.0, rig.dslModel.Statements())
+	assert.NoError(err)
+
+	note := rig.model.Primitives.ForSourceLine(6)
+	left, _, _, _ := lineExtents(note.Lines)
+	boxRight := rig.centre(t, 0) + lifeline.NestingOffset(1, 40.0) + 20.0
+	assert.InDelta(boxRight+7.0, left, tolerance)
+}
+
+func TestNotesAreKeptInsideTheFrame(t *testing.T) {
+	assert := assert.New(t)
+	rig := newScriptTestRig(`
+		life A foo
+		life B bar
+		life C baz
+		life D qux
+		life E quux
+		life F corge
+		note F fibble
+	`)
+	_, _, err := rig.maker.ScanInteractionStatements(
+		30.0, rig.dslModel.Statements())
+	assert.NoError(err)
+
+	_, _, right, _ := lineExtents(rig.model.Primitives.Lines)
+	assert.InDelta(2000.0-10.0-7.0, right, tolerance)
+}
+
+func TestNoteOverLifelinesInterruptsTheirActivityBoxes(t *testing.T) {
+	assert := assert.New(t)
+	rig := newScriptTestRig(`
+		life A foo
+		life B bar
+		full AB fibble
+		note over A..B fobble
+	`)
+	_, _, err := rig.maker.ScanInteractionStatements(
+		30.0, rig.dslModel.Statements())
+	assert.NoError(err)
+	_, top, _, bottom := lineExtents(rig.model.Primitives.ForSourceLine(5).Lines)
+
+	// Neither lifeline's box has a side drawn through the note.
+	for _, ll := range rig.lifelines {
+		boxes := rig.maker.dependencies.boxes[ll]
+		assert.NoError(boxes.TerminateAt(bottom + 10.0))
+		prims := graphics.NewPrimitives()
+		lifeline.NewBoxDrawer(*boxes, 0, 40.0, nil).Draw(prims)
+		assert.Len(prims.Lines, 6)
+		for _, line := range prims.Lines {
+			upper := math.Min(line.P1.Y, line.P2.Y)
+			lower := math.Max(line.P1.Y, line.P2.Y)
+			assert.False(upper < bottom && lower > top)
+		}
+	}
+}
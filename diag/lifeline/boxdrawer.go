@@ -1,35 +1,107 @@
 package lifeline
 
 import (
+	"github.com/peterhoward42/umli/geom"
 	"github.com/peterhoward42/umli/graphics"
 )
 
 // BoxDrawer knows how to draw the lines required to represent the
 // activity boxes on a lifeline - as specified by an BoxTracker object.
+// It can also fill the boxes.
 type BoxDrawer struct {
 	boxes    BoxTracker
 	centreX  float64
 	boxWidth float64
+	fill     *graphics.FillStyle
 }
 
-// NewBoxDrawer provides an BoxDrawer ready to use.
+// NewBoxDrawer provides an BoxDrawer ready to use. The boxes are filled
+// in the given style, unless fill is nil, in which case they are not filled.
 func NewBoxDrawer(boxes BoxTracker, centreX float64,
-	boxWidth float64) *BoxDrawer {
+	boxWidth float64, fill *graphics.FillStyle) *BoxDrawer {
 	return &BoxDrawer{
 		boxes:    boxes,
 		centreX:  centreX,
 		boxWidth: boxWidth,
+		fill:     fill,
 	}
 }
 
-// Draw creates the lines required, and add them to prims.
+// Draw creates the lines (and fills) required, and add them to prims.
+// Nested boxes are drawn offset to the right of the box that encloses them,
+// and the enclosing box's right hand edge is left out where it would otherwise
+// cut through them. The boxes' sides and fills are left out in the gaps that
+// the BoxTracker holds.
 func (abc *BoxDrawer) Draw(prims *graphics.Primitives) {
 	dx := 0.5 * abc.boxWidth
-	for _, segment := range abc.boxes.AsSegments() {
-		left := abc.centreX - dx
-		right := abc.centreX + dx
-		top := segment.Start
-		bottom := segment.End
-		prims.AddRect(left, top, right, bottom)
+	boxes := abc.boxes.AsBoxes()
+	for _, box := range boxes {
+		centreX := abc.centreX + NestingOffset(box.Depth, abc.boxWidth)
+		left := centreX - dx
+		right := centreX + dx
+		top := box.Start
+		bottom := box.End
+		sides := visibleParts(box.Segment, abc.boxes.gaps)
+		if abc.fill != nil {
+			for _, side := range sides {
+				prims.AddFilledPoly([]graphics.Point{
+					{X: left, Y: side.Start}, {X: right, Y: side.Start},
+					{X: right, Y: side.End}, {X: left, Y: side.End}})
+				prims.FilledPolys[len(prims.FilledPolys)-1].Style = abc.fill
+			}
+		}
+		prims.AddLine(left, top, right, top, false)
+		rightGaps := append([]geom.Segment{}, abc.boxes.gaps...)
+		for _, nested := range abc.nestedDirectlyInside(box, boxes) {
+			rightGaps = append(rightGaps, nested.Segment)
+		}
+		for _, part := range visibleParts(box.Segment, rightGaps) {
+			prims.AddLine(right, part.Start, right, part.End, false)
+		}
+		prims.AddLine(right, bottom, left, bottom, false)
+		for _, side := range sides {
+			prims.AddLine(left, side.End, left, side.Start, false)
+		}
 	}
 }
+
+// visibleParts provides the parts of seg that are not covered by any of the
+// gaps, (in top to bottom order).
+func visibleParts(seg geom.Segment, gaps []geom.Segment) []geom.Segment {
+	sorted := append([]geom.Segment{}, gaps...)
+	geom.SortSegments(sorted)
+	parts := []geom.Segment{}
+	y := seg.Start
+	for _, gap := range geom.MergeSegments(sorted) {
+		if gap.End <= y || gap.Start >= seg.End {
+			continue
+		}
+		if gap.Start > y {
+			parts = append(parts, geom.NewSegment(y, gap.Start))
+		}
+		y = gap.End
+	}
+	if y < seg.End {
+		parts = append(parts, geom.NewSegment(y, seg.End))
+	}
+	return parts
+}
+
+// nestedDirectlyInside provides those of the boxes that are nested
+// immediately inside outer, (in the order they start).
+func (abc *BoxDrawer) nestedDirectlyInside(outer Box, boxes []Box) []Box {
+	nested := []Box{}
+	for _, box := range boxes {
+		if box.Depth == outer.Depth+1 && box.Start >= outer.Start &&
+			box.End <= outer.End {
+			nested = append(nested, box)
+		}
+	}
+	return nested
+}
+
+// NestingOffset provides how far to the right of its lifeline a box that is
+// nested to the given depth should be drawn.
+func NestingOffset(depth int, boxWidth float64) float64 {
+	return float64(depth) * 0.5 * boxWidth
+}
@@ -3,6 +3,7 @@ package lifeline
 import (
 	"testing"
 
+	"github.com/peterhoward42/umli/geom"
 	"github.com/peterhoward42/umli/graphics"
 	"github.com/stretchr/testify/assert"
 )
@@ -20,7 +21,7 @@ func TestDrawsTheRightLines(t *testing.T) {
 
 	centreX := 100.0
 	boxWidth := 10.0
-	drawer := NewBoxDrawer(*boxes, centreX, boxWidth)
+	drawer := NewBoxDrawer(*boxes, centreX, boxWidth, nil)
 	prims := graphics.NewPrimitives()
 	drawer.Draw(prims)
 
@@ -28,4 +29,73 @@ func TestDrawsTheRightLines(t *testing.T) {
 	topLeft := graphics.NewPoint(95, 25)
 	bottomRight := graphics.NewPoint(105, 60)
 	assert.True(prims.ContainsRect(topLeft, bottomRight))
+	assert.Len(prims.FilledPolys, 0)
+}
+
+func TestFillsTheBoxesWhenAskedTo(t *testing.T) {
+	assert := assert.New(t)
+	boxes := NewBoxTracker()
+	assert.NoError(boxes.AddStartingAt(25))
+	assert.NoError(boxes.TerminateAt(60))
+	fill := &graphics.FillStyle{Colour: &graphics.White}
+	prims := graphics.NewPrimitives()
+	NewBoxDrawer(*boxes, 100, 10, fill).Draw(prims)
+
+	assert.Equal(4, len(prims.Lines))
+	assert.Len(prims.FilledPolys, 1)
+	poly := prims.FilledPolys[0]
+	assert.Len(poly.Vertices, 4)
+	assert.True(poly.IncludesThisVertex(graphics.NewPoint(95, 25)))
+	assert.True(poly.IncludesThisVertex(graphics.NewPoint(105, 60)))
+	assert.Equal(fill, poly.Style)
+}
+
+func TestNestedBoxesAreOffsetAndNotCutThrough(t *testing.T) {
+	assert := assert.New(t)
+	boxes := NewBoxTracker()
+	assert.NoError(boxes.AddStartingAt(10))
+	boxes.AddNestedStartingAt(20)
+	assert.NoError(boxes.TerminateAt(30))
+	assert.NoError(boxes.TerminateAt(60))
+	prims := graphics.NewPrimitives()
+	NewBoxDrawer(*boxes, 100, 10, nil).Draw(prims)
+
+	// The outer box, with its right hand edge broken where the nested box
+	// sits.
+	assert.True(prims.ContainsLine(graphics.Line{
+		P1: graphics.NewPoint(95, 10), P2: graphics.NewPoint(105, 10)}))
+	assert.True(prims.ContainsLine(graphics.Line{
+		P1: graphics.NewPoint(105, 10), P2: graphics.NewPoint(105, 20)}))
+	assert.True(prims.ContainsLine(graphics.Line{
+		P1: graphics.NewPoint(105, 30), P2: graphics.NewPoint(105, 60)}))
+	assert.False(prims.ContainsLine(graphics.Line{
+		P1: graphics.NewPoint(105, 10), P2: graphics.NewPoint(105, 60)}))
+
+	// The nested box, half a box width to the right.
+	assert.True(prims.ContainsRect(
+		graphics.NewPoint(100, 20), graphics.NewPoint(110, 30)))
+	assert.Len(prims.Lines, 9)
+}
+
+func TestBoxesAreLeftOutInTheirGaps(t *testing.T) {
+	assert := assert.New(t)
+	boxes := NewBoxTracker()
+	assert.NoError(boxes.AddStartingAt(10))
+	boxes.AddGap(geom.NewSegment(30, 40))
+	assert.NoError(boxes.TerminateAt(60))
+	fill := &graphics.FillStyle{Colour: &graphics.White}
+	prims := graphics.NewPrimitives()
+	NewBoxDrawer(*boxes, 100, 10, fill).Draw(prims)
+
+	// The top and bottom edges, and both sides either side of the gap.
+	assert.Len(prims.Lines, 6)
+	for _, x := range []float64{95, 105} {
+		assert.True(prims.ContainsLine(graphics.Line{
+			P1: graphics.NewPoint(x, 10), P2: graphics.NewPoint(x, 30)}))
+		assert.True(prims.ContainsLine(graphics.Line{
+			P1: graphics.NewPoint(x, 40), P2: graphics.NewPoint(x, 60)}))
+	}
+	assert.Len(prims.FilledPolys, 2)
+	assert.True(prims.FilledPolys[0].IncludesThisVertex(graphics.NewPoint(105, 30)))
+	assert.True(prims.FilledPolys[1].IncludesThisVertex(graphics.NewPoint(95, 40)))
 }
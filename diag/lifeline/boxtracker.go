@@ -6,14 +6,56 @@ import (
 	"github.com/peterhoward42/umli/geom"
 )
 
+// These are the reasons a BoxTracker can refuse a request. They are wrapped in
+// a BoxStateError, so test for them with errors.Is.
+var (
+	ErrPreviousBoxNotTerminated = errors.New(
+		"Cannot add new box when previous is not terminated")
+	ErrNoBoxToTerminate     = errors.New("There is no box to terminate")
+	ErrBoxAlreadyTerminated = errors.New(
+		"Cannot terminate an already-terminated box")
+)
+
+// BoxStateError is the error a BoxTracker returns when it is asked to do
+// something that does not make sense given the boxes it has already.
+type BoxStateError struct {
+	Y      float64 // The Y coordinate of the request.
+	Reason error   // One of the ErrXXX values above.
+}
+
+func (e *BoxStateError) Error() string {
+	return e.Reason.Error()
+}
+
+// Unwrap provides the reason, so that errors.Is can test for it.
+func (e *BoxStateError) Unwrap() error {
+	return e.Reason
+}
+
 /*
 BoxTracker keeps track of the vertical extents of the activity boxes
 on a single lifeline. The nub of the problem it takes care of, is that you
 don't know when an activity box should be closed off, at the time you have
 to register where it should start.
+
+Boxes can be nested, (for example when a lifeline that is already busy
+receives a callback). Each nested box knows how deeply it is nested, so that
+it can be drawn offset from the box that encloses it.
+
+It also keeps track of the gaps where the boxes must not be drawn, because
+something else (like a note) is drawn over the lifeline there.
 */
 type BoxTracker struct {
-	segs []geom.Segment // Used to track the start and end of each box.
+	boxes []Box // Used to track the start and end of each box.
+	open  []int // Indices into boxes of those not yet terminated, innermost last.
+	gaps  []geom.Segment
+}
+
+// Box is a single activity box on a lifeline. Depth is zero for an outermost
+// box, one for a box nested inside it, and so on.
+type Box struct {
+	geom.Segment
+	Depth int
 }
 
 // NewBoxTracker provides an an BoxTracker ready to use.
@@ -25,55 +67,82 @@ func NewBoxTracker() *BoxTracker {
 // should start, but with the Y coordinate at which it should end - as yet
 // unknown.
 func (ab *BoxTracker) AddStartingAt(startY float64) error {
-	if len(ab.segs) != 0 && ab.segs[len(ab.segs)-1].End == -1 {
-		return errors.New("Cannot add new box when previous is not terminated")
+	if ab.HasABoxInProgress() {
+		return &BoxStateError{startY, ErrPreviousBoxNotTerminated}
 	}
-	ab.segs = append(ab.segs, geom.NewSegment(startY, -1))
+	ab.push(startY)
 	return nil
 }
 
-// TerminateAt finalises the Segment representing the most recently registered
-// box, noting that it should end at endY.
+// AddNestedStartingAt is like AddStartingAt, except that it is happy to start
+// the new box while others are in progress - nesting it inside the innermost
+// of them.
+func (ab *BoxTracker) AddNestedStartingAt(startY float64) {
+	ab.push(startY)
+}
+
+// push registers a new box starting at startY, nested inside whichever boxes
+// are in progress.
+func (ab *BoxTracker) push(startY float64) {
+	ab.boxes = append(ab.boxes, Box{geom.NewSegment(startY, -1), len(ab.open)})
+	ab.open = append(ab.open, len(ab.boxes)-1)
+}
+
+// TerminateAt finalises the innermost box that is in progress, noting that it
+// should end at endY.
 func (ab *BoxTracker) TerminateAt(endY float64) error {
-	if len(ab.segs) == 0 {
-		return errors.New("There is no box to terminate")
+	if len(ab.boxes) == 0 {
+		return &BoxStateError{endY, ErrNoBoxToTerminate}
 	}
-	mostRecent := &ab.segs[len(ab.segs)-1]
-	if mostRecent.End != -1 {
-		return errors.New("Cannot terminate an already-terminated box")
+	if len(ab.open) == 0 {
+		return &BoxStateError{endY, ErrBoxAlreadyTerminated}
 	}
-	mostRecent.End = endY
+	innermost := ab.open[len(ab.open)-1]
+	ab.boxes[innermost].End = endY
+	ab.open = ab.open[:len(ab.open)-1]
 	return nil
 }
 
-// AsSegments provides all the boxes that have been registered.
+// AddGap registers a vertical extent in which the boxes should not be drawn.
+func (ab *BoxTracker) AddGap(gap geom.Segment) {
+	ab.gaps = append(ab.gaps, gap)
+}
+
+// AsSegments provides the vertical extents of all the boxes that have been
+// registered.
 func (ab *BoxTracker) AsSegments() []geom.Segment {
-	return ab.segs
+	segs := []geom.Segment{}
+	for _, box := range ab.boxes {
+		segs = append(segs, box.Segment)
+	}
+	return segs
+}
+
+// AsBoxes provides all the boxes that have been registered, in the order they
+// were started.
+func (ab *BoxTracker) AsBoxes() []Box {
+	return ab.boxes
 }
 
 /*
 GetStartOfFinalBoxIfNotTerminated provides the y coordinate at which the
-most recently added box starts at - but only when there is at least one
-box, and the most recently added box has not yet been terminated. Otherwise
-it returns nil.
+innermost box that is in progress starts at - but only when there is
+such a box. Otherwise it returns nil.
 */
 func (ab *BoxTracker) GetStartOfFinalBoxIfNotTerminated() *float64 {
-	if len(ab.segs) == 0 {
+	if len(ab.open) == 0 {
 		return nil
 	}
-	mostRecent := &ab.segs[len(ab.segs)-1]
-	if mostRecent.End == -1 {
-		return &mostRecent.Start
-	}
-	return nil
+	return &ab.boxes[ab.open[len(ab.open)-1]].Start
 }
 
-// HasABoxInProgress returns true if there are some boxes, and the most
-// recently added one has not yet been terminated.
+// HasABoxInProgress returns true if there is at least one box that has not
+// yet been terminated.
 func (ab *BoxTracker) HasABoxInProgress() bool {
-	if len(ab.segs) == 0 {
-		return false
-	}
-	mostRecent := &ab.segs[len(ab.segs)-1]
-	return mostRecent.End == -1
+	return len(ab.open) != 0
+}
+
+// Depth provides how many boxes are in progress.
+func (ab *BoxTracker) Depth() int {
+	return len(ab.open)
 }
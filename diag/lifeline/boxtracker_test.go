@@ -1,6 +1,7 @@
 package lifeline
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/peterhoward42/umli/geom"
@@ -30,6 +31,12 @@ func TestErrorWhenThereIsNoBoxToTerminate(t *testing.T) {
 	boxes := NewBoxTracker()
 	err := boxes.TerminateAt(105)
 	assert.EqualError(err, "There is no box to terminate")
+
+	// Which callers can identify.
+	assert.True(errors.Is(err, ErrNoBoxToTerminate))
+	var stateErr *BoxStateError
+	assert.True(errors.As(err, &stateErr))
+	assert.Equal(105.0, stateErr.Y)
 }
 
 func TestCannotTerminateAnAlreadyTerminatedBox(t *testing.T) {
@@ -106,3 +113,39 @@ func TestGetStartOfFinalBoxIfNotTerminatedWhenThereAreNoBoxes(t *testing.T) {
 	finalBoxStart := boxes.GetStartOfFinalBoxIfNotTerminated()
 	assert.Nil(finalBoxStart)
 }
+
+func TestNestedBoxesAreTerminatedInnermostFirst(t *testing.T) {
+	assert := assert.New(t)
+	boxes := NewBoxTracker()
+	assert.NoError(boxes.AddStartingAt(10))
+	boxes.AddNestedStartingAt(20)
+	boxes.AddNestedStartingAt(25)
+	assert.Equal(3, boxes.Depth())
+	assert.Equal(25.0, *boxes.GetStartOfFinalBoxIfNotTerminated())
+
+	assert.NoError(boxes.TerminateAt(30))
+	assert.NoError(boxes.TerminateAt(40))
+	assert.True(boxes.HasABoxInProgress())
+	assert.Equal(10.0, *boxes.GetStartOfFinalBoxIfNotTerminated())
+	assert.NoError(boxes.TerminateAt(50))
+	assert.False(boxes.HasABoxInProgress())
+
+	assert.Equal([]Box{
+		{geom.NewSegment(10, 50), 0},
+		{geom.NewSegment(20, 40), 1},
+		{geom.NewSegment(25, 30), 2},
+	}, boxes.AsBoxes())
+	assert.Equal([]geom.Segment{
+		geom.NewSegment(10, 50),
+		geom.NewSegment(20, 40),
+		geom.NewSegment(25, 30),
+	}, boxes.AsSegments())
+}
+
+func TestANestedBoxCanStartWhenNoneIsInProgress(t *testing.T) {
+	assert := assert.New(t)
+	boxes := NewBoxTracker()
+	boxes.AddNestedStartingAt(10)
+	assert.NoError(boxes.TerminateAt(20))
+	assert.Equal([]Box{{geom.NewSegment(10, 20), 0}}, boxes.AsBoxes())
+}
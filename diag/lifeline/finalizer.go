@@ -10,12 +10,14 @@ import (
 )
 
 // Finalizer knows how to draw lifelines including making the gaps required
-// in them to avoid activity boxes and interaction line no go zones.
+// in them to avoid activity boxes and interaction line no go zones. And
+// cutting them to their lifespans.
 type Finalizer struct {
 	lifelines []*dsl.Statement
 	spacer    *Spacing
 	noGoZones []nogozone.NoGoZone
 	boxes     map[*dsl.Statement]*BoxTracker
+	lifespans map[*dsl.Statement]Lifespan
 }
 
 // NewFinalizer provides a Finalizer ready to use.
@@ -24,22 +26,26 @@ func NewFinalizer(
 	spacer *Spacing,
 	noGoZones []nogozone.NoGoZone,
 	boxes map[*dsl.Statement]*BoxTracker,
+	lifespans map[*dsl.Statement]Lifespan,
 	sizer sizer.Sizer) *Finalizer {
 	return &Finalizer{
 		lifelines: lifelines,
 		spacer:    spacer,
 		noGoZones: noGoZones,
 		boxes:     boxes,
+		lifespans: lifespans,
 	}
 }
 
-// Finalize draws all the lifelines.
+// Finalize draws all the lifelines. Those that have a lifespan run only for
+// that part of the range from top to bottom. (Lifelines missing from the
+// lifespans run from top to bottom).
 func (f *Finalizer) Finalize(
 	top float64, bottom float64, minSegLen float64,
 	primitives *graphics.Primitives) error {
 	for _, ll := range f.lifelines {
 		if err := f.finalizeOne(ll, top, bottom, minSegLen, primitives); err != nil {
-			return fmt.Errorf("finalizeOne: %v", err)
+			return fmt.Errorf("finalizeOne: %w", err)
 		}
 	}
 	return nil
@@ -50,16 +56,20 @@ func (f *Finalizer) finalizeOne(
 	lifeline *dsl.Statement, top float64, bottom float64,
 	minSegLen float64, primitives *graphics.Primitives) error {
 	boxes := *f.boxes[lifeline]
+	top, bottom = f.lifespans[lifeline].Clip(top, bottom)
 	lifelineSegments := LifelineSegments{}
 	lifelineSegments.Assemble(lifeline, top, bottom, minSegLen, f.noGoZones, boxes, f.lifelines)
 	lifelineXCoords, err := f.spacer.CentreLine(lifeline)
 	if err != nil {
-		return fmt.Errorf("space.CentreLine: %v", err)
+		return fmt.Errorf("space.CentreLine: %w", err)
 	}
 	x := lifelineXCoords.Centre
 	dashed := true
+	mark := primitives.Mark()
 	for _, seg := range lifelineSegments.Segs {
 		primitives.AddLine(x, seg.Start, x, seg.End, dashed)
 	}
+	primitives.TagSince(mark, graphics.Origin{
+		Kind: graphics.Lifeline, SourceLine: lifeline.LineNo})
 	return nil
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/peterhoward42/umli/graphics"
 	"github.com/peterhoward42/umli/parser"
 	"github.com/peterhoward42/umli/sizer"
+	"github.com/peterhoward42/umli/textmetrics"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -30,9 +31,11 @@ func TestLifelinesGetDrawnCorrectlyIncludingMakingTheRequiredGaps(t *testing.T)
 	sizer := sizer.NewLiteralSizer(map[string]float64{
 		"FrameInternalPadB":          10,
 		"IdealLifelineTitleBoxWidth": 300.0,
+		"TitleBoxLabelPadLR":         1.0,
 	})
 	lifelines := dslModel.LifelineStatements()
-	spacer := NewSpacing(sizer, fontHt, width, lifelines)
+	spacer := NewSpacing(sizer, textmetrics.NewFixedPitchMeasurer(0.5),
+		fontHt, width, lifelines)
 	noGoSeg := geom.NewSegment(50, 60)
 	zone := nogozone.NewNoGoZone(noGoSeg, lifelines[0], lifelines[2])
 	noGoZones := []nogozone.NoGoZone{zone}
@@ -47,7 +50,7 @@ func TestLifelinesGetDrawnCorrectlyIncludingMakingTheRequiredGaps(t *testing.T)
 	assert.NoError(err)
 
 	minSegLen := 1.0
-	lifelineF := NewFinalizer(lifelines, spacer, noGoZones, boxes, sizer)
+	lifelineF := NewFinalizer(lifelines, spacer, noGoZones, boxes, nil, sizer)
 	top := 10.0
 	bottom := 100.0
 	primitives := graphics.NewPrimitives()
@@ -23,7 +23,11 @@ func (gaps *Gaps) PopulateFromNoGoZones(noGoZones []nogozone.NoGoZone,
 	gaps.Items = []geom.Segment{}
 	for _, noGoZone := range noGoZones {
 		// Does this noGoZone affect lifeline?
-		affectedLifelines := SpanExcl(noGoZone.OneEndLifeline,
+		spanFn := SpanExcl
+		if noGoZone.Inclusive {
+			spanFn = SpanIncl
+		}
+		affectedLifelines := spanFn(noGoZone.OneEndLifeline,
 			noGoZone.OtherEndLifeline,
 			allLifelines)
 		if gaps.lifelineIsAmong(affectedLifelines, lifeline) {
@@ -65,3 +65,26 @@ func TestGetZeroGapsWhenLifelineIsAffectedByNoneOfTheNoGoZones(t *testing.T) {
 	segs := gaps.Items
 	assert.Len(segs, 0)
 }
+
+func TestInclusiveNoGoZonesAffectTheLifelinesAtTheirEnds(t *testing.T) {
+	assert := assert.New(t)
+
+	a := &dsl.Statement{}
+	b := &dsl.Statement{}
+	c := &dsl.Statement{}
+	allLifelines := []*dsl.Statement{a, b, c}
+
+	seg12 := geom.NewSegment(1, 2)
+	nogozones := []nogozone.NoGoZone{
+		nogozone.NewInclusiveNoGoZone(seg12, a, b),
+	}
+
+	for _, lifeline := range []*dsl.Statement{a, b} {
+		gaps := Gaps{}
+		gaps.PopulateFromNoGoZones(nogozones, lifeline, allLifelines)
+		assert.Equal([]geom.Segment{seg12}, gaps.Items)
+	}
+	gaps := Gaps{}
+	gaps.PopulateFromNoGoZones(nogozones, c, allLifelines)
+	assert.Len(gaps.Items, 0)
+}
@@ -0,0 +1,79 @@
+package lifeline
+
+import (
+	"math"
+
+	"github.com/peterhoward42/umli"
+	"github.com/peterhoward42/umli/geom"
+	"github.com/peterhoward42/umli/graphics"
+)
+
+/*
+drawParticipantIcon draws the standard UML (or robustness diagram) icon for
+the given participant kind. The icon fits in a square of the given size,
+whose top edge is centred on (x, top). Curves are approximated by polylines.
+*/
+func drawParticipantIcon(kind string, x float64, top float64, size float64,
+	prims *graphics.Primitives) {
+	notDashed := false
+	midY := top + 0.5*size
+	switch kind {
+	case umli.Actor:
+		// A stick figure.
+		headRadius := size / 6
+		hips := top + 0.65*size
+		arms := top + 0.45*size
+		prims.AddPolyline(circle(x, top+headRadius, headRadius))
+		prims.AddLine(x, top+2*headRadius, x, hips, notDashed)
+		prims.AddLine(x-size/3, arms, x+size/3, arms, notDashed)
+		prims.AddLine(x, hips, x-size/3, top+size, notDashed)
+		prims.AddLine(x, hips, x+size/3, top+size, notDashed)
+	case umli.Boundary:
+		// A circle attached to a vertical bar on its left.
+		radius := size / 3
+		circleX := x + 0.5*size - radius
+		left := x - 0.5*size
+		prims.AddPolyline(circle(circleX, midY, radius))
+		prims.AddLine(left, midY-radius, left, midY+radius, notDashed)
+		prims.AddLine(left, midY, circleX-radius, midY, notDashed)
+	case umli.Control:
+		// A circle with an arrow head on top.
+		radius := 0.4 * size
+		circleTop := midY - radius
+		prims.AddPolyline(circle(x, midY, radius))
+		prims.AddPolyline(geom.MakeArrow(
+			x+size, x-0.1*size, circleTop, 0.2*size, 0.2*size))
+	case umli.Entity:
+		// A circle resting on a horizontal line.
+		radius := 0.4 * size
+		bottom := midY + radius
+		prims.AddPolyline(circle(x, midY, radius))
+		prims.AddLine(x-radius, bottom, x+radius, bottom, notDashed)
+	case umli.Database:
+		// An upright cylinder.
+		radiusX := 0.35 * size
+		radiusY := size / 8
+		upper := top + radiusY
+		lower := top + size - radiusY
+		prims.AddPolyline(geom.MakeArc(x, upper, radiusX, radiusY, 0, 2*math.Pi))
+		prims.AddLine(x-radiusX, upper, x-radiusX, lower, notDashed)
+		prims.AddLine(x+radiusX, upper, x+radiusX, lower, notDashed)
+		prims.AddPolyline(geom.MakeArc(x, lower, radiusX, radiusY, 0, math.Pi))
+	case umli.Queue:
+		// A cylinder lying on its side.
+		radiusX := size / 8
+		radiusY := 0.3 * size
+		left := x - 0.5*size + radiusX
+		right := x + 0.5*size - radiusX
+		prims.AddPolyline(geom.MakeArc(right, midY, radiusX, radiusY, 0, 2*math.Pi))
+		prims.AddLine(left, midY-radiusY, right, midY-radiusY, notDashed)
+		prims.AddLine(left, midY+radiusY, right, midY+radiusY, notDashed)
+		prims.AddPolyline(geom.MakeArc(
+			left, midY, radiusX, radiusY, 0.5*math.Pi, 1.5*math.Pi))
+	}
+}
+
+// circle makes a closed polyline that approximates a circle.
+func circle(x float64, y float64, radius float64) []graphics.Point {
+	return geom.MakeArc(x, y, radius, radius, 0, 2*math.Pi)
+}
@@ -0,0 +1,26 @@
+package lifeline
+
+/*
+Lifespan records where a lifeline begins and ends, for a lifeline that is
+created, or destroyed, part way down the diagram. The zero value is the
+lifespan of a lifeline that runs from the title boxes at the top, to the bottom
+of the diagram.
+*/
+type Lifespan struct {
+	Created   bool
+	Top       float64 // The bottom of its title box, when Created.
+	Destroyed bool
+	Bottom    float64 // Where it is destroyed, when Destroyed.
+}
+
+// Clip provides the part of the range from top to bottom, that lies within
+// the lifespan.
+func (l Lifespan) Clip(top, bottom float64) (clippedTop, clippedBottom float64) {
+	if l.Created {
+		top = l.Top
+	}
+	if l.Destroyed {
+		bottom = l.Bottom
+	}
+	return top, bottom
+}
@@ -0,0 +1,23 @@
+package lifeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClipHonoursCreationAndDestruction(t *testing.T) {
+	assert := assert.New(t)
+
+	top, bottom := Lifespan{}.Clip(10, 100)
+	assert.Equal(10.0, top)
+	assert.Equal(100.0, bottom)
+
+	top, bottom = Lifespan{Created: true, Top: 30}.Clip(10, 100)
+	assert.Equal(30.0, top)
+	assert.Equal(100.0, bottom)
+
+	top, bottom = Lifespan{Destroyed: true, Bottom: 60}.Clip(10, 100)
+	assert.Equal(10.0, top)
+	assert.Equal(60.0, bottom)
+}
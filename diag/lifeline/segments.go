@@ -20,7 +20,9 @@ Assemble populates a LifelineSegments by working out the segments
 required taking into account
 both the gaps that are required to avoid NoGoZone(s), and the gaps required to
 not interfere with the lifeline's activity boxes. It also makes a few
-adjustments - for example to avoid ending up with very tiny segments.
+adjustments - for example to avoid ending up with very tiny segments. Nothing
+is drawn above topOfLifeline, nor below bottomOfLifeline, so these can be
+used to cut a lifeline to its lifespan.
 */
 func (s *LifelineSegments) Assemble(
 	lifeline *dsl.Statement,
@@ -41,6 +43,12 @@ func (s *LifelineSegments) Assemble(
 	prev := topOfLifeline
 	var segs []geom.Segment
 	for _, gap := range mergedGaps {
+		if gap.End <= prev {
+			continue
+		}
+		if gap.Start >= bottomOfLifeline {
+			break
+		}
 		seg := geom.NewSegment(prev, gap.Start)
 		if seg.Length() >= minSegLen {
 			segs = append(segs, seg)
@@ -48,7 +56,7 @@ func (s *LifelineSegments) Assemble(
 		prev = gap.End
 	}
 	finalSeg := geom.NewSegment(prev, bottomOfLifeline)
-	if finalSeg.Length() >= minSegLen {
+	if prev < bottomOfLifeline && finalSeg.Length() >= minSegLen {
 		segs = append(segs, finalSeg)
 	}
 	s.Segs = segs
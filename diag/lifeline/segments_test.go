@@ -169,3 +169,29 @@ func TestItDiscardsTinySegmentsGaps(t *testing.T) {
 	assert.Len(segments.Segs, 1)
 	assert.Equal(segments.Segs[0], geom.NewSegment(1.0, 50))
 }
+
+func TestGapsOutsideTheLifespanAreIgnored(t *testing.T) {
+	assert := assert.New(t)
+
+	// Boxes that start before the top, straddle the bottom, and lie
+	// entirely below it.
+	boxes := *NewBoxTracker()
+	assert.NoError(boxes.AddStartingAt(0))
+	assert.NoError(boxes.TerminateAt(20))
+	assert.NoError(boxes.AddStartingAt(40))
+	assert.NoError(boxes.TerminateAt(50))
+	assert.NoError(boxes.AddStartingAt(70))
+	assert.NoError(boxes.TerminateAt(90))
+	assert.NoError(boxes.AddStartingAt(95))
+	assert.NoError(boxes.TerminateAt(99))
+
+	segments := LifelineSegments{}
+	var lifeline *dsl.Statement = nil
+	segments.Assemble(lifeline, 30, 80, 0.1,
+		[]nogozone.NoGoZone{}, boxes, []*dsl.Statement{})
+
+	assert.Equal([]geom.Segment{
+		geom.NewSegment(30, 40),
+		geom.NewSegment(50, 70),
+	}, segments.Segs)
+}
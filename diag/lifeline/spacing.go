@@ -1,34 +1,46 @@
 package lifeline
 
 import (
-	"errors"
 	"fmt"
+	"math"
 
+	"github.com/peterhoward42/umli"
 	"github.com/peterhoward42/umli/dsl"
 	"github.com/peterhoward42/umli/sizer"
+	"github.com/peterhoward42/umli/textmetrics"
 )
 
 /*
 Spacing holds the knowledge about the horizontal pitch and geometry
 of lifelines. For example, how to space them out across the page.
 
-The implementation makes all the title boxes the same width, and distributes these equally
-across the width of the diagram. It uses the same gap (gutter) between these
-boxes and as margins at the left and right edge of the diagram.
+It offers two spacing modes. The uniform mode (see NewSpacing) makes all the
+title boxes the same width, and distributes these equally across the width of
+the diagram. It uses the same gap (gutter) between these boxes and as margins
+at the left and right edge of the diagram. The variable mode (see
+NewVariableSpacing) instead makes the gap between each pair of adjacent
+lifelines wide enough for the interaction labels that span it.
+
+In both modes, the title boxes are made wide enough to hold the widest of the
+lifeline titles, measured using the measurer provided.
 */
 type Spacing struct {
 	sizer         sizer.Sizer
+	measurer      textmetrics.Measurer
 	fontHeight    float64
 	diagWidth     float64
 	lifelines     []*dsl.Statement
 	drivingValues drivingValues
 }
 
-// NewSpacing  provides a Spacing  ready to use.
-func NewSpacing(sizer sizer.Sizer, fontHeight float64, diagWidth float64,
+// NewSpacing  provides a Spacing  ready to use, that uses the uniform
+// spacing mode.
+func NewSpacing(sizer sizer.Sizer, measurer textmetrics.Measurer,
+	fontHeight float64, diagWidth float64,
 	lifelines []*dsl.Statement) *Spacing {
 	spacer := &Spacing{
 		sizer:      sizer,
+		measurer:   measurer,
 		lifelines:  lifelines,
 		fontHeight: fontHeight,
 		diagWidth:  diagWidth,
@@ -43,11 +55,39 @@ type TitleBoxXCoords struct {
 	Right  float64
 }
 
-// LifelinePitch returns the spacing between adjacent lifelines.
-// It provides a sensible answer also when there is only one lifeline, and
-// maybe the caller wants to base some other size on what the pitch would be.
+/*
+LifelinePitch returns the spacing between adjacent lifelines. (Or in the
+variable spacing mode, the smallest such spacing).
+It provides a sensible answer also when there is only one lifeline, and
+maybe the caller wants to base some other size on what the pitch would be.
+*/
 func (s Spacing) LifelinePitch() float64 {
-	return s.drivingValues.titleBoxWidth + s.drivingValues.titleBoxGutter
+	return s.drivingValues.pitch
+}
+
+// TitleBoxWidth provides the width of the lifeline title boxes.
+func (s Spacing) TitleBoxWidth() float64 {
+	return s.drivingValues.titleBoxWidth
+}
+
+/*
+DiagramWidth provides the width of the diagram needed to accomodate the
+lifelines. This is the width the Spacing was made with, except in the
+variable spacing mode, where it may have had to be made wider.
+*/
+func (s Spacing) DiagramWidth() float64 {
+	return s.diagWidth
+}
+
+/*
+Outermost provides the leftmost and rightmost lifelines. (Which are the same
+lifeline when there is only one, and nils when there are none).
+*/
+func (s Spacing) Outermost() (leftmost, rightmost *dsl.Statement) {
+	if len(s.lifelines) == 0 {
+		return nil, nil
+	}
+	return s.lifelines[0], s.lifelines[len(s.lifelines)-1]
 }
 
 /*
@@ -56,10 +96,10 @@ CentreLine provides the X coordinate for the centreline of lifeline.
 func (s Spacing) CentreLine(lifeline *dsl.Statement) (*TitleBoxXCoords, error) {
 	num, err := s.lifelineNumber(lifeline)
 	if err != nil {
-		return nil, fmt.Errorf("lifelineNumber: %v", err)
+		return nil, fmt.Errorf("lifelineNumber: %w", err)
 	}
 	dv := s.drivingValues
-	centre := (float64(num)+1)*dv.titleBoxGutter + (float64(num)+0.5)*dv.titleBoxWidth
+	centre := dv.centres[num]
 	delta := dv.titleBoxWidth / 2.0
 	return &TitleBoxXCoords{centre - delta, centre, centre + delta}, nil
 }
@@ -72,17 +112,20 @@ them. This function decides what they should be.
 type drivingValues struct {
 	titleBoxWidth  float64
 	titleBoxGutter float64
+	pitch          float64
+	centres        []float64 // Lifeline centres, in lifeline order.
 }
 
 /*
 setDrivingValues calculates the values that other spacing decisions are derived
 from. They include trying to use an optimal looking width for lifeline title
-boxes, but backtracking when this would make the gutter between the title boxes
+boxes, (or wider if that is needed to hold the lifeline titles), but
+backtracking when this would make the gutter between the title boxes
 too small and reducing the size of the title boxes such that a minimum gutter
 of one font height is preserved.
 */
 func (s *Spacing) setDrivingValues() {
-	s.drivingValues.titleBoxWidth = s.sizer.Get("IdealLifelineTitleBoxWidth")
+	s.drivingValues.titleBoxWidth = s.titleBoxWidth()
 	n := len(s.lifelines)
 	spaceAvail := s.diagWidth - s.drivingValues.titleBoxWidth*float64(n)
 	nGuttersRequired := n + 1
@@ -93,8 +136,36 @@ func (s *Spacing) setDrivingValues() {
 	// one font height.
 	if s.drivingValues.titleBoxGutter < s.fontHeight {
 		s.drivingValues.titleBoxGutter = s.fontHeight
-		s.drivingValues.titleBoxWidth = s.diagWidth - float64(n+1)*s.drivingValues.titleBoxGutter/float64(n)
+		s.drivingValues.titleBoxWidth = (s.diagWidth -
+			float64(nGuttersRequired)*s.drivingValues.titleBoxGutter) /
+			float64(n)
+	}
+	dv := &s.drivingValues
+	dv.pitch = dv.titleBoxWidth + dv.titleBoxGutter
+	dv.centres = make([]float64, n)
+	for num := range dv.centres {
+		dv.centres[num] = (float64(num)+1)*dv.titleBoxGutter +
+			(float64(num)+0.5)*dv.titleBoxWidth
+	}
+}
+
+// titleBoxWidth provides the width the title boxes would ideally be, if
+// there were no constraints on the diagram width.
+func (s *Spacing) titleBoxWidth() float64 {
+	return math.Max(
+		s.sizer.Get("IdealLifelineTitleBoxWidth"), s.widestTitle()+
+			2*s.sizer.Get("TitleBoxLabelPadLR"))
+}
+
+// widestTitle provides the width of the widest line of text in any of the
+// lifeline title boxes.
+func (s *Spacing) widestTitle() float64 {
+	widest := 0.0
+	for _, lifeline := range s.lifelines {
+		widest = math.Max(widest, textmetrics.MaxWidth(
+			s.measurer, lifeline.LabelSegments, s.fontHeight))
 	}
+	return widest
 }
 
 func (s *Spacing) lifelineNumber(lifeline *dsl.Statement) (int, error) {
@@ -103,5 +174,5 @@ func (s *Spacing) lifelineNumber(lifeline *dsl.Statement) (int, error) {
 			return num, nil
 		}
 	}
-	return -1, errors.New("lifeline is not registered")
+	return -1, &umli.UnknownLifelineError{Name: lifeline.LifelineName}
 }
@@ -1,10 +1,13 @@
 package lifeline
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/peterhoward42/umli"
 	"github.com/peterhoward42/umli/dsl"
 	"github.com/peterhoward42/umli/sizer"
+	"github.com/peterhoward42/umli/textmetrics"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -20,6 +23,7 @@ func TestCentreLineCanonicalCase(t *testing.T) {
 
 	sizer := sizer.NewLiteralSizer(map[string]float64{
 		"IdealLifelineTitleBoxWidth": 100.0,
+		"TitleBoxLabelPadLR":         1.0,
 	})
 
 	lifelineA := &dsl.Statement{}
@@ -28,7 +32,8 @@ func TestCentreLineCanonicalCase(t *testing.T) {
 
 	fontHeight := 20.0
 	diagWidth := 800.0
-	spacing := NewSpacing(sizer, fontHeight, diagWidth, lifelines)
+	spacing := NewSpacing(sizer, textmetrics.NewFixedPitchMeasurer(0.5),
+		fontHeight, diagWidth, lifelines)
 	boxXCoords, err := spacing.CentreLine(lifelineB)
 	assert.NoError(err)
 	assert.Equal(500.0, boxXCoords.Left)
@@ -49,6 +54,7 @@ func TestCentreLineSquashedCase(t *testing.T) {
 
 	sizer := sizer.NewLiteralSizer(map[string]float64{
 		"IdealLifelineTitleBoxWidth": 99999999.0,
+		"TitleBoxLabelPadLR":         1.0,
 	})
 
 	lifelineA := &dsl.Statement{}
@@ -57,12 +63,13 @@ func TestCentreLineSquashedCase(t *testing.T) {
 
 	fontHeight := 20.0
 	diagWidth := 800.0
-	spacing := NewSpacing(sizer, fontHeight, diagWidth, lifelines)
+	spacing := NewSpacing(sizer, textmetrics.NewFixedPitchMeasurer(0.5),
+		fontHeight, diagWidth, lifelines)
 	boxXCoords, err := spacing.CentreLine(lifelineB)
 	assert.NoError(err)
-	assert.Equal(810.0, boxXCoords.Left)
-	assert.Equal(1195.0, boxXCoords.Centre)
-	assert.Equal(1580.0, boxXCoords.Right)
+	assert.Equal(410.0, boxXCoords.Left)
+	assert.Equal(595.0, boxXCoords.Centre)
+	assert.Equal(780.0, boxXCoords.Right)
 }
 
 /*
@@ -77,6 +84,7 @@ func TestCentreLineCornerCaseOfOneLifeline(t *testing.T) {
 
 	sizer := sizer.NewLiteralSizer(map[string]float64{
 		"IdealLifelineTitleBoxWidth": 100.0,
+		"TitleBoxLabelPadLR":         1.0,
 	})
 
 	lifelineA := &dsl.Statement{}
@@ -84,7 +92,8 @@ func TestCentreLineCornerCaseOfOneLifeline(t *testing.T) {
 
 	fontHeight := 20.0
 	diagWidth := 800.0
-	spacing := NewSpacing(sizer, fontHeight, diagWidth, lifelines)
+	spacing := NewSpacing(sizer, textmetrics.NewFixedPitchMeasurer(0.5),
+		fontHeight, diagWidth, lifelines)
 	boxXCoords, err := spacing.CentreLine(lifelineA)
 	assert.NoError(err)
 	assert.Equal(350.0, boxXCoords.Left)
@@ -104,6 +113,7 @@ func TestLifelinePitchFnForTwoLifelines(t *testing.T) {
 
 	sizer := sizer.NewLiteralSizer(map[string]float64{
 		"IdealLifelineTitleBoxWidth": 100.0,
+		"TitleBoxLabelPadLR":         1.0,
 	})
 
 	lifelineA := &dsl.Statement{}
@@ -112,7 +122,8 @@ func TestLifelinePitchFnForTwoLifelines(t *testing.T) {
 
 	fontHeight := 20.0
 	diagWidth := 800.0
-	spacing := NewSpacing(sizer, fontHeight, diagWidth, lifelines)
+	spacing := NewSpacing(sizer, textmetrics.NewFixedPitchMeasurer(0.5),
+		fontHeight, diagWidth, lifelines)
 	pitch := spacing.LifelinePitch()
 	assert.Equal(300.0, pitch)
 }
@@ -123,6 +134,7 @@ func TestLifelinePitchFnForOneLifeline(t *testing.T) {
 
 	sizer := sizer.NewLiteralSizer(map[string]float64{
 		"IdealLifelineTitleBoxWidth": 100.0,
+		"TitleBoxLabelPadLR":         1.0,
 	})
 
 	lifelineA := &dsl.Statement{}
@@ -130,7 +142,137 @@ func TestLifelinePitchFnForOneLifeline(t *testing.T) {
 
 	fontHeight := 20.0
 	diagWidth := 800.0
-	spacing := NewSpacing(sizer, fontHeight, diagWidth, lifelines)
+	spacing := NewSpacing(sizer, textmetrics.NewFixedPitchMeasurer(0.5),
+		fontHeight, diagWidth, lifelines)
 	pitch := spacing.LifelinePitch()
 	assert.Equal(450.0, pitch)
 }
+
+/*
+Given a Spacing object initialised with a lifeline whose title is wider
+than the ideal title box width...
+When calling its CentreLine method...
+Then the title box should be widened to hold the title.
+*/
+func TestTitleBoxIsWidenedToHoldWideTitles(t *testing.T) {
+	assert := assert.New(t)
+
+	sizer := sizer.NewLiteralSizer(map[string]float64{
+		"IdealLifelineTitleBoxWidth": 100.0,
+		"TitleBoxLabelPadLR":         5.0,
+	})
+	lifelineA := &dsl.Statement{
+		LabelSegments: []string{"short", "a much longer line"}}
+	lifelines := []*dsl.Statement{lifelineA}
+
+	fontHeight := 20.0
+	diagWidth := 800.0
+	spacing := NewSpacing(sizer, textmetrics.NewFixedPitchMeasurer(0.5),
+		fontHeight, diagWidth, lifelines)
+	boxXCoords, err := spacing.CentreLine(lifelineA)
+	assert.NoError(err)
+
+	// 18 characters at 10 units each, plus the padding either side.
+	assert.Equal(400.0-95.0, boxXCoords.Left)
+	assert.Equal(400.0+95.0, boxXCoords.Right)
+}
+
+/*
+Given a variable Spacing object initialised with three lifelines, and
+interaction lines whose labels need more room between the first pair than the
+second...
+When calling its CentreLine method...
+Then the first gap should be widened to fit the label, and the spare
+width shared out.
+*/
+func TestVariableSpacingWidensGapsForLabels(t *testing.T) {
+	assert := assert.New(t)
+
+	sizer := sizer.NewLiteralSizer(map[string]float64{
+		"IdealLifelineTitleBoxWidth": 100.0,
+		"TitleBoxLabelPadLR":         1.0,
+		"ActivityBoxWidth":           20.0,
+		"InteractionLabelPadLR":      5.0,
+	})
+	a := &dsl.Statement{Keyword: umli.Life}
+	b := &dsl.Statement{Keyword: umli.Life}
+	c := &dsl.Statement{Keyword: umli.Life}
+	lifelines := []*dsl.Statement{a, b, c}
+	statements := []*dsl.Statement{a, b, c, {
+		Keyword:             umli.Full,
+		ReferencedLifelines: []*dsl.Statement{b, a},
+		LabelSegments:       []string{"forty characters of text................"},
+	}}
+
+	fontHeight := 10.0
+	minDiagWidth := 800.0
+	spacing := NewVariableSpacing(sizer, textmetrics.NewFixedPitchMeasurer(0.5),
+		fontHeight, minDiagWidth, lifelines, statements)
+
+	// The AB gap must be 200 (label) + 20 (activity box) + 10 (padding)
+	// = 230. The BC gap only needs to be the title box width plus one font
+	// height = 110. The margins need to be half a title box plus one font
+	// height = 60. That makes 460, leaving 340 spare to share among the gaps
+	// and margins = 85 each.
+	aX, _ := spacing.CentreLine(a)
+	bX, _ := spacing.CentreLine(b)
+	cX, _ := spacing.CentreLine(c)
+	assert.InDelta(145.0, aX.Centre, 0.001)
+	assert.InDelta(145.0+315.0, bX.Centre, 0.001)
+	assert.InDelta(145.0+315.0+195.0, cX.Centre, 0.001)
+	assert.InDelta(195.0, spacing.LifelinePitch(), 0.001)
+	assert.Equal(800.0, spacing.DiagramWidth())
+}
+
+/*
+Given a variable Spacing object with an interaction label too wide to fit
+in the diagram width...
+Then the diagram should be made wider.
+*/
+func TestVariableSpacingGrowsDiagramWhenNecessary(t *testing.T) {
+	assert := assert.New(t)
+
+	sizer := sizer.NewLiteralSizer(map[string]float64{
+		"IdealLifelineTitleBoxWidth": 100.0,
+		"TitleBoxLabelPadLR":         1.0,
+		"ActivityBoxWidth":           20.0,
+		"InteractionLabelPadLR":      5.0,
+	})
+	a := &dsl.Statement{Keyword: umli.Life}
+	b := &dsl.Statement{Keyword: umli.Life}
+	c := &dsl.Statement{Keyword: umli.Life}
+	lifelines := []*dsl.Statement{a, b, c}
+	statements := []*dsl.Statement{a, b, c, {
+		Keyword:             umli.Dash,
+		ReferencedLifelines: []*dsl.Statement{a, c},
+		LabelSegments:       []string{strings.Repeat("x", 200)},
+	}}
+
+	fontHeight := 10.0
+	minDiagWidth := 800.0
+	spacing := NewVariableSpacing(sizer, textmetrics.NewFixedPitchMeasurer(0.5),
+		fontHeight, minDiagWidth, lifelines, statements)
+
+	// The label needs 1000 + 20 + 10 = 1030 between A and C, which is shared
+	// equally between the two gaps. Plus the margins of 60 each.
+	aX, _ := spacing.CentreLine(a)
+	bX, _ := spacing.CentreLine(b)
+	cX, _ := spacing.CentreLine(c)
+	assert.InDelta(60.0, aX.Centre, 0.001)
+	assert.InDelta(60.0+515.0, bX.Centre, 0.001)
+	assert.InDelta(60.0+1030.0, cX.Centre, 0.001)
+	assert.InDelta(1150.0, spacing.DiagramWidth(), 0.001)
+}
+
+func TestOutermostIsNilWhenThereAreNoLifelines(t *testing.T) {
+	assert := assert.New(t)
+	sizer := sizer.NewLiteralSizer(map[string]float64{
+		"IdealLifelineTitleBoxWidth": 100.0,
+		"TitleBoxLabelPadLR":         1.0,
+	})
+	spacing := NewSpacing(sizer, textmetrics.NewFixedPitchMeasurer(0.5),
+		20.0, 800.0, []*dsl.Statement{})
+	leftmost, rightmost := spacing.Outermost()
+	assert.Nil(leftmost)
+	assert.Nil(rightmost)
+}
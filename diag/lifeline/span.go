@@ -28,3 +28,17 @@ func SpanExcl(
 	}
 	return span
 }
+
+/*
+SpanIncl works out for any two lifelines (from, to), which lifelines lie
+between them, including from and to themselves.
+*/
+func SpanIncl(
+	from, to *dsl.Statement, allLifelines []*dsl.Statement) []*dsl.Statement {
+	if from == to {
+		return []*dsl.Statement{from}
+	}
+	span := []*dsl.Statement{from}
+	span = append(span, SpanExcl(from, to, allLifelines)...)
+	return append(span, to)
+}
@@ -44,3 +44,23 @@ func TestSpanCountsRight(t *testing.T) {
 	assert.Equal(b, span[0])
 	assert.Equal(c, span[1])
 }
+
+func TestSpanInclCountsRight(t *testing.T) {
+	assert := assert.New(t)
+	a := &dsl.Statement{}
+	b := &dsl.Statement{}
+	c := &dsl.Statement{}
+	d := &dsl.Statement{}
+	allLifelines := []*dsl.Statement{a, b, c, d}
+	span := SpanIncl(d, b, allLifelines)
+	assert.Equal([]*dsl.Statement{d, c, b}, span)
+}
+
+func TestSpanInclWhenBothEndsAreTheSame(t *testing.T) {
+	assert := assert.New(t)
+	a := &dsl.Statement{}
+	b := &dsl.Statement{}
+	allLifelines := []*dsl.Statement{a, b}
+	span := SpanIncl(b, b, allLifelines)
+	assert.Equal([]*dsl.Statement{b}, span)
+}
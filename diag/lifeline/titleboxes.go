@@ -6,23 +6,30 @@ import (
 	"github.com/peterhoward42/umli/dsl"
 	"github.com/peterhoward42/umli/graphics"
 	"github.com/peterhoward42/umli/sizer"
+	"github.com/peterhoward42/umli/textmetrics"
 )
 
 /*
-TitleBoxes knows how to draw the lifeline title boxes
+TitleBoxes knows how to draw the lifeline title boxes. Titles that are too
+wide for the boxes are word-wrapped. Lifelines that declare a participant kind
+(like actor), get the icon for that kind in place of the box, with the title
+beneath it.
 */
 type TitleBoxes struct {
 	sizer      sizer.Sizer
+	measurer   textmetrics.Measurer
 	spacer     *Spacing
 	lifelines  []*dsl.Statement
 	fontHeight float64
 }
 
 // NewTitleBoxes creates a TitleBoxes ready to use.
-func NewTitleBoxes(sizer sizer.Sizer, lifelineSpacing *Spacing, lifelines []*dsl.Statement,
+func NewTitleBoxes(sizer sizer.Sizer, measurer textmetrics.Measurer,
+	lifelineSpacing *Spacing, lifelines []*dsl.Statement,
 	fontHeight float64) *TitleBoxes {
 	return &TitleBoxes{
 		sizer:      sizer,
+		measurer:   measurer,
 		spacer:     lifelineSpacing,
 		lifelines:  lifelines,
 		fontHeight: fontHeight,
@@ -30,19 +37,23 @@ func NewTitleBoxes(sizer sizer.Sizer, lifelineSpacing *Spacing, lifelines []*dsl
 }
 
 /*
-Make works out the graphics primitives needed to represent all the lifeline
-title boxes, and adds them to prims.
+Make works out the graphics primitives needed to represent the lifeline
+title boxes at the top of the diagram, and adds them to prims. It leaves out
+those lifelines that are createdLater, (by a create statement).
 */
 func (tbx TitleBoxes) Make(
-	currentTideMark float64,
+	currentTideMark float64, createdLater map[*dsl.Statement]bool,
 	prims *graphics.Primitives) (newTideMark float64, bottomOfBoxes float64, err error) {
 
 	totalHeight, forLabelsHeight := tbx.Height()
 	for _, life := range tbx.lifelines {
+		if createdLater[life] {
+			continue
+		}
 		err := tbx.MakeOne(life, currentTideMark, totalHeight, forLabelsHeight,
 			prims)
 		if err != nil {
-			return -1.0, -1.0, fmt.Errorf("MakeOne: %v", err)
+			return -1.0, -1.0, fmt.Errorf("MakeOne: %w", err)
 		}
 	}
 	bottomOfBoxes = currentTideMark + totalHeight
@@ -52,7 +63,9 @@ func (tbx TitleBoxes) Make(
 
 /*
 MakeOne works out the graphics primitives needed to represent the title box
-for lifeline and adds them to prims.
+for lifeline and adds them to prims. When any lifeline has a participant icon,
+the space for it is at the top, and the rectangles of the other lifelines'
+boxes enclose only the part beneath that.
 */
 func (tbx TitleBoxes) MakeOne(
 	lifeline *dsl.Statement,
@@ -63,33 +76,65 @@ func (tbx TitleBoxes) MakeOne(
 
 	titleBoxXCoords, err := tbx.spacer.CentreLine(lifeline)
 	if err != nil {
-		return fmt.Errorf("spacing.CentreLine: %v", err)
+		return fmt.Errorf("spacing.CentreLine: %w", err)
 	}
 
-	// Make the rectangle.
+	mark := prims.Mark()
+
+	// Make the rectangle or icon.
 	bottom := topOfBox + totalHeight
-	prims.AddRect(titleBoxXCoords.Left, topOfBox, titleBoxXCoords.Right, bottom)
+	if lifeline.ParticipantKind == "" {
+		prims.AddRect(titleBoxXCoords.Left, topOfBox+tbx.iconSpace(),
+			titleBoxXCoords.Right, bottom)
+	} else {
+		drawParticipantIcon(lifeline.ParticipantKind, titleBoxXCoords.Centre,
+			topOfBox, tbx.sizer.Get("ParticipantIconSize"), prims)
+	}
 
 	// Make the strings.
 	topRowOfTextY := bottom - tbx.sizer.Get("TitleBoxLabelPadB") - labelHeight
 	prims.RowOfStrings(titleBoxXCoords.Centre, topRowOfTextY,
-		tbx.fontHeight, graphics.Centre, lifeline.LabelSegments)
+		tbx.fontHeight, graphics.Centre, tbx.wrappedTitle(lifeline))
 
+	prims.TagSince(mark, graphics.Origin{
+		Kind: graphics.TitleBox, SourceLine: lifeline.LineNo})
 	return nil
 }
 
 /*
 Height provides the height required for the titlebox, based on the lifeline
-with the most label segments.
+with the most lines of text, (after word-wrapping).
 */
 func (tbx TitleBoxes) Height() (overallHeight, forLabels float64) {
 	var maxN int
 	for _, s := range tbx.lifelines {
-		if len(s.LabelSegments) > maxN {
-			maxN = len(s.LabelSegments)
+		if n := len(tbx.wrappedTitle(s)); n > maxN {
+			maxN = n
 		}
 	}
 	forLabels = float64(maxN) * tbx.fontHeight
-	overallHeight = forLabels + tbx.sizer.Get("TitleBoxLabelPadT") + tbx.sizer.Get("TitleBoxLabelPadB")
+	overallHeight = forLabels + tbx.sizer.Get("TitleBoxLabelPadT") +
+		tbx.sizer.Get("TitleBoxLabelPadB") + tbx.iconSpace()
 	return overallHeight, forLabels
 }
+
+// iconSpace provides the height reserved at the top of the title boxes for
+// participant icons. Which is none unless at least one lifeline has an icon.
+func (tbx TitleBoxes) iconSpace() float64 {
+	for _, s := range tbx.lifelines {
+		if s.ParticipantKind != "" {
+			return tbx.sizer.Get("ParticipantIconSize") +
+				tbx.sizer.Get("ParticipantIconPadB")
+		}
+	}
+	return 0
+}
+
+// wrappedTitle provides the lines of text for lifeline's title, word-wrapped
+// to fit inside its title box.
+func (tbx TitleBoxes) wrappedTitle(lifeline *dsl.Statement) []string {
+	available := tbx.spacer.TitleBoxWidth() -
+		2*tbx.sizer.Get("TitleBoxLabelPadLR")
+	return textmetrics.Wrap(tbx.measurer, lifeline.LabelSegments,
+		tbx.fontHeight, available)
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/peterhoward42/umli/dsl"
 	"github.com/peterhoward42/umli/graphics"
 	"github.com/peterhoward42/umli/sizer"
+	"github.com/peterhoward42/umli/textmetrics"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -23,17 +24,20 @@ func TestSmallestViableExampleExhaustively(t *testing.T) {
 
 	sizer := sizer.NewLiteralSizer(map[string]float64{
 		"IdealLifelineTitleBoxWidth": 200.0,
+		"TitleBoxLabelPadLR":         1.0,
 		"TitleBoxLabelPadB":          2,
 		"TitleBoxLabelPadT":          5,
 		"TitleBoxPadB":               3,
 	})
 	fontHeight := 6.0
 	diagWidth := 2000.0
-	spacer := NewSpacing(sizer, fontHeight, diagWidth, lifelines)
+	spacer := NewSpacing(sizer, textmetrics.NewFixedPitchMeasurer(0.5),
+		fontHeight, diagWidth, lifelines)
 	tideMark := 10.0
 	prims := graphics.NewPrimitives()
-	titleBoxes := NewTitleBoxes(sizer, spacer, lifelines, fontHeight)
-	newTideMark, bottomOfBoxes, err := titleBoxes.Make(tideMark, prims)
+	titleBoxes := NewTitleBoxes(sizer, textmetrics.NewFixedPitchMeasurer(0.5),
+		spacer, lifelines, fontHeight)
+	newTideMark, bottomOfBoxes, err := titleBoxes.Make(tideMark, nil, prims)
 	assert.NoError(err)
 
 	// Correct title box rectangle present?
@@ -71,17 +75,20 @@ func TestIsConsumingMultipleLifelinesProperly(t *testing.T) {
 	lifelines := []*dsl.Statement{lifelineA}
 	sizer := sizer.NewLiteralSizer(map[string]float64{
 		"IdealLifelineTitleBoxWidth": 200.0,
+		"TitleBoxLabelPadLR":         1.0,
 		"TitleBoxLabelPadB":          2,
 		"TitleBoxLabelPadT":          5,
 		"TitleBoxPadB":               3,
 	})
 	fontHeight := 6.0
 	diagWidth := 2000.0
-	spacer := NewSpacing(sizer, fontHeight, diagWidth, lifelines)
+	spacer := NewSpacing(sizer, textmetrics.NewFixedPitchMeasurer(0.5),
+		fontHeight, diagWidth, lifelines)
 	tideMark := 10.0
 	prims := graphics.NewPrimitives()
-	titleBoxes := NewTitleBoxes(sizer, spacer, lifelines, fontHeight)
-	newTideMark, _, err := titleBoxes.Make(tideMark, prims)
+	titleBoxes := NewTitleBoxes(sizer, textmetrics.NewFixedPitchMeasurer(0.5),
+		spacer, lifelines, fontHeight)
+	newTideMark, _, err := titleBoxes.Make(tideMark, nil, prims)
 	assert.NoError(err)
 
 	// Capture metrics
@@ -96,14 +103,141 @@ func TestIsConsumingMultipleLifelinesProperly(t *testing.T) {
 		LabelSegments: []string{"foo"},
 	}
 	lifelines = []*dsl.Statement{lifelineA, lifelineB}
-	spacer = NewSpacing(sizer, fontHeight, diagWidth, lifelines)
+	spacer = NewSpacing(sizer, textmetrics.NewFixedPitchMeasurer(0.5),
+		fontHeight, diagWidth, lifelines)
 	tideMark = 10.0
 	prims = graphics.NewPrimitives()
-	titleBoxes = NewTitleBoxes(sizer, spacer, lifelines, fontHeight)
-	newTideMark, _, err = titleBoxes.Make(tideMark, prims)
+	titleBoxes = NewTitleBoxes(sizer, textmetrics.NewFixedPitchMeasurer(0.5),
+		spacer, lifelines, fontHeight)
+	newTideMark, _, err = titleBoxes.Make(tideMark, nil, prims)
 	assert.NoError(err)
 
 	newLinesProduced := len(prims.Lines)
 	assert.True(newLinesProduced > linesProduced)
 	assert.Equal(firstRunTidemark, newTideMark)
 }
+
+func TestLifelinesCreatedLaterGetNoTitleBoxAtTheTop(t *testing.T) {
+	assert := assert.New(t)
+	lifelineA := &dsl.Statement{
+		Keyword: umli.Life, LifelineName: "A", LabelSegments: []string{"foo"}}
+	lifelineB := &dsl.Statement{
+		Keyword: umli.Life, LifelineName: "B", LabelSegments: []string{"bar"}}
+	lifelines := []*dsl.Statement{lifelineA, lifelineB}
+	sizer := sizer.NewLiteralSizer(map[string]float64{
+		"IdealLifelineTitleBoxWidth": 300.0,
+		"TitleBoxLabelPadLR":         1.0,
+		"TitleBoxLabelPadB":          2,
+		"TitleBoxLabelPadT":          5,
+		"TitleBoxPadB":               3,
+	})
+	measurer := textmetrics.NewFixedPitchMeasurer(0.5)
+	spacer := NewSpacing(sizer, measurer, 10, 2000, lifelines)
+	titleBoxes := NewTitleBoxes(sizer, measurer, spacer, lifelines, 10)
+	prims := graphics.NewPrimitives()
+	_, _, err := titleBoxes.Make(
+		10.0, map[*dsl.Statement]bool{lifelineB: true}, prims)
+	assert.NoError(err)
+	assert.Len(prims.Lines, 4)
+	assert.Len(prims.Labels, 1)
+	assert.Equal("foo", prims.Labels[0].TheString)
+}
+
+func TestTitlesAreWrappedToFitInsideSquashedTitleBoxes(t *testing.T) {
+	assert := assert.New(t)
+
+	lifeline := &dsl.Statement{
+		Keyword:       umli.Life,
+		LifelineName:  "A",
+		LabelSegments: []string{"foo bar baz"},
+	}
+	lifelines := []*dsl.Statement{lifeline}
+	sizer := sizer.NewLiteralSizer(map[string]float64{
+		"IdealLifelineTitleBoxWidth": 60.0,
+		"TitleBoxLabelPadLR":         1.0,
+		"TitleBoxLabelPadB":          2,
+		"TitleBoxLabelPadT":          5,
+		"TitleBoxPadB":               3,
+	})
+
+	// The diagram is too narrow for the ideal title box width, so the title
+	// box is squashed to 50 wide. Each character is 5 wide.
+	fontHeight := 10.0
+	diagWidth := 70.0
+	measurer := textmetrics.NewFixedPitchMeasurer(0.5)
+	spacer := NewSpacing(sizer, measurer, fontHeight, diagWidth, lifelines)
+	titleBoxes := NewTitleBoxes(sizer, measurer, spacer, lifelines, fontHeight)
+	prims := graphics.NewPrimitives()
+	_, bottomOfBoxes, err := titleBoxes.Make(10.0, nil, prims)
+	assert.NoError(err)
+
+	assert.Len(prims.Labels, 2)
+	assert.Equal("foo bar", prims.Labels[0].TheString)
+	assert.Equal("baz", prims.Labels[1].TheString)
+
+	// The box must be tall enough for both lines.
+	assert.Equal(10.0+5+2*fontHeight+2, bottomOfBoxes)
+}
+
+func TestParticipantIconsReplaceTheBoxAndMakeRoomAboveTheOthers(t *testing.T) {
+	assert := assert.New(t)
+	lifelineA := &dsl.Statement{Keyword: umli.Life, LifelineName: "A",
+		ParticipantKind: umli.Actor, LabelSegments: []string{"foo"}}
+	lifelineB := &dsl.Statement{
+		Keyword: umli.Life, LifelineName: "B", LabelSegments: []string{"bar"}}
+	lifelines := []*dsl.Statement{lifelineA, lifelineB}
+	sizer := sizer.NewLiteralSizer(map[string]float64{
+		"IdealLifelineTitleBoxWidth": 300.0,
+		"TitleBoxLabelPadLR":         1.0,
+		"TitleBoxLabelPadB":          2,
+		"TitleBoxLabelPadT":          5,
+		"TitleBoxPadB":               3,
+		"ParticipantIconSize":        30,
+		"ParticipantIconPadB":        4,
+	})
+	measurer := textmetrics.NewFixedPitchMeasurer(0.5)
+	spacer := NewSpacing(sizer, measurer, 10, 2000, lifelines)
+	titleBoxes := NewTitleBoxes(sizer, measurer, spacer, lifelines, 10)
+	prims := graphics.NewPrimitives()
+	_, bottomOfBoxes, err := titleBoxes.Make(10.0, nil, prims)
+	assert.NoError(err)
+	assert.Equal(10.0+30+4+5+10+2, bottomOfBoxes)
+
+	// The actor is a head, and four lines for the body, arms and legs.
+	assert.Len(prims.Polylines, 1)
+	assert.Len(prims.Lines, 4+4)
+	for _, polyline := range prims.Polylines {
+		assert.Equal(graphics.TitleBox, polyline.Origin.Kind)
+	}
+
+	// B's box encloses only its title, beneath the space for the icon.
+	coords, err := spacer.CentreLine(lifelineB)
+	assert.NoError(err)
+	assert.True(prims.ContainsRect(
+		graphics.NewPoint(coords.Left, 10.0+30+4),
+		graphics.NewPoint(coords.Right, bottomOfBoxes)))
+
+	// Both titles are at the same height.
+	assert.Len(prims.Labels, 2)
+	assert.Equal(prims.Labels[0].Anchor.Y, prims.Labels[1].Anchor.Y)
+}
+
+func TestEveryParticipantKindHasAnIconThatFitsItsSquare(t *testing.T) {
+	assert := assert.New(t)
+	for _, kind := range umli.ParticipantKinds {
+		prims := graphics.NewPrimitives()
+		drawParticipantIcon(kind, 100, 10, 30, prims)
+		assert.NotEmpty(prims.Polylines, kind)
+		points := []graphics.Point{}
+		for _, line := range prims.Lines {
+			points = append(points, line.P1, line.P2)
+		}
+		for _, polyline := range prims.Polylines {
+			points = append(points, polyline.Vertices...)
+		}
+		for _, p := range points {
+			assert.InDelta(100.0, p.X, 15.0+1e-9, kind)
+			assert.InDelta(25.0, p.Y, 15.0+1e-9, kind)
+		}
+	}
+}
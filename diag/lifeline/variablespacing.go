@@ -0,0 +1,147 @@
+package lifeline
+
+import (
+	"math"
+	"sort"
+
+	"github.com/peterhoward42/umli"
+	"github.com/peterhoward42/umli/dsl"
+	"github.com/peterhoward42/umli/sizer"
+	"github.com/peterhoward42/umli/textmetrics"
+)
+
+/*
+NewVariableSpacing provides a Spacing ready to use, that uses the variable
+spacing mode. The gap between each adjacent pair of lifelines is made wide
+enough to fit the widest interaction label (in statements) that spans it.
+
+The lifelines are spread out to fill minDiagWidth when they do not need all
+of it, but when they need more, the diagram is made wider. (See
+DiagramWidth).
+*/
+func NewVariableSpacing(sizer sizer.Sizer, measurer textmetrics.Measurer,
+	fontHeight float64, minDiagWidth float64, lifelines []*dsl.Statement,
+	statements []*dsl.Statement) *Spacing {
+	spacer := &Spacing{
+		sizer:      sizer,
+		measurer:   measurer,
+		lifelines:  lifelines,
+		fontHeight: fontHeight,
+		diagWidth:  minDiagWidth,
+	}
+	spacer.setVariableDrivingValues(statements)
+	return spacer
+}
+
+/*
+setVariableDrivingValues is the variable spacing mode's counterpart to
+setDrivingValues.
+
+Every gap between adjacent lifelines starts out as the minimum that keeps
+their title boxes one font height apart. Then each interaction line's label
+widens the gaps it spans, (equally), if they are not already wide enough in
+total. Labels that span fewer gaps are considered first, so that the widening
+happens where it is needed most. Finally any spare diagram width is shared
+out equally between the gaps and the margins.
+*/
+func (s *Spacing) setVariableDrivingValues(statements []*dsl.Statement) {
+	dv := &s.drivingValues
+	dv.titleBoxWidth = s.titleBoxWidth()
+	minGutter := s.fontHeight
+	n := len(s.lifelines)
+	gaps := make([]float64, int(math.Max(0, float64(n-1))))
+	for i := range gaps {
+		gaps[i] = dv.titleBoxWidth + minGutter
+	}
+	for _, need := range s.labelNeeds(statements) {
+		got := 0.0
+		for i := need.left; i < need.right; i++ {
+			got += gaps[i]
+		}
+		if got >= need.width {
+			continue
+		}
+		increase := (need.width - got) / float64(need.right-need.left)
+		for i := need.left; i < need.right; i++ {
+			gaps[i] += increase
+		}
+	}
+
+	// Share out any spare width.
+	margin := minGutter + 0.5*dv.titleBoxWidth
+	required := 2 * margin
+	for _, gap := range gaps {
+		required += gap
+	}
+	if spare := s.diagWidth - required; spare > 0 {
+		share := spare / float64(n+1)
+		margin += share
+		for i := range gaps {
+			gaps[i] += share
+		}
+	} else {
+		s.diagWidth = required
+	}
+
+	dv.titleBoxGutter = margin - 0.5*dv.titleBoxWidth
+	dv.pitch = dv.titleBoxWidth + dv.titleBoxGutter
+	if len(gaps) != 0 {
+		dv.pitch = gaps[0]
+		for _, gap := range gaps {
+			dv.pitch = math.Min(dv.pitch, gap)
+		}
+	}
+	dv.centres = make([]float64, n)
+	x := margin
+	for num := range dv.centres {
+		dv.centres[num] = x
+		if num < len(gaps) {
+			x += gaps[num]
+		}
+	}
+}
+
+// labelNeed is the distance that an interaction line's label needs between
+// the centres of the lifelines it spans.
+type labelNeed struct {
+	left  int // Lifeline number
+	right int // Lifeline number
+	width float64
+}
+
+/*
+labelNeeds provides the labelNeed(s) of the interaction lines in statements,
+ordered so that those spanning the fewest gaps come first. The width needed
+includes room for the activity boxes at either end, and some padding.
+*/
+func (s *Spacing) labelNeeds(statements []*dsl.Statement) []labelNeed {
+	needs := []labelNeed{}
+	for _, statement := range statements {
+		switch statement.Keyword {
+		case umli.Full, umli.Async, umli.Dash, umli.Create:
+		default:
+			continue
+		}
+		from, err := s.lifelineNumber(statement.ReferencedLifelines[0])
+		if err != nil {
+			continue
+		}
+		to, err := s.lifelineNumber(statement.ReferencedLifelines[1])
+		if err != nil {
+			continue
+		}
+		width := textmetrics.MaxWidth(
+			s.measurer, statement.NumberedLabel(), s.fontHeight) +
+			s.sizer.Get("ActivityBoxWidth") +
+			2*s.sizer.Get("InteractionLabelPadLR")
+		left, right := from, to
+		if left > right {
+			left, right = right, left
+		}
+		needs = append(needs, labelNeed{left, right, width})
+	}
+	sort.SliceStable(needs, func(i, j int) bool {
+		return needs[i].right-needs[i].left < needs[j].right-needs[j].left
+	})
+	return needs
+}
@@ -7,16 +7,29 @@ import (
 
 /*
 NoGoZone models the space that a (horizontal) interaction line and its label
-occupies.
+occupies. Or more generally, any band of the diagram that the lifelines
+between two lifelines should not be drawn through.
+
+By default the lifelines at either end are not affected, because an
+interaction line stops at them. But when Inclusive is set, they are affected
+too. For example where a label is written across them.
 */
 type NoGoZone struct {
 	Height           geom.Segment
 	OneEndLifeline   *dsl.Statement
 	OtherEndLifeline *dsl.Statement
+	Inclusive        bool
 }
 
 // NewNoGoZone creates and initialises a NoGoZone
 func NewNoGoZone(height geom.Segment, oneEndLifeline,
 	otherEndLifelone *dsl.Statement) NoGoZone {
-	return NoGoZone{height, oneEndLifeline, otherEndLifelone}
+	return NoGoZone{height, oneEndLifeline, otherEndLifelone, false}
+}
+
+// NewInclusiveNoGoZone creates and initialises a NoGoZone that affects
+// the lifelines at either end, as well as those between them.
+func NewInclusiveNoGoZone(height geom.Segment, oneEndLifeline,
+	otherEndLifelone *dsl.Statement) NoGoZone {
+	return NoGoZone{height, oneEndLifeline, otherEndLifelone, true}
 }
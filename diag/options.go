@@ -0,0 +1,119 @@
+package diag
+
+import (
+	"errors"
+
+	"github.com/peterhoward42/umli/sizer"
+	"github.com/peterhoward42/umli/textmetrics"
+	"github.com/peterhoward42/umli/theme"
+)
+
+/*
+Option is the type for the functional options that NewCreator accepts, to
+configure the Creator. For example:
+
+	creator, err := diag.NewCreator(
+		diag.WithWidth(1000), diag.WithLayout(diag.VariableLayout))
+
+Anything not specified by an option takes the default value documented
+against the corresponding WithXXX function.
+*/
+type Option func(c *Creator) error
+
+// LayoutStrategy chooses how lifelines are spread across the diagram.
+type LayoutStrategy int
+
+// Values for type LayoutStrategy.
+const (
+	// UniformLayout spaces the lifelines evenly. (See lifeline.NewSpacing).
+	UniformLayout LayoutStrategy = iota
+	// VariableLayout spaces the lifelines to suit the labels between them.
+	// (See lifeline.NewVariableSpacing).
+	VariableLayout
+)
+
+// defaultWidth is the diagram width used unless WithWidth says otherwise.
+// It is easy to reason about during debugging if you think of it as pixels.
+const defaultWidth = 2000.0
+
+/*
+WithWidth sets the width of the diagrams created. (The default is 2000). The
+font height is a proportion of this, so changing it scales the whole diagram.
+The variable layout may make a diagram wider than this to fit its labels.
+*/
+func WithWidth(width float64) Option {
+	return func(c *Creator) error {
+		if width <= 0 {
+			return errors.New("The diagram width must be greater than zero")
+		}
+		c.width = width
+		return nil
+	}
+}
+
+/*
+WithSizer makes the Creator use the Sizer(s) made by newSizer, instead of
+a sizer.CompleteSizer. The newSizer function is given the font height chosen
+for each diagram. The Sizers it makes must provide all of sizer.RequiredKeys(),
+and size overrides, (from a Config or size statements) do not apply to them.
+*/
+func WithSizer(newSizer func(fontHeight float64) sizer.Sizer) Option {
+	return func(c *Creator) error {
+		if newSizer == nil {
+			return errors.New("The sizer function must not be nil")
+		}
+		c.newSizer = func(fontHeight float64, _ sizer.Overrides) (
+			sizer.Sizer, error) {
+			return newSizer(fontHeight), nil
+		}
+		return nil
+	}
+}
+
+/*
+WithMeasurer makes the Creator measure text with the given Measurer, instead
+of textmetrics.Default(). It should measure text in the same way as the
+renderer that will be used.
+*/
+func WithMeasurer(measurer textmetrics.Measurer) Option {
+	return func(c *Creator) error {
+		if measurer == nil {
+			return errors.New("The measurer must not be nil")
+		}
+		c.measurer = measurer
+		return nil
+	}
+}
+
+/*
+WithLayout chooses how lifelines are spread across the diagram. (The default
+is UniformLayout). A spacing statement in the DSL script takes precedence.
+*/
+func WithLayout(strategy LayoutStrategy) Option {
+	return func(c *Creator) error {
+		if strategy != UniformLayout && strategy != VariableLayout {
+			return errors.New("Unknown layout strategy")
+		}
+		c.layout = strategy
+		return nil
+	}
+}
+
+// WithConfig makes the Creator adopt the settings in cfg. (See
+// Creator.ApplyConfig).
+func WithConfig(cfg *Config) Option {
+	return func(c *Creator) error {
+		return c.ApplyConfig(cfg)
+	}
+}
+
+/*
+WithTheme makes the Creator draw diagrams in the given theme. (The default is
+theme.Default). A theme statement in the DSL script takes precedence.
+*/
+func WithTheme(thm theme.Theme) Option {
+	return func(c *Creator) error {
+		c.theme = thm
+		return nil
+	}
+}
@@ -0,0 +1,106 @@
+package diag
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/peterhoward42/umli/parser"
+	"github.com/peterhoward42/umli/sizer"
+	"github.com/stretchr/testify/assert"
+)
+
+const optionsScript = `
+	life A foo
+	life B bar
+	full AB fibble
+`
+
+func TestDefaultsAreUnchangedWhenNoOptionsAreGiven(t *testing.T) {
+	assert := assert.New(t)
+	creator, err := NewCreator()
+	assert.NoError(err)
+	assert.Equal(2000.0, creator.width)
+	assert.Equal(UniformLayout, creator.layout)
+	assert.NotNil(creator.measurer)
+	graphicsModel, err := creator.Create(*parser.MustCompileParse(optionsScript))
+	assert.NoError(err)
+	assert.Equal(2000.0, graphicsModel.Width)
+	assert.Equal(20.0, graphicsModel.FontHeight)
+}
+
+func TestWithWidthScalesTheDiagram(t *testing.T) {
+	assert := assert.New(t)
+	creator, err := NewCreator(WithWidth(1000))
+	assert.NoError(err)
+	graphicsModel, err := creator.Create(*parser.MustCompileParse(optionsScript))
+	assert.NoError(err)
+	assert.Equal(1000.0, graphicsModel.Width)
+	assert.Equal(10.0, graphicsModel.FontHeight)
+
+	_, err = NewCreator(WithWidth(0))
+	assert.EqualError(err, "The diagram width must be greater than zero")
+}
+
+func TestWithSizerReplacesTheCompleteSizer(t *testing.T) {
+	assert := assert.New(t)
+	var gotFontHeight float64
+	creator, err := NewCreator(WithSizer(func(fontHeight float64) sizer.Sizer {
+		gotFontHeight = fontHeight
+		return sizer.NewLiteralSizer(map[string]float64{"ArrowLen": 1})
+	}))
+	assert.NoError(err)
+	_, err = creator.Create(*parser.MustCompileParse(optionsScript))
+	assert.Error(err)
+	assert.Equal(20.0, gotFontHeight)
+	assert.Contains(err.Error(), "The sizer cannot provide these sizes")
+
+	_, err = NewCreator(WithSizer(nil))
+	assert.EqualError(err, "The sizer function must not be nil")
+}
+
+// fixedMeasurer claims every character is one font height wide.
+type fixedMeasurer struct{}
+
+func (m fixedMeasurer) Width(s string, fontHeight float64) float64 {
+	return float64(len(s)) * fontHeight
+}
+
+func TestWithMeasurerAndLayoutControlVariableSpacing(t *testing.T) {
+	assert := assert.New(t)
+	script := `
+		life A foo
+		life B bar
+		full AB ` + strings.Repeat("x", 150)
+
+	// The default measurer finds the label fits easily, but the wide
+	// measurer does not.
+	creator, err := NewCreator(WithLayout(VariableLayout))
+	assert.NoError(err)
+	graphicsModel, err := creator.Create(*parser.MustCompileParse(script))
+	assert.NoError(err)
+	assert.Equal(2000.0, graphicsModel.Width)
+
+	creator, err = NewCreator(
+		WithLayout(VariableLayout), WithMeasurer(fixedMeasurer{}))
+	assert.NoError(err)
+	graphicsModel, err = creator.Create(*parser.MustCompileParse(script))
+	assert.NoError(err)
+	assert.True(graphicsModel.Width > 2000.0)
+
+	// But a spacing statement in the script takes precedence.
+	graphicsModel, err = creator.Create(
+		*parser.MustCompileParse("spacing uniform\n" + script))
+	assert.NoError(err)
+	assert.Equal(2000.0, graphicsModel.Width)
+
+	_, err = NewCreator(WithMeasurer(nil))
+	assert.EqualError(err, "The measurer must not be nil")
+	_, err = NewCreator(WithLayout(LayoutStrategy(42)))
+	assert.EqualError(err, "Unknown layout strategy")
+}
+
+func TestWithConfigValidatesTheConfig(t *testing.T) {
+	assert := assert.New(t)
+	_, err := NewCreator(WithConfig(&Config{Sizes: sizer.Overrides{"Bogus": 1}}))
+	assert.EqualError(err, "Invalid config: Unknown size: Bogus")
+}
@@ -0,0 +1,49 @@
+package diag
+
+import (
+	"github.com/peterhoward42/umli/graphics"
+	"github.com/peterhoward42/umli/theme"
+)
+
+/*
+applyTheme styles the graphics in mdl according to thm, and the kind of
+diagram element each is part of. The frame's lines get the frame style, and
+any other line the plain line style. Any unstyled polygon is styled as a
+filled arrow head, and any unstyled polyline as an open one, (except for those
+in participant icons which are styled like lines). Primitives that are styled
+already (like the activity box fills) are left alone.
+*/
+func applyTheme(mdl *graphics.Model, thm theme.Theme) {
+	mdl.Background = thm.Background
+	prims := mdl.Primitives
+	for i := range prims.Lines {
+		line := &prims.Lines[i]
+		if line.Style != nil {
+			continue
+		}
+		line.Style = thm.Line
+		if line.Origin.Kind == graphics.Frame {
+			line.Style = thm.Frame
+		}
+	}
+	for i := range prims.FilledPolys {
+		if prims.FilledPolys[i].Style == nil {
+			prims.FilledPolys[i].Style = thm.Arrow
+		}
+	}
+	for i := range prims.Polylines {
+		polyline := &prims.Polylines[i]
+		if polyline.Style != nil {
+			continue
+		}
+		polyline.Style = thm.OpenArrow
+		if polyline.Origin.Kind == graphics.TitleBox {
+			polyline.Style = thm.Line
+		}
+	}
+	for i := range prims.Labels {
+		if prims.Labels[i].Style == nil {
+			prims.Labels[i].Style = thm.Text
+		}
+	}
+}
@@ -108,6 +108,61 @@ func (m *Model) Title() []string {
 	return s.LabelSegments
 }
 
+// VariableSpacingRequested returns true if there is a statement asking for
+// lifelines to be spaced to suit the labels between them.
+func (m *Model) VariableSpacingRequested() bool {
+	s, ok := m.FirstStatementOfType(umli.Spacing)
+	if !ok {
+		return false
+	}
+	return s.VariableSpacing
+}
+
+// OpenRepliesRequested returns true if there is a statement asking for
+// replies to be drawn with open arrow heads, (rather than filled ones).
+func (m *Model) OpenRepliesRequested() bool {
+	s, ok := m.FirstStatementOfType(umli.Replies)
+	if !ok {
+		return false
+	}
+	return s.OpenReplies
+}
+
+// SizeOverrides provides the sizes specified by size statements, keyed on
+// the size's name. When the same size is specified more than once, the last
+// one wins.
+func (m *Model) SizeOverrides() map[string]float64 {
+	overrides := map[string]float64{}
+	for _, s := range m.statements {
+		if s.Keyword == umli.Size {
+			overrides[s.SizeKey] = s.SizeValue
+		}
+	}
+	return overrides
+}
+
+// CreatedLifelines provides the lifelines that are created part way down the
+// diagram by a create statement, (rather than existing from the top).
+func (m *Model) CreatedLifelines() map[*Statement]bool {
+	created := map[*Statement]bool{}
+	for _, s := range m.statements {
+		if s.Keyword == umli.Create {
+			created[s.ReferencedLifelines[1]] = true
+		}
+	}
+	return created
+}
+
+// ThemeName provides the name of the theme asked for by a theme statement,
+// or false if there is no such statement.
+func (m *Model) ThemeName() (name string, ok bool) {
+	s, ok := m.FirstStatementOfType(umli.Theme)
+	if !ok {
+		return "", false
+	}
+	return s.ThemeName, true
+}
+
 // LifelineLettersSupressed returns true if there is an explict don't-show
 // lifeline letters statement
 func (m *Model) LifelineLettersSupressed() bool {
@@ -4,11 +4,23 @@ package dsl
 // types in the DSL - and provides a superset of attributes required.
 type Statement struct {
 	Keyword             string       // E.g. "full|stop"
+	LineNo              int          // In the script, counted from 1. (Or zero).
 	LifelineName        string       // Only used for <life> statements.
-	ReferencedLifelines []*Statement // When lifeline operands are present
+	ParticipantKind     string       // Only for <life> statements. E.g. "actor"
+	ReferencedLifelines []*Statement // Lifeline operands, or those a fragment spans
 	LabelSegments       []string     // Each line of text called for in the label
 	TextSize            float64      // Only used for <textsize> statements.
 	ShowLetters         bool         // Only used for <showletters> statements.
+	NoteOver            bool         // A <note> over, not beside its lifeline(s).
+	VariableSpacing     bool         // Only used for <spacing> statements.
+	OpenReplies         bool         // Only used for <replies> statements.
+	SizeKey             string       // Only used for <size> statements.
+	SizeValue           float64      // Only used for <size> statements.
+	ThemeName           string       // Only used for <theme> statements.
+	NumberStart         int          // Only used for <autonumber> statements.
+	NumberStep          int          // Only used for <autonumber> statements.
+	NumberFormat        string       // Only for <autonumber> statements. E.g. "[01]"
+	SequenceNumber      string       // Given to messages by autonumber. E.g. "7."
 }
 
 // NewStatement instantiates a Statement, ready to use.
@@ -18,3 +30,16 @@ func NewStatement() *Statement {
 		LabelSegments:       []string{},
 	}
 }
+
+// NumberedLabel provides the lines of the statement's label, with its
+// sequence number (if it has one) written at the start of the first line.
+func (s *Statement) NumberedLabel() []string {
+	if s.SequenceNumber == "" {
+		return s.LabelSegments
+	}
+	if len(s.LabelSegments) == 0 {
+		return []string{s.SequenceNumber}
+	}
+	lines := []string{s.SequenceNumber + " " + s.LabelSegments[0]}
+	return append(lines, s.LabelSegments[1:]...)
+}
@@ -0,0 +1,18 @@
+package dsl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNumberedLabelPrefixesTheFirstLine(t *testing.T) {
+	assert := assert.New(t)
+	s := &Statement{LabelSegments: []string{"foo", "bar"}}
+	assert.Equal([]string{"foo", "bar"}, s.NumberedLabel())
+	s.SequenceNumber = "[07]"
+	assert.Equal([]string{"[07] foo", "bar"}, s.NumberedLabel())
+	assert.Equal([]string{"foo", "bar"}, s.LabelSegments)
+	s.LabelSegments = []string{}
+	assert.Equal([]string{"[07]"}, s.NumberedLabel())
+}
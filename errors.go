@@ -1,10 +1,128 @@
 package umli
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
 
-// DSLError builds an error that describes a fault in some input DSL,
-// and which includes the original faulty line and its line number.
-func DSLError(line string, lineNo int, msg string) error {
-	return fmt.Errorf("Error on this line <%s> (line: %v): %s",
-		line, lineNo, msg)
+// Severity says how serious a DSLProblem is.
+type Severity int
+
+// Values for type Severity
+const (
+	// SeverityError means the script cannot be made into a diagram.
+	SeverityError Severity = iota
+	// SeverityWarning means the script can be made into a diagram, but
+	// probably does not say what its author intended.
+	SeverityWarning
+)
+
+func (s Severity) String() string {
+	if s == SeverityWarning {
+		return "Warning"
+	}
+	return "Error"
+}
+
+/*
+DSLProblem describes a fault in some input DSL. It says where the fault is,
+in enough detail for an editor to underline the offending token.
+
+Line numbers and columns are both counted from 1, and columns are counted in
+characters (not bytes). StartCol and EndCol are inclusive.
+*/
+type DSLProblem struct {
+	Line      int
+	StartCol  int
+	EndCol    int
+	Token     string // The text at fault. (Or the whole statement).
+	Message   string
+	Severity  Severity
+	Source    string // The line of the script, exactly as written.
+	Statement string // The statement in the line, without comments or padding.
+	Err       error  // The underlying error. E.g. an *UnknownLifelineError.
+}
+
+// Error provides a description of the problem, which includes the faulty
+// statement and its line number.
+func (p *DSLProblem) Error() string {
+	return fmt.Sprintf("%v on this line <%s> (line: %v): %s",
+		p.Severity, p.Statement, p.Line, p.Message)
+}
+
+// Unwrap provides the underlying error, so that errors.Is and errors.As can
+// inspect it.
+func (p *DSLProblem) Unwrap() error {
+	return p.Err
+}
+
+/*
+DSLErrorList is a list of the problems found in a DSL script - in line order.
+It is the error type returned by the parser when a script is faulty, and holds
+every problem found, not just the first.
+*/
+type DSLErrorList []*DSLProblem
+
+// Error provides the descriptions of all the problems, one per line.
+func (l DSLErrorList) Error() string {
+	descriptions := []string{}
+	for _, p := range l {
+		descriptions = append(descriptions, p.Error())
+	}
+	return strings.Join(descriptions, "\n")
+}
+
+// Err returns the list as an error if it contains any problems of
+// SeverityError, and otherwise nil.
+func (l DSLErrorList) Err() error {
+	for _, p := range l {
+		if p.Severity == SeverityError {
+			return l
+		}
+	}
+	return nil
+}
+
+// Is reports whether any of the problems in the list matches target, (in
+// the sense of errors.Is).
+func (l DSLErrorList) Is(target error) bool {
+	for _, p := range l {
+		if errors.Is(p, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first of the problems in the list that matches target, (in
+// the sense of errors.As), and if there is one sets target to it.
+func (l DSLErrorList) As(target interface{}) bool {
+	for _, p := range l {
+		if errors.As(p, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// SyntaxError is the underlying error of a DSLProblem when a statement is
+// malformed.
+type SyntaxError struct {
+	Token string // The text at fault, or empty for the whole statement.
+	Msg   string
+}
+
+func (e *SyntaxError) Error() string {
+	return e.Msg
+}
+
+// UnknownLifelineError is the error used when a lifeline is referred to
+// by a name that has not been declared in a life statement.
+type UnknownLifelineError struct {
+	Name string
+}
+
+func (e *UnknownLifelineError) Error() string {
+	return fmt.Sprintf("Unknown lifeline: %s", e.Name)
 }
@@ -0,0 +1,25 @@
+package geom
+
+import (
+	"math"
+
+	"github.com/peterhoward42/umli/graphics"
+)
+
+// arcSteps is how many straight line steps approximate an arc.
+const arcSteps = 24
+
+// MakeArc assembles the vertices of a polyline that approximates an
+// elliptical arc centred on (x, y). The angles are in radians, measured from
+// the positive X axis, and increase clockwise (because Y points downwards).
+// An arc from 0 to 2 Pi makes a closed ellipse.
+func MakeArc(x float64, y float64, radiusX float64, radiusY float64,
+	fromAngle float64, toAngle float64) []graphics.Point {
+	vertices := []graphics.Point{}
+	for i := 0; i <= arcSteps; i++ {
+		theta := fromAngle + (toAngle-fromAngle)*float64(i)/arcSteps
+		vertices = append(vertices, graphics.NewPoint(
+			x+radiusX*math.Cos(theta), y+radiusY*math.Sin(theta)))
+	}
+	return vertices
+}
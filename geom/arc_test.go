@@ -0,0 +1,24 @@
+package geom
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArcRunsFromAndToTheRightAngles(t *testing.T) {
+	assert := assert.New(t)
+	// The lower half of an ellipse.
+	vertices := MakeArc(10, 20, 4, 2, 0, math.Pi)
+	assert.Len(vertices, arcSteps+1)
+	first := vertices[0]
+	middle := vertices[arcSteps/2]
+	last := vertices[arcSteps]
+	assert.InDelta(14.0, first.X, 0.0001)
+	assert.InDelta(20.0, first.Y, 0.0001)
+	assert.InDelta(10.0, middle.X, 0.0001)
+	assert.InDelta(22.0, middle.Y, 0.0001)
+	assert.InDelta(6.0, last.X, 0.0001)
+	assert.InDelta(20.0, last.Y, 0.0001)
+}
@@ -4,9 +4,10 @@ import (
 	"github.com/peterhoward42/umli/graphics"
 )
 
-// makeArrow assembles the vertices required to make a horizontal!
+// MakeArrow assembles the vertices required to make a horizontal!
 // arrow head polygon that has its tip at (x2, y), and points in
-// the x1->x2 direction.
+// the x1->x2 direction. The tip is the middle vertex, so the same vertices
+// serve for an open arrow head, (drawn as a polyline).
 func MakeArrow(x1 float64, x2 float64, y float64,
 	arrowLen float64, arrowHeight float64) []graphics.Point {
 	dx := arrowLen
@@ -0,0 +1,22 @@
+package geom
+
+import (
+	"math"
+
+	"github.com/peterhoward42/umli/graphics"
+)
+
+// dotSides is how many sides the polygon has that approximates a dot.
+const dotSides = 16
+
+// MakeDot assembles the vertices of a polygon that approximates a circular
+// dot, centred on (x, y).
+func MakeDot(x float64, y float64, radius float64) []graphics.Point {
+	vertices := []graphics.Point{}
+	for i := 0; i < dotSides; i++ {
+		theta := 2 * math.Pi * float64(i) / dotSides
+		vertices = append(vertices, graphics.NewPoint(
+			x+radius*math.Cos(theta), y+radius*math.Sin(theta)))
+	}
+	return vertices
+}
@@ -0,0 +1,20 @@
+package geom
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDotVerticesLieOnTheCircle(t *testing.T) {
+	assert := assert.New(t)
+	vertices := MakeDot(10, 20, 2)
+	assert.Len(vertices, dotSides)
+	for _, v := range vertices {
+		assert.InDelta(2.0, math.Hypot(v.X-10, v.Y-20), 0.0001)
+	}
+	// The first vertex is at 3 o'clock.
+	assert.InDelta(12.0, vertices[0].X, 0.0001)
+	assert.InDelta(20.0, vertices[0].Y, 0.0001)
+}
@@ -3,9 +3,12 @@ module github.com/peterhoward42/umli
 go 1.14
 
 require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/fogleman/gg v1.3.1-0.20190826191358-4dc34561c649
 	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0
-	github.com/golangci/golangci-lint v1.24.0 // indirect
+	github.com/kr/pretty v0.1.0 // indirect
 	github.com/stretchr/testify v1.4.0
 	golang.org/x/image v0.0.0-20200119044424-58c23975cae1
+	gopkg.in/check.v1 v1.0.0-20190902080502-41f04d3bba15 // indirect
+	gopkg.in/yaml.v2 v2.2.7
 )
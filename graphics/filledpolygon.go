@@ -2,13 +2,17 @@ package graphics
 
 // FilledPoly represents a filled polygon.
 // Which can be used for an arrow head.
-type FilledPoly []Point // Do not repeat first point as last point.
+type FilledPoly struct {
+	Vertices []Point    // Do not repeat first point as last point.
+	Style    *FillStyle // Optional.
+	Origin   Origin     // Optional.
+}
 
 // IncludesThisVertex asserts that this polygon has one, and only one
 // vertex matching p.
 func (p *FilledPoly) IncludesThisVertex(q Point) bool {
 	count := 0
-	for _, v := range *p {
+	for _, v := range p.Vertices {
 		if v.EqualIsh(q) {
 			count++
 		}
@@ -9,7 +9,7 @@ import (
 func TestIncludesThisVertex(t *testing.T) {
 	assert := assert.New(t)
 
-	poly := FilledPoly{Point{2, 3}}
+	poly := FilledPoly{Vertices: []Point{{2, 3}}}
 
 	assert.True(poly.IncludesThisVertex(Point{2, 3}))
 	assert.False(poly.IncludesThisVertex(Point{2, 3.1}))
@@ -0,0 +1,400 @@
+package graphics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+/*
+This module provides a stable JSON encoding for a Model, so that the model
+can be handed to third party renderers, (for example a web front end that
+draws the diagram itself).
+
+The encoding is defined by a set of wire types below, rather than by
+encoding the Model types directly. This is so that the encoding does not
+change by accident when the Model types change. The encoding carries a
+version number, which must be incremented whenever the encoding changes.
+
+Version 2 added the optional styles, version 3 the optional background colour,
+version 4 the optional origins of the primitives, version 5 the polylines,
+and version 6 the optional sequence numbers in the origins. DecodeJSON still
+accepts the older versions, because each is a subset of its successor.
+
+The schema is documented in ../docs/graphics-model.schema.json.
+*/
+
+// JSONSchemaVersion is the version of the JSON encoding produced by
+// EncodeJSON.
+const JSONSchemaVersion = 6
+
+// oldestJSONSchemaVersion is the oldest version that DecodeJSON accepts.
+const oldestJSONSchemaVersion = 1
+
+// jsonSchemaName identifies a JSON document as an encoded Model.
+const jsonSchemaName = "umli-graphics-model"
+
+// EncodeJSON writes the JSON encoding of mdl to w.
+func EncodeJSON(w io.Writer, mdl *Model) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(newJSONModel(mdl)); err != nil {
+		return fmt.Errorf("encoder.Encode: %v", err)
+	}
+	return nil
+}
+
+// DecodeJSON reads a Model from the JSON encoding in r, (as produced by
+// EncodeJSON). It validates what it reads, and returns an error if the
+// encoding is malformed, or is of a version it does not understand.
+func DecodeJSON(r io.Reader) (*Model, error) {
+	var in jsonModel
+	if err := json.NewDecoder(r).Decode(&in); err != nil {
+		return nil, fmt.Errorf("decoder.Decode: %v", err)
+	}
+	mdl, err := in.toModel()
+	if err != nil {
+		return nil, fmt.Errorf("toModel: %v", err)
+	}
+	return mdl, nil
+}
+
+type jsonModel struct {
+	Schema      string         `json:"schema"`
+	Version     int            `json:"version"`
+	Width       float64        `json:"width"`
+	Height      float64        `json:"height"`
+	FontHeight  float64        `json:"fontHeight"`
+	Dash        jsonDash       `json:"dash"`
+	Background  string         `json:"background,omitempty"`
+	Lines       []jsonLine     `json:"lines"`
+	FilledPolys []jsonPoly     `json:"filledPolys"`
+	Polylines   []jsonPolyline `json:"polylines"`
+	Labels      []jsonLabel    `json:"labels"`
+}
+
+type jsonDash struct {
+	DashLen float64 `json:"dashLen"`
+	GapLen  float64 `json:"gapLen"`
+}
+
+type jsonPoint struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+type jsonLine struct {
+	P1     jsonPoint      `json:"p1"`
+	P2     jsonPoint      `json:"p2"`
+	Dashed bool           `json:"dashed"`
+	Style  *jsonLineStyle `json:"style,omitempty"`
+	jsonOrigin
+}
+
+type jsonPoly struct {
+	Vertices []jsonPoint    `json:"vertices"`
+	Style    *jsonFillStyle `json:"style,omitempty"`
+	jsonOrigin
+}
+
+type jsonPolyline struct {
+	Vertices []jsonPoint    `json:"vertices"`
+	Style    *jsonLineStyle `json:"style,omitempty"`
+	jsonOrigin
+}
+
+type jsonLabel struct {
+	Text       string         `json:"text"`
+	FontHeight float64        `json:"fontHeight"`
+	Anchor     jsonPoint      `json:"anchor"`
+	HJust      Justification  `json:"hJust"`
+	VJust      Justification  `json:"vJust"`
+	Style      *jsonTextStyle `json:"style,omitempty"`
+	jsonOrigin
+}
+
+// jsonOrigin is embedded in each primitive, so that its fields appear
+// alongside the primitive's own.
+type jsonOrigin struct {
+	Kind           ElementKind `json:"kind,omitempty"`
+	SourceLine     int         `json:"sourceLine,omitempty"`
+	SequenceNumber string      `json:"sequenceNumber,omitempty"`
+}
+
+// The styles encode colours in their hexadecimal form, (e.g. "#ff8000"),
+// and omit anything that is not set.
+
+type jsonLineStyle struct {
+	Colour string  `json:"colour,omitempty"`
+	Width  float64 `json:"width,omitempty"`
+}
+
+type jsonFillStyle struct {
+	Colour string `json:"colour,omitempty"`
+}
+
+type jsonTextStyle struct {
+	Colour string `json:"colour,omitempty"`
+	Bold   bool   `json:"bold,omitempty"`
+	Italic bool   `json:"italic,omitempty"`
+}
+
+// newJSONModel makes the wire representation of mdl.
+func newJSONModel(mdl *Model) jsonModel {
+	out := jsonModel{
+		Schema:      jsonSchemaName,
+		Version:     JSONSchemaVersion,
+		Width:       mdl.Width,
+		Height:      mdl.Height,
+		FontHeight:  mdl.FontHeight,
+		Dash:        jsonDash{mdl.DashLineDashLen, mdl.DashLineGapLen},
+		Background:  hexOrEmpty(mdl.Background),
+		Lines:       []jsonLine{},
+		FilledPolys: []jsonPoly{},
+		Polylines:   []jsonPolyline{},
+		Labels:      []jsonLabel{},
+	}
+	prims := mdl.Primitives
+	for _, line := range prims.Lines {
+		out.Lines = append(out.Lines, jsonLine{
+			jsonPoint(line.P1), jsonPoint(line.P2), line.Dashed,
+			newJSONLineStyle(line.Style), jsonOrigin(line.Origin)})
+	}
+	for _, poly := range prims.FilledPolys {
+		out.FilledPolys = append(out.FilledPolys,
+			jsonPoly{newJSONPoints(poly.Vertices), newJSONFillStyle(poly.Style),
+				jsonOrigin(poly.Origin)})
+	}
+	for _, polyline := range prims.Polylines {
+		out.Polylines = append(out.Polylines,
+			jsonPolyline{newJSONPoints(polyline.Vertices),
+				newJSONLineStyle(polyline.Style),
+				jsonOrigin(polyline.Origin)})
+	}
+	for _, label := range prims.Labels {
+		out.Labels = append(out.Labels, jsonLabel{
+			Text:       label.TheString,
+			FontHeight: label.FontHeight,
+			Anchor:     jsonPoint(label.Anchor),
+			HJust:      label.HJust,
+			VJust:      label.VJust,
+			Style:      newJSONTextStyle(label.Style),
+			jsonOrigin: jsonOrigin(label.Origin),
+		})
+	}
+	return out
+}
+
+func newJSONPoints(points []Point) []jsonPoint {
+	out := []jsonPoint{}
+	for _, point := range points {
+		out = append(out, jsonPoint(point))
+	}
+	return out
+}
+
+func newJSONLineStyle(style *LineStyle) *jsonLineStyle {
+	if style == nil {
+		return nil
+	}
+	return &jsonLineStyle{hexOrEmpty(style.Colour), style.Width}
+}
+
+func newJSONFillStyle(style *FillStyle) *jsonFillStyle {
+	if style == nil {
+		return nil
+	}
+	return &jsonFillStyle{hexOrEmpty(style.Colour)}
+}
+
+func newJSONTextStyle(style *TextStyle) *jsonTextStyle {
+	if style == nil {
+		return nil
+	}
+	return &jsonTextStyle{hexOrEmpty(style.Colour), style.Bold, style.Italic}
+}
+
+// hexOrEmpty provides the hexadecimal form of c, or an empty string when c
+// is nil.
+func hexOrEmpty(c *Colour) string {
+	if c == nil {
+		return ""
+	}
+	return c.Hex()
+}
+
+// toModel makes a Model from its wire representation, validating it as it
+// goes.
+func (in jsonModel) toModel() (*Model, error) {
+	if in.Schema != jsonSchemaName {
+		return nil, fmt.Errorf("schema is <%s>, expected <%s>",
+			in.Schema, jsonSchemaName)
+	}
+	if in.Version < oldestJSONSchemaVersion || in.Version > JSONSchemaVersion {
+		return nil, fmt.Errorf(
+			"unsupported schema version: %d (supported versions are %d to %d)",
+			in.Version, oldestJSONSchemaVersion, JSONSchemaVersion)
+	}
+	mdl := NewModel(in.Width, in.FontHeight, in.Dash.DashLen, in.Dash.GapLen)
+	mdl.Height = in.Height
+	background, err := parseColourOrEmpty(in.Background)
+	if err != nil {
+		return nil, fmt.Errorf("background: %v", err)
+	}
+	mdl.Background = background
+	prims := mdl.Primitives
+	for i, line := range in.Lines {
+		style, err := line.Style.toStyle()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i, err)
+		}
+		if err := line.jsonOrigin.validate(); err != nil {
+			return nil, fmt.Errorf("line %d: %v", i, err)
+		}
+		prims.Lines = append(prims.Lines, Line{Point(line.P1), Point(line.P2),
+			line.Dashed, style, Origin(line.jsonOrigin)})
+	}
+	for i, poly := range in.FilledPolys {
+		if len(poly.Vertices) < 3 {
+			return nil, fmt.Errorf(
+				"filled polygon %d has fewer than 3 vertices", i)
+		}
+		style, err := poly.Style.toStyle()
+		if err != nil {
+			return nil, fmt.Errorf("filled polygon %d: %v", i, err)
+		}
+		if err := poly.jsonOrigin.validate(); err != nil {
+			return nil, fmt.Errorf("filled polygon %d: %v", i, err)
+		}
+		prims.FilledPolys = append(prims.FilledPolys, FilledPoly{
+			toPoints(poly.Vertices), style, Origin(poly.jsonOrigin)})
+	}
+	for i, polyline := range in.Polylines {
+		if len(polyline.Vertices) < 2 {
+			return nil, fmt.Errorf(
+				"polyline %d has fewer than 2 vertices", i)
+		}
+		style, err := polyline.Style.toStyle()
+		if err != nil {
+			return nil, fmt.Errorf("polyline %d: %v", i, err)
+		}
+		if err := polyline.jsonOrigin.validate(); err != nil {
+			return nil, fmt.Errorf("polyline %d: %v", i, err)
+		}
+		prims.Polylines = append(prims.Polylines, Polyline{
+			toPoints(polyline.Vertices), style, Origin(polyline.jsonOrigin)})
+	}
+	for i, label := range in.Labels {
+		if !isOneOf(label.HJust, Left, Centre, Right) {
+			return nil, fmt.Errorf(
+				"label %d has invalid horizontal justification: <%s>",
+				i, label.HJust)
+		}
+		if !isOneOf(label.VJust, Top, Centre, Bottom) {
+			return nil, fmt.Errorf(
+				"label %d has invalid vertical justification: <%s>",
+				i, label.VJust)
+		}
+		style, err := label.Style.toStyle()
+		if err != nil {
+			return nil, fmt.Errorf("label %d: %v", i, err)
+		}
+		if err := label.jsonOrigin.validate(); err != nil {
+			return nil, fmt.Errorf("label %d: %v", i, err)
+		}
+		prims.Labels = append(prims.Labels, Label{
+			TheString:  label.Text,
+			FontHeight: label.FontHeight,
+			Anchor:     Point(label.Anchor),
+			HJust:      label.HJust,
+			VJust:      label.VJust,
+			Style:      style,
+			Origin:     Origin(label.jsonOrigin),
+		})
+	}
+	return mdl, nil
+}
+
+// toPoints is the inverse of newJSONPoints.
+func toPoints(in []jsonPoint) []Point {
+	out := []Point{}
+	for _, point := range in {
+		out = append(out, Point(point))
+	}
+	return out
+}
+
+func (in *jsonLineStyle) toStyle() (*LineStyle, error) {
+	if in == nil {
+		return nil, nil
+	}
+	colour, err := parseColourOrEmpty(in.Colour)
+	if err != nil {
+		return nil, err
+	}
+	if in.Width < 0 {
+		return nil, fmt.Errorf("line width must not be negative")
+	}
+	return &LineStyle{colour, in.Width}, nil
+}
+
+func (in *jsonFillStyle) toStyle() (*FillStyle, error) {
+	if in == nil {
+		return nil, nil
+	}
+	colour, err := parseColourOrEmpty(in.Colour)
+	if err != nil {
+		return nil, err
+	}
+	return &FillStyle{colour}, nil
+}
+
+func (in *jsonTextStyle) toStyle() (*TextStyle, error) {
+	if in == nil {
+		return nil, nil
+	}
+	colour, err := parseColourOrEmpty(in.Colour)
+	if err != nil {
+		return nil, err
+	}
+	return &TextStyle{colour, in.Bold, in.Italic}, nil
+}
+
+// validate checks that the kind is one of those known, (or empty), and that
+// the source line is not negative.
+func (in jsonOrigin) validate() error {
+	if in.SourceLine < 0 {
+		return fmt.Errorf("source line must not be negative")
+	}
+	if in.Kind == "" {
+		return nil
+	}
+	for _, kind := range ElementKinds {
+		if in.Kind == kind {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown element kind: <%s>", in.Kind)
+}
+
+// parseColourOrEmpty is the inverse of hexOrEmpty.
+func parseColourOrEmpty(s string) (*Colour, error) {
+	if s == "" {
+		return nil, nil
+	}
+	colour, err := ParseColour(s)
+	if err != nil {
+		return nil, err
+	}
+	return &colour, nil
+}
+
+// isOneOf returns true if j is among the candidates given.
+func isOneOf(j Justification, candidates ...Justification) bool {
+	for _, candidate := range candidates {
+		if j == candidate {
+			return true
+		}
+	}
+	return false
+}
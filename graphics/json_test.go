@@ -0,0 +1,197 @@
+package graphics
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// exampleModel is a DRY test helper that makes a Model with at least one of
+// every kind of primitive, and every justification value.
+func exampleModel() *Model {
+	mdl := NewModel(2000, 20, 10, 5)
+	mdl.Height = 750.5
+	prims := mdl.Primitives
+	prims.AddLine(1, 2, 3, 4, false)
+	prims.AddLine(5.25, 6, 7, 8.125, true)
+	prims.AddFilledPoly([]Point{{10, 10}, {20, 15}, {10, 20}})
+	prims.AddPolyline([]Point{{30, 10}, {40, 15}, {30, 20}})
+	prims.AddLabel("left top", 20, 100, 200, Left, Top)
+	prims.AddLabel("centre centre <&>", 20, 300, 400, Centre, Centre)
+	prims.AddLabel("right bottom", 15, 500, 600, Right, Bottom)
+	return mdl
+}
+
+func TestJSONRoundTripPreservesTheModel(t *testing.T) {
+	assert := assert.New(t)
+	original := exampleModel()
+	var buf bytes.Buffer
+	err := EncodeJSON(&buf, original)
+	assert.NoError(err)
+	decoded, err := DecodeJSON(&buf)
+	assert.NoError(err)
+	assert.Equal(original, decoded)
+}
+
+func TestJSONRoundTripOfEmptyModel(t *testing.T) {
+	assert := assert.New(t)
+	original := NewModel(2000, 20, 10, 5)
+	var buf bytes.Buffer
+	err := EncodeJSON(&buf, original)
+	assert.NoError(err)
+	assert.Contains(buf.String(), `"lines": []`)
+	decoded, err := DecodeJSON(&buf)
+	assert.NoError(err)
+	assert.Equal(original, decoded)
+}
+
+func TestJSONEncodingUsesTheDocumentedFieldNames(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	err := EncodeJSON(&buf, exampleModel())
+	assert.NoError(err)
+
+	// Decode generically, to check the names third parties will rely upon.
+	var generic map[string]interface{}
+	err = json.Unmarshal(buf.Bytes(), &generic)
+	assert.NoError(err)
+	assert.Equal("umli-graphics-model", generic["schema"])
+	assert.Equal(6.0, generic["version"])
+	assert.Equal(2000.0, generic["width"])
+	assert.Equal(750.5, generic["height"])
+	assert.Equal(20.0, generic["fontHeight"])
+	assert.Equal(map[string]interface{}{"dashLen": 10.0, "gapLen": 5.0},
+		generic["dash"])
+
+	line := generic["lines"].([]interface{})[1].(map[string]interface{})
+	assert.Equal(map[string]interface{}{"x": 5.25, "y": 6.0}, line["p1"])
+	assert.Equal(true, line["dashed"])
+
+	poly := generic["filledPolys"].([]interface{})[0].(map[string]interface{})
+	assert.Len(poly["vertices"], 3)
+
+	polyline := generic["polylines"].([]interface{})[0].(map[string]interface{})
+	assert.Len(polyline["vertices"], 3)
+
+	label := generic["labels"].([]interface{})[2].(map[string]interface{})
+	assert.Equal("right bottom", label["text"])
+	assert.Equal("Right", label["hJust"])
+	assert.Equal("Bottom", label["vJust"])
+}
+
+func TestJSONDecodingRejectsBadInput(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := DecodeJSON(strings.NewReader("not json"))
+	assert.Error(err)
+
+	_, err = DecodeJSON(strings.NewReader(
+		`{"schema": "something-else", "version": 1}`))
+	assert.EqualError(err, "toModel: schema is <something-else>, "+
+		"expected <umli-graphics-model>")
+
+	_, err = DecodeJSON(strings.NewReader(
+		`{"schema": "umli-graphics-model", "version": 99}`))
+	assert.EqualError(err, "toModel: unsupported schema version: 99 "+
+		"(supported versions are 1 to 6)")
+
+	_, err = DecodeJSON(strings.NewReader(`{
+		"schema": "umli-graphics-model", "version": 1,
+		"labels": [{"text": "x", "hJust": "Top", "vJust": "Top"}]}`))
+	assert.EqualError(err, "toModel: label 0 has invalid horizontal "+
+		"justification: <Top>")
+
+	_, err = DecodeJSON(strings.NewReader(`{
+		"schema": "umli-graphics-model", "version": 1,
+		"filledPolys": [{"vertices": [{"x": 1, "y": 2}]}]}`))
+	assert.EqualError(err, "toModel: filled polygon 0 has fewer than 3 "+
+		"vertices")
+
+	_, err = DecodeJSON(strings.NewReader(`{
+		"schema": "umli-graphics-model", "version": 5,
+		"polylines": [{"vertices": [{"x": 1, "y": 2}]}]}`))
+	assert.EqualError(err, "toModel: polyline 0 has fewer than 2 vertices")
+}
+
+func TestJSONRoundTripPreservesStylesBackgroundAndOrigins(t *testing.T) {
+	assert := assert.New(t)
+	original := exampleModel()
+	red := Colour{R: 255}
+	original.Background = &Colour{1, 2, 3}
+	prims := original.Primitives
+	prims.Lines[0].Style = &LineStyle{Colour: &red, Width: 3}
+	prims.Lines[1].Style = &LineStyle{}
+	prims.FilledPolys[0].Style = &FillStyle{Colour: &red}
+	prims.Polylines[0].Style = &LineStyle{Width: 2}
+	prims.Labels[0].Style = &TextStyle{Colour: &red, Bold: true}
+	prims.Labels[1].Style = &TextStyle{Italic: true}
+	prims.Lines[0].Origin = Origin{InteractionLine, 12, "7."}
+	prims.FilledPolys[0].Origin = Origin{Kind: ArrowHead}
+	prims.Polylines[0].Origin = Origin{ArrowHead, 4, ""}
+	prims.Labels[2].Origin = Origin{SourceLine: 3}
+	var buf bytes.Buffer
+	err := EncodeJSON(&buf, original)
+	assert.NoError(err)
+	assert.Contains(buf.String(), `"colour": "#ff0000"`)
+	assert.Contains(buf.String(), `"background": "#010203"`)
+	assert.Contains(buf.String(), `"kind": "InteractionLine",`)
+	assert.Contains(buf.String(), `"sourceLine": 12`)
+	assert.Contains(buf.String(), `"sequenceNumber": "7."`)
+	decoded, err := DecodeJSON(&buf)
+	assert.NoError(err)
+	assert.Equal(original, decoded)
+}
+
+func TestJSONOmitsStylesThatAreNotSet(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	err := EncodeJSON(&buf, exampleModel())
+	assert.NoError(err)
+	assert.NotContains(buf.String(), "style")
+	assert.NotContains(buf.String(), "background")
+	assert.NotContains(buf.String(), "kind")
+	assert.NotContains(buf.String(), "sourceLine")
+	assert.NotContains(buf.String(), "sequenceNumber")
+}
+
+func TestJSONDecodesVersion1Documents(t *testing.T) {
+	assert := assert.New(t)
+	mdl, err := DecodeJSON(strings.NewReader(`{
+		"schema": "umli-graphics-model", "version": 1,
+		"lines": [{"p1": {"x": 1, "y": 2}, "p2": {"x": 3, "y": 4}}]}`))
+	assert.NoError(err)
+	assert.Len(mdl.Primitives.Lines, 1)
+	assert.Nil(mdl.Primitives.Lines[0].Style)
+}
+
+func TestJSONDecodingRejectsBadStyles(t *testing.T) {
+	assert := assert.New(t)
+	_, err := DecodeJSON(strings.NewReader(`{
+		"schema": "umli-graphics-model", "version": 2,
+		"lines": [{"style": {"colour": "red"}}]}`))
+	assert.EqualError(err,
+		"toModel: line 0: Invalid colour: <red>, expected #rrggbb")
+
+	_, err = DecodeJSON(strings.NewReader(`{
+		"schema": "umli-graphics-model", "version": 2,
+		"lines": [{"style": {"width": -1}}]}`))
+	assert.EqualError(err, "toModel: line 0: line width must not be negative")
+
+	_, err = DecodeJSON(strings.NewReader(`{
+		"schema": "umli-graphics-model", "version": 3, "background": "#12"}`))
+	assert.EqualError(err,
+		"toModel: background: Invalid colour: <#12>, expected #rrggbb")
+
+	_, err = DecodeJSON(strings.NewReader(`{
+		"schema": "umli-graphics-model", "version": 4,
+		"labels": [{"hJust": "Left", "vJust": "Top", "kind": "Blob"}]}`))
+	assert.EqualError(err, "toModel: label 0: unknown element kind: <Blob>")
+
+	_, err = DecodeJSON(strings.NewReader(`{
+		"schema": "umli-graphics-model", "version": 4,
+		"lines": [{"sourceLine": -1}]}`))
+	assert.EqualError(err, "toModel: line 0: source line must not be negative")
+}
@@ -7,6 +7,7 @@ type Model struct {
 	FontHeight      float64
 	DashLineDashLen float64
 	DashLineGapLen  float64
+	Background      *Colour // Optional. (Renderers default to white).
 	Primitives      *Primitives
 }
 
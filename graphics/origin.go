@@ -0,0 +1,78 @@
+package graphics
+
+/*
+This module provides the means for primitives to say where they came from:
+which element of the diagram they are part of, and which line of the DSL
+script produced them. So that, for example, an interactive viewer can
+highlight the DSL line when the user clicks on an arrow, and the reverse.
+*/
+
+// ElementKind says which kind of diagram element a primitive is part of.
+type ElementKind string
+
+// The values for ElementKind. The zero value means the kind is unknown.
+const (
+	InteractionLine ElementKind = "InteractionLine" // Including self loops and labels.
+	ArrowHead       ElementKind = "ArrowHead"
+	Lifeline        ElementKind = "Lifeline"
+	ActivityBox     ElementKind = "ActivityBox"
+	TitleBox        ElementKind = "TitleBox" // At the top of a lifeline.
+	Frame           ElementKind = "Frame"    // Including the diagram's title.
+	Note            ElementKind = "Note"
+	Fragment        ElementKind = "Fragment"
+)
+
+// ElementKinds provides all the (known) element kinds.
+var ElementKinds = []ElementKind{InteractionLine, ArrowHead, Lifeline,
+	ActivityBox, TitleBox, Frame, Note, Fragment}
+
+// Origin says where a primitive came from. Its zero value means unknown.
+type Origin struct {
+	Kind           ElementKind
+	SourceLine     int    // The DSL script line, counted from 1. (Or zero).
+	SequenceNumber string // Of the message, when autonumbered. E.g. "7."
+}
+
+// Mark records how many of each type of primitive a Primitives holds, so that
+// those added afterwards can be identified.
+type Mark struct {
+	lines       int
+	filledPolys int
+	polylines   int
+	labels      int
+}
+
+// Mark provides a Mark for the primitives held now.
+func (p *Primitives) Mark() Mark {
+	return Mark{len(p.Lines), len(p.FilledPolys), len(p.Polylines),
+		len(p.Labels)}
+}
+
+/*
+TagSince sets the origin of all the primitives added since mark was made.
+Except for those that have a Kind already, which are left alone. This allows
+the code that makes a diagram element to tag its parts more specifically,
+(an arrow head for example), and leave its caller to tag the rest.
+*/
+func (p *Primitives) TagSince(mark Mark, origin Origin) {
+	for i := mark.lines; i < len(p.Lines); i++ {
+		if p.Lines[i].Origin.Kind == "" {
+			p.Lines[i].Origin = origin
+		}
+	}
+	for i := mark.filledPolys; i < len(p.FilledPolys); i++ {
+		if p.FilledPolys[i].Origin.Kind == "" {
+			p.FilledPolys[i].Origin = origin
+		}
+	}
+	for i := mark.polylines; i < len(p.Polylines); i++ {
+		if p.Polylines[i].Origin.Kind == "" {
+			p.Polylines[i].Origin = origin
+		}
+	}
+	for i := mark.labels; i < len(p.Labels); i++ {
+		if p.Labels[i].Origin.Kind == "" {
+			p.Labels[i].Origin = origin
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package graphics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagSinceTagsOnlyNewUntaggedPrimitives(t *testing.T) {
+	assert := assert.New(t)
+	prims := NewPrimitives()
+	prims.AddLine(0, 0, 1, 1, false)
+
+	mark := prims.Mark()
+	prims.AddLine(2, 2, 3, 3, false)
+	prims.AddLabel("foo", 10, 0, 0, Left, Top)
+	prims.AddFilledPoly([]Point{{0, 0}, {1, 0}, {0, 1}})
+	prims.TagSince(prims.Mark(), Origin{Kind: Note}) // Tags nothing.
+	arrowMark := prims.Mark()
+	prims.AddFilledPoly([]Point{{5, 0}, {6, 0}, {5, 1}})
+	prims.AddPolyline([]Point{{7, 0}, {8, 0}, {7, 1}})
+	prims.TagSince(arrowMark, Origin{Kind: ArrowHead, SourceLine: 4})
+	prims.TagSince(mark, Origin{Kind: InteractionLine, SourceLine: 4})
+
+	assert.Equal(Origin{}, prims.Lines[0].Origin)
+	assert.Equal(Origin{InteractionLine, 4, ""}, prims.Lines[1].Origin)
+	assert.Equal(Origin{InteractionLine, 4, ""}, prims.Labels[0].Origin)
+	assert.Equal(Origin{InteractionLine, 4, ""}, prims.FilledPolys[0].Origin)
+	assert.Equal(Origin{ArrowHead, 4, ""}, prims.FilledPolys[1].Origin)
+	assert.Equal(Origin{ArrowHead, 4, ""}, prims.Polylines[0].Origin)
+}
+
+func TestForSourceLineFindsThePrimitivesFromALine(t *testing.T) {
+	assert := assert.New(t)
+	prims := NewPrimitives()
+	prims.AddLine(0, 0, 1, 1, false)
+	mark := prims.Mark()
+	prims.AddLine(2, 2, 3, 3, false)
+	prims.AddLabel("foo", 10, 0, 0, Left, Top)
+	prims.AddFilledPoly([]Point{{0, 0}, {1, 0}, {0, 1}})
+	prims.TagSince(mark, Origin{Kind: InteractionLine, SourceLine: 4})
+
+	found := prims.ForSourceLine(4)
+	assert.Len(found.Lines, 1)
+	assert.Equal(2.0, found.Lines[0].P1.X)
+	assert.Len(found.Labels, 1)
+	assert.Len(found.FilledPolys, 1)
+
+	found = prims.ForSourceLine(5)
+	assert.Len(found.Lines, 0)
+	assert.Len(found.Labels, 0)
+	assert.Len(found.FilledPolys, 0)
+}
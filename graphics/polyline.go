@@ -0,0 +1,31 @@
+package graphics
+
+// Polyline represents a sequence of connected straight lines, that is
+// stroked, but not filled (nor closed). Which can be used for an open arrow
+// head.
+type Polyline struct {
+	Vertices []Point
+	Style    *LineStyle // Optional.
+	Origin   Origin     // Optional.
+}
+
+// ArrowHeadStyle is a type safe string for the ways an arrow head can be
+// drawn.
+type ArrowHeadStyle string
+
+// The corresponding values for arrow head styles.
+const (
+	FilledArrowHead ArrowHeadStyle = "Filled" // A filled triangle.
+	OpenArrowHead   ArrowHeadStyle = "Open"   // Two strokes meeting at the tip.
+)
+
+// AddArrowHead adds an arrow head to the Primitive's store, with the given
+// vertices, (as made by geom.MakeArrow). A filled head is added as a
+// FilledPoly, and an open one as a Polyline.
+func (p *Primitives) AddArrowHead(vertices []Point, style ArrowHeadStyle) {
+	if style == OpenArrowHead {
+		p.AddPolyline(vertices)
+		return
+	}
+	p.AddFilledPoly(vertices)
+}
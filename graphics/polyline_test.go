@@ -0,0 +1,21 @@
+package graphics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddArrowHeadHonoursTheStyle(t *testing.T) {
+	assert := assert.New(t)
+	vertices := []Point{{0, 0}, {1, 1}, {0, 2}}
+	prims := NewPrimitives()
+	prims.AddArrowHead(vertices, FilledArrowHead)
+	assert.Len(prims.FilledPolys, 1)
+	assert.Len(prims.Polylines, 0)
+
+	prims = NewPrimitives()
+	prims.AddArrowHead(vertices, OpenArrowHead)
+	assert.Len(prims.FilledPolys, 0)
+	assert.Equal([]Polyline{{Vertices: vertices}}, prims.Polylines)
+}
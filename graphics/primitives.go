@@ -22,7 +22,9 @@ func NewPoint(x, y float64) Point {
 type Line struct {
 	P1     Point
 	P2     Point
-	Dashed bool // vs. Full
+	Dashed bool       // vs. Full
+	Style  *LineStyle // Optional.
+	Origin Origin     // Optional.
 }
 
 // Justification is a type safe string for text justifications
@@ -45,37 +47,53 @@ type Label struct {
 	Anchor     Point
 	HJust      Justification
 	VJust      Justification
+	Style      *TextStyle // Optional.
+	Origin     Origin     // Optional.
 }
 
-// Primitives is a container for a set of: Line, FilledPoly and Label(s).
+// Primitives is a container for a set of: Line, FilledPoly, Polyline and
+// Label(s).
 type Primitives struct {
 	Lines       []Line
 	FilledPolys []FilledPoly
+	Polylines   []Polyline
 	Labels      []Label
 }
 
 // NewPrimitives constructs a Primitives ready to use.
 func NewPrimitives() *Primitives {
-	return &Primitives{[]Line{}, []FilledPoly{}, []Label{}}
+	return &Primitives{[]Line{}, []FilledPoly{}, []Polyline{}, []Label{}}
 }
 
 // AddLine adds the given line to the Primitive's line store.
 func (p *Primitives) AddLine(
 	x1 float64, y1 float64, x2 float64, y2 float64, dashed bool) {
-	line := Line{Point{x1, y1}, Point{x2, y2}, dashed}
+	line := Line{P1: Point{x1, y1}, P2: Point{x2, y2}, Dashed: dashed}
 	p.Lines = append(p.Lines, line)
 }
 
 // AddFilledPoly adds the given filled polygon to the Primitive's store.
 func (p *Primitives) AddFilledPoly(vertices []Point) {
-	poly := FilledPoly(vertices)
+	poly := FilledPoly{Vertices: vertices}
 	p.FilledPolys = append(p.FilledPolys, poly)
 }
 
+// AddPolyline adds the given polyline to the Primitive's store.
+func (p *Primitives) AddPolyline(vertices []Point) {
+	polyline := Polyline{Vertices: vertices}
+	p.Polylines = append(p.Polylines, polyline)
+}
+
 // AddLabel adds a Label to the Primitive's Lable store.
 func (p *Primitives) AddLabel(theString string, fontHeight float64,
 	x float64, y float64, hJust Justification, vJust Justification) {
-	label := Label{theString, fontHeight, Point{x, y}, hJust, vJust}
+	label := Label{
+		TheString:  theString,
+		FontHeight: fontHeight,
+		Anchor:     Point{x, y},
+		HJust:      hJust,
+		VJust:      vJust,
+	}
 	p.Labels = append(p.Labels, label)
 }
 
@@ -105,5 +123,6 @@ func (p *Primitives) AddRect(
 func (p *Primitives) Add(newPrims *Primitives) {
 	p.Lines = append(p.Lines, newPrims.Lines...)
 	p.FilledPolys = append(p.FilledPolys, newPrims.FilledPolys...)
+	p.Polylines = append(p.Polylines, newPrims.Polylines...)
 	p.Labels = append(p.Labels, newPrims.Labels...)
 }
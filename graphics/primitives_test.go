@@ -11,10 +11,10 @@ func TestAddRectDecomposesCorrectly(t *testing.T) {
 	p := NewPrimitives()
 	p.AddRect(0, 0, 4, 3)
 	assert.Len(p.Lines, 4)
-	assert.Equal(Line{Point{0, 0}, Point{4, 0}, false}, p.Lines[0])
-	assert.Equal(Line{Point{4, 0}, Point{4, 3}, false}, p.Lines[1])
-	assert.Equal(Line{Point{4, 3}, Point{0, 3}, false}, p.Lines[2])
-	assert.Equal(Line{Point{0, 3}, Point{0, 0}, false}, p.Lines[3])
+	assert.Equal(Line{P1: Point{0, 0}, P2: Point{4, 0}}, p.Lines[0])
+	assert.Equal(Line{P1: Point{4, 0}, P2: Point{4, 3}}, p.Lines[1])
+	assert.Equal(Line{P1: Point{4, 3}, P2: Point{0, 3}}, p.Lines[2])
+	assert.Equal(Line{P1: Point{0, 3}, P2: Point{0, 0}}, p.Lines[3])
 
 	sampleLine := p.Lines[3]
 	start := sampleLine.P1
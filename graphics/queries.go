@@ -67,10 +67,10 @@ func (p *Primitives) ContainsRect(tl, br Point) bool {
 
 	required := []Line{}
 
-	required = append(required, Line{Point{l, t}, Point{r, t}, false})
-	required = append(required, Line{Point{r, t}, Point{r, b}, false})
-	required = append(required, Line{Point{r, b}, Point{l, b}, false})
-	required = append(required, Line{Point{l, b}, Point{l, t}, false})
+	required = append(required, Line{P1: Point{l, t}, P2: Point{r, t}})
+	required = append(required, Line{P1: Point{r, t}, P2: Point{r, b}})
+	required = append(required, Line{P1: Point{r, b}, P2: Point{l, b}})
+	required = append(required, Line{P1: Point{l, b}, P2: Point{l, t}})
 
 	for _, line := range required {
 		if !p.ContainsLine(line) {
@@ -134,3 +134,30 @@ func (p *Primitives) BoundingBoxOfLines() (left, top, right, bottom float64) {
 	}
 	return
 }
+
+// ForSourceLine provides the primitives that came from the given line of the
+// DSL script, (in a new Primitives).
+func (p *Primitives) ForSourceLine(line int) *Primitives {
+	found := NewPrimitives()
+	for _, l := range p.Lines {
+		if l.Origin.SourceLine == line {
+			found.Lines = append(found.Lines, l)
+		}
+	}
+	for _, poly := range p.FilledPolys {
+		if poly.Origin.SourceLine == line {
+			found.FilledPolys = append(found.FilledPolys, poly)
+		}
+	}
+	for _, polyline := range p.Polylines {
+		if polyline.Origin.SourceLine == line {
+			found.Polylines = append(found.Polylines, polyline)
+		}
+	}
+	for _, label := range p.Labels {
+		if label.Origin.SourceLine == line {
+			found.Labels = append(found.Labels, label)
+		}
+	}
+	return found
+}
@@ -77,10 +77,10 @@ func TestContainsLine(t *testing.T) {
 	p3 := Point{9, 11}
 
 	cases := []testcase{}
-	cases = append(cases, testcase{Line{p1, p2, true}, true})   // canonical
-	cases = append(cases, testcase{Line{p2, p1, true}, true})   // reversed
-	cases = append(cases, testcase{Line{p1, p3, true}, false})  // different geom
-	cases = append(cases, testcase{Line{p1, p2, false}, false}) // not dashed
+	cases = append(cases, testcase{Line{P1: p1, P2: p2, Dashed: true}, true})  // canonical
+	cases = append(cases, testcase{Line{P1: p2, P2: p1, Dashed: true}, true})  // reversed
+	cases = append(cases, testcase{Line{P1: p1, P2: p3, Dashed: true}, false}) // different geom
+	cases = append(cases, testcase{Line{P1: p1, P2: p2}, false})               // not dashed
 
 	p := NewPrimitives()
 	p.AddLine(1, 2, 9, 10, true)
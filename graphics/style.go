@@ -0,0 +1,116 @@
+package graphics
+
+import (
+	"fmt"
+	"strconv"
+)
+
+/*
+This module provides the optional styling that can be attached to the
+primitives. A primitive without a style (i.e. a nil Style field) is drawn
+in the renderer's default way, which is how the model was drawn before styles
+existed. Similarly, the zero value of each style field means "use the
+default". So for example a LineStyle that only sets Colour, leaves the line
+width alone.
+*/
+
+// Colour is an opaque colour, held as 8 bit red, green and blue components.
+type Colour struct {
+	R uint8
+	G uint8
+	B uint8
+}
+
+// Some commonly used colours.
+var (
+	Black = Colour{0, 0, 0}
+	White = Colour{255, 255, 255}
+)
+
+// ParseColour makes a Colour from its hexadecimal form, as used in HTML.
+// E.g. "#ff8000". (The leading hash is optional).
+func ParseColour(s string) (Colour, error) {
+	hex := s
+	if len(hex) > 0 && hex[0] == '#' {
+		hex = hex[1:]
+	}
+	if len(hex) != 6 {
+		return Colour{}, fmt.Errorf("Invalid colour: <%s>, expected #rrggbb", s)
+	}
+	rgb, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return Colour{}, fmt.Errorf("Invalid colour: <%s>, expected #rrggbb", s)
+	}
+	return Colour{uint8(rgb >> 16), uint8(rgb >> 8), uint8(rgb)}, nil
+}
+
+// Hex provides the hexadecimal form of the colour, e.g. "#ff8000".
+func (c Colour) Hex() string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// LineStyle is the optional styling for a Line.
+type LineStyle struct {
+	Colour *Colour
+	Width  float64 // In model units. (The default is 1).
+}
+
+// FillStyle is the optional styling for a FilledPoly.
+type FillStyle struct {
+	Colour *Colour
+}
+
+// TextStyle is the optional styling for a Label.
+type TextStyle struct {
+	Colour *Colour
+	Bold   bool
+	Italic bool
+}
+
+/*
+The following accessors are safe to call on a nil style, and provide the
+fallback given in place of anything the style does not set. They save
+renderers from checking for nil at every turn.
+*/
+
+// StrokeColour provides the colour to draw the line in.
+func (s *LineStyle) StrokeColour(fallback Colour) Colour {
+	if s == nil || s.Colour == nil {
+		return fallback
+	}
+	return *s.Colour
+}
+
+// StrokeWidth provides the width to draw the line at.
+func (s *LineStyle) StrokeWidth(fallback float64) float64 {
+	if s == nil || s.Width == 0 {
+		return fallback
+	}
+	return s.Width
+}
+
+// FillColour provides the colour to fill the polygon with.
+func (s *FillStyle) FillColour(fallback Colour) Colour {
+	if s == nil || s.Colour == nil {
+		return fallback
+	}
+	return *s.Colour
+}
+
+// TextColour provides the colour to draw the text in.
+func (s *TextStyle) TextColour(fallback Colour) Colour {
+	if s == nil || s.Colour == nil {
+		return fallback
+	}
+	return *s.Colour
+}
+
+// IsBold says if the text should be bold.
+func (s *TextStyle) IsBold() bool {
+	return s != nil && s.Bold
+}
+
+// IsItalic says if the text should be italic.
+func (s *TextStyle) IsItalic() bool {
+	return s != nil && s.Italic
+}
@@ -0,0 +1,52 @@
+package graphics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseColourAndHexAreInverses(t *testing.T) {
+	assert := assert.New(t)
+	c, err := ParseColour("#Ff8001")
+	assert.NoError(err)
+	assert.Equal(Colour{255, 128, 1}, c)
+	assert.Equal("#ff8001", c.Hex())
+
+	c, err = ParseColour("000000")
+	assert.NoError(err)
+	assert.Equal(Black, c)
+
+	for _, bad := range []string{"", "#", "#fff", "#ff80011", "#gg0000", "#+f0000"} {
+		_, err = ParseColour(bad)
+		assert.EqualError(err, "Invalid colour: <"+bad+">, expected #rrggbb")
+	}
+}
+
+func TestStyleAccessorsProvideFallbacksForNilStyles(t *testing.T) {
+	assert := assert.New(t)
+	red := Colour{R: 255}
+
+	var lineStyle *LineStyle
+	assert.Equal(Black, lineStyle.StrokeColour(Black))
+	assert.Equal(1.0, lineStyle.StrokeWidth(1))
+	lineStyle = &LineStyle{Width: 3}
+	assert.Equal(Black, lineStyle.StrokeColour(Black))
+	assert.Equal(3.0, lineStyle.StrokeWidth(1))
+	lineStyle = &LineStyle{Colour: &red}
+	assert.Equal(red, lineStyle.StrokeColour(Black))
+	assert.Equal(1.0, lineStyle.StrokeWidth(1))
+
+	var fillStyle *FillStyle
+	assert.Equal(Black, fillStyle.FillColour(Black))
+	assert.Equal(red, (&FillStyle{&red}).FillColour(Black))
+
+	var textStyle *TextStyle
+	assert.Equal(Black, textStyle.TextColour(Black))
+	assert.False(textStyle.IsBold())
+	assert.False(textStyle.IsItalic())
+	textStyle = &TextStyle{Colour: &red, Italic: true}
+	assert.Equal(red, textStyle.TextColour(Black))
+	assert.False(textStyle.IsBold())
+	assert.True(textStyle.IsItalic())
+}
@@ -15,13 +15,78 @@ const (
 	Life        = "life"
 	Dash        = "dash"
 	Full        = "full"
+	Async       = "async"
 	Self        = "self"
+	Found       = "found"
+	Lost        = "lost"
 	Stop        = "stop"
+	Activate    = "activate"
+	Deactivate  = "deactivate"
+	Create      = "create"
+	Destroy     = "destroy"
+	Note        = "note"
+	Spacing     = "spacing"
+	Replies     = "replies"
+	Size        = "size"
+	Theme       = "theme"
+	Autonumber  = "autonumber"
+
+	// Combined fragments.
+	Alt      = "alt"
+	Opt      = "opt"
+	Loop     = "loop"
+	Par      = "par"
+	Break    = "break"
+	Critical = "critical"
+	Else     = "else"
+	End      = "end"
 )
 
+// These constants represent the kinds of participant that a <life> statement
+// can declare, by including the kind in guillemets. E.g. "life A <<actor>> User".
+const (
+	Actor    = "actor"
+	Boundary = "boundary"
+	Control  = "control"
+	Entity   = "entity"
+	Database = "database"
+	Queue    = "queue"
+)
+
+// ParticipantKinds provides the participant kinds as a list.
+var ParticipantKinds = []string{Actor, Boundary, Control, Entity, Database, Queue}
+
+// KnownParticipantKind returns true if the given participant kind is a
+// recognized one.
+func KnownParticipantKind(kind string) bool {
+	for _, known := range ParticipantKinds {
+		if kind == known {
+			return true
+		}
+	}
+	return false
+}
+
 // AllKeywords provides the keywords as a list.
 var AllKeywords = []string{
-	Title, Life, ShowLetters, Full, Dash, Self, Stop, TextSize}
+	Title, Life, ShowLetters, Full, Async, Dash, Self, Found, Lost, Stop,
+	Activate, Deactivate, Create, Destroy, TextSize, Note, Spacing, Replies,
+	Size, Theme, Autonumber,
+	Alt, Opt, Loop, Par, Break, Critical, Else, End}
+
+// FragmentKeywords provides the keywords that open a combined fragment.
+var FragmentKeywords = []string{Alt, Opt, Loop, Par, Break, Critical}
+
+// IsFragmentKeyword returns true if the given keyword opens a combined
+// fragment.
+func IsFragmentKeyword(keyWord string) bool {
+	for _, known := range FragmentKeywords {
+		if keyWord == known {
+			return true
+		}
+	}
+	return false
+}
 
 // KnownKeyword returns true if the given keyword is a recognized one.
 func KnownKeyword(keyWord string) bool {
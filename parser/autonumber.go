@@ -0,0 +1,175 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/peterhoward42/umli"
+	"github.com/peterhoward42/umli/dsl"
+)
+
+/*
+This module provides the automatic numbering of messages that an autonumber
+statement asks for. The numbers are worked out here, as the statements are
+parsed, and stored in each statement's SequenceNumber. So that everything
+downstream of the parser sees the same number for a message.
+*/
+
+/*
+parseAutonumber parses an autonumber statement. Which can take the following
+forms:
+
+	autonumber                  (1, 2, 3...)
+	autonumber 10               (10, 11, 12...)
+	autonumber 10 5             (10, 15, 20...)
+	autonumber 1 1 [01]         ([01], [02], [03]...)
+	autonumber 1 1 1.2          (hierarchical: 1, 2.1, 2.2, 3...)
+
+The format is an example of how the number 1 should look. Its digits say how
+many digits to zero-pad the number to, and the text either side of them is
+written either side of the number.
+*/
+func (p *Parser) parseAutonumber(line string, words []string) (
+	s *dsl.Statement, err error) {
+	s = &dsl.Statement{
+		Keyword:      umli.Autonumber,
+		NumberStart:  1,
+		NumberStep:   1,
+		NumberFormat: "1",
+	}
+	if len(words) > 1 {
+		if s.NumberStart, err = strconv.Atoi(words[1]); err != nil ||
+			s.NumberStart < 0 {
+			return nil, errorAt(words[1],
+				"Autonumber start must be a whole number")
+		}
+	}
+	if len(words) > 2 {
+		if s.NumberStep, err = strconv.Atoi(words[2]); err != nil ||
+			s.NumberStep < 1 {
+			return nil, errorAt(words[2],
+				"Autonumber increment must be a whole number, of at least 1")
+		}
+	}
+	if len(words) > 3 {
+		s.NumberFormat = words[3]
+		if _, ok := parseNumberFormat(s.NumberFormat); !ok {
+			return nil, errorAt(words[3],
+				"Autonumber format must contain a number. E.g. 1. or [01]")
+		}
+	}
+	if len(words) > 4 {
+		return nil, errorAt(words[4],
+			"Autonumber takes at most a start, an increment, and a format")
+	}
+	return s, nil
+}
+
+// numberedKeywords are the keywords of the statements that are given a
+// number. I.e. the messages.
+var numberedKeywords = map[string]bool{
+	umli.Full:   true,
+	umli.Async:  true,
+	umli.Dash:   true,
+	umli.Self:   true,
+	umli.Create: true,
+	umli.Found:  true,
+	umli.Lost:   true,
+}
+
+/*
+number sets the sequence number of statement s, if numbering is in force, and
+s is a message. An autonumber statement (re)starts the numbering. For
+hierarchical numbering, each fragment takes the next number, and the messages
+inside it are numbered beneath that.
+*/
+func (p *Parser) number(s *dsl.Statement) {
+	if s.Keyword == umli.Autonumber {
+		format, _ := parseNumberFormat(s.NumberFormat)
+		p.numberer = &numberer{format, s.NumberStep,
+			[]int{s.NumberStart - s.NumberStep}}
+		return
+	}
+	n := p.numberer
+	if n == nil {
+		return
+	}
+	switch {
+	case numberedKeywords[s.Keyword]:
+		s.SequenceNumber = n.next()
+	case umli.IsFragmentKeyword(s.Keyword) && n.format.hierarchical:
+		s.SequenceNumber = n.next()
+		n.counters = append(n.counters, 0)
+	case s.Keyword == umli.End && len(n.counters) > 1:
+		n.counters = n.counters[:len(n.counters)-1]
+	}
+}
+
+// numberer keeps track of the numbering in force.
+type numberer struct {
+	format numberFormat
+	step   int
+	// One counter for each level of (hierarchical) numbering.
+	counters []int
+}
+
+// next advances the innermost counter, and provides the resultant number.
+// Only the outermost counter uses the step. The others count in ones.
+func (n *numberer) next() string {
+	innermost := len(n.counters) - 1
+	if innermost == 0 {
+		n.counters[0] += n.step
+	} else {
+		n.counters[innermost]++
+	}
+	return n.format.render(n.counters)
+}
+
+// numberFormat is a parsed autonumber format.
+type numberFormat struct {
+	prefix       string
+	width        int
+	suffix       string
+	hierarchical bool
+}
+
+/*
+parseNumberFormat parses an autonumber format. Like "1.", "[01]" or "1.2".
+The first run of digits is the number, and if it is followed by a dot and
+more digits, the numbering is hierarchical. It returns false if there is
+no number in the format.
+*/
+func parseNumberFormat(format string) (numberFormat, bool) {
+	start := strings.IndexFunc(format, unicode.IsDigit)
+	if start < 0 {
+		return numberFormat{}, false
+	}
+	end := start + digitsAt(format[start:])
+	f := numberFormat{prefix: format[:start], width: end - start}
+	if strings.HasPrefix(format[end:], ".") && digitsAt(format[end+1:]) > 0 {
+		f.hierarchical = true
+		end += 1 + digitsAt(format[end+1:])
+	}
+	f.suffix = format[end:]
+	return f, true
+}
+
+// digitsAt provides how many digits there are at the start of s.
+func digitsAt(s string) int {
+	n := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsDigit(r) })
+	if n < 0 {
+		return len(s)
+	}
+	return n
+}
+
+// render formats the number given by counters, (one for each level).
+func (f numberFormat) render(counters []int) string {
+	levels := []string{}
+	for _, counter := range counters {
+		levels = append(levels, fmt.Sprintf("%0*d", f.width, counter))
+	}
+	return f.prefix + strings.Join(levels, ".") + f.suffix
+}
@@ -0,0 +1,100 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// sequenceNumbers provides the sequence numbers that parsing script gives
+// to its statements, (including the empty ones).
+func sequenceNumbers(t *testing.T, script string) []string {
+	model, err := NewParser(script).Parse()
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	numbers := []string{}
+	for _, s := range model.Statements() {
+		numbers = append(numbers, s.SequenceNumber)
+	}
+	return numbers
+}
+
+func TestMessagesAreOnlyNumberedAfterAutonumber(t *testing.T) {
+	assert := assert.New(t)
+	numbers := sequenceNumbers(t, `
+		life A foo
+		life B bar
+		full AB one
+		autonumber
+		full AB two
+		stop B
+		dash BA three
+		self A four
+		note A five
+		found A six
+		lost A seven
+	`)
+	assert.Equal(
+		[]string{"", "", "", "", "1", "", "2", "3", "", "4", "5"}, numbers)
+}
+
+func TestAutonumberStartIncrementAndFormat(t *testing.T) {
+	assert := assert.New(t)
+	numbers := sequenceNumbers(t, `
+		autonumber 8 2 [01]
+		life A foo
+		self A one
+		self A two
+		autonumber 5 5 1.
+		self A three
+		self A four
+	`)
+	assert.Equal([]string{"", "", "[08]", "[10]", "", "5.", "10."}, numbers)
+}
+
+func TestHierarchicalNumbersInsideFragments(t *testing.T) {
+	assert := assert.New(t)
+	numbers := sequenceNumbers(t, `
+		autonumber 1 1 1.2
+		life A foo
+		self A one
+		alt
+		self A two
+		loop
+		self A three
+		end
+		else
+		self A four
+		end
+		self A five
+	`)
+	assert.Equal([]string{"", "", "1", "2", "2.1", "2.2", "2.2.1", "",
+		"", "2.3", "", "3"}, numbers)
+}
+
+func TestFragmentsDoNotTakeNumbersUnlessHierarchical(t *testing.T) {
+	assert := assert.New(t)
+	numbers := sequenceNumbers(t, `
+		autonumber
+		life A foo
+		opt
+		self A one
+		end
+		self A two
+	`)
+	assert.Equal([]string{"", "", "", "1", "", "2"}, numbers)
+}
+
+func TestBadAutonumberStatements(t *testing.T) {
+	assert := assert.New(t)
+	_, err := NewParser("autonumber x").Parse()
+	assert.EqualError(err, "Error on this line <autonumber x> (line: 1): "+
+		"Autonumber start must be a whole number")
+	_, err = NewParser("autonumber 1 0").Parse()
+	assert.EqualError(err, "Error on this line <autonumber 1 0> (line: 1): "+
+		"Autonumber increment must be a whole number, of at least 1")
+	_, err = NewParser("autonumber 1 1 step").Parse()
+	assert.EqualError(err, "Error on this line <autonumber 1 1 step> (line: 1): "+
+		"Autonumber format must contain a number. E.g. 1. or [01]")
+}
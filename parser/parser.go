@@ -8,29 +8,62 @@ import (
 	"errors"
 	"fmt"
 	re "regexp"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/peterhoward42/umli"
 	"github.com/peterhoward42/umli/dsl"
+	"github.com/peterhoward42/umli/sizer"
+	"github.com/peterhoward42/umli/theme"
 )
 
 // Parser is capable of parsing the DSL script to produce a dsl.Model.
 type Parser struct {
-	inputScript string
-	model       dsl.Model
+	inputScript   string
+	model         dsl.Model
+	source        lineSource        // The line currently being parsed.
+	openFragments []openFragment    // A stack of the fragments not yet ended.
+	problems      umli.DSLErrorList // The problems found so far.
+	numberer      *numberer         // Nil until an autonumber statement.
+
+	// The lifelines that statements have referred to so far, and those that
+	// have been destroyed so far.
+	used      map[*dsl.Statement]bool
+	destroyed map[*dsl.Statement]bool
+}
+
+// ErrNoInput is the reason for the problem reported when the script is empty,
+// (or holds only white space).
+var ErrNoInput = errors.New("There is no input text")
+
+// openFragment remembers a statement that opened a combined fragment, along
+// with where it came from, so that errors about it can refer to that line.
+type openFragment struct {
+	statement *dsl.Statement
+	source    lineSource
 }
 
 func NewParser(inputScript string) *Parser {
 	return &Parser{
 		inputScript: inputScript,
+		used:        map[*dsl.Statement]bool{},
+		destroyed:   map[*dsl.Statement]bool{},
 	}
 }
 
-// Parse is the parsing invocation method.
+/*
+Parse is the parsing invocation method.
+
+It carries on past faulty lines, so that it can report all the problems in the
+script at once. When there are any, the error returned is a umli.DSLErrorList.
+Problems that are only warnings do not prevent the model being returned, and
+are available from the Warnings method afterwards.
+*/
 func (p *Parser) Parse() (*dsl.Model, error) {
 	if len(strings.TrimSpace(p.inputScript)) == 0 {
-		return nil, errors.New("There is no input text")
+		p.addProblem(lineSource{lineNo: 1}, ErrNoInput, umli.SeverityError)
+		return nil, p.problems.Err()
 	}
 	reader := strings.NewReader(p.inputScript)
 	scanner := bufio.NewScanner(reader)
@@ -38,23 +71,50 @@ func (p *Parser) Parse() (*dsl.Model, error) {
 	for scanner.Scan() {
 		line := scanner.Text()
 		lineNo++
-		trimmed := strings.TrimSpace(line)
+		trimmed := strings.TrimSpace(p.stripComment(line))
 		if len(trimmed) == 0 {
 			continue
 		}
+		p.source = lineSource{lineNo, line, trimmed}
 		statement, err := p.parseLine(trimmed)
 		if err != nil {
-			return nil, umli.DSLError(trimmed, lineNo, err.Error())
+			p.addProblem(p.source, err, umli.SeverityError)
+			continue
 		}
+		statement.LineNo = lineNo
 		p.model.Append(statement)
 	}
 	if err := scanner.Err(); err != nil {
 		return nil, err
 	}
+	for _, unclosed := range p.openFragments {
+		keyword := unclosed.statement.Keyword
+		p.addProblem(unclosed.source, errorAt(keyword,
+			"This <%s> has no matching <%s>", keyword, umli.End),
+			umli.SeverityError)
+	}
+	sort.SliceStable(p.problems, func(i, j int) bool {
+		return p.problems[i].Line < p.problems[j].Line
+	})
+	if err := p.problems.Err(); err != nil {
+		return nil, err
+	}
 	p.addOptionalLifelineLetters()
 	return &p.model, nil
 }
 
+// Warnings provides the problems found by Parse that were not serious
+// enough to make it fail.
+func (p *Parser) Warnings() umli.DSLErrorList {
+	warnings := umli.DSLErrorList{}
+	for _, problem := range p.problems {
+		if problem.Severity == umli.SeverityWarning {
+			warnings = append(warnings, problem)
+		}
+	}
+	return warnings
+}
+
 // parseLine parses the text present in a single line of DSL, into
 // the fields expected, validates them, and packages the result into a
 // dsl.Statement.
@@ -62,14 +122,15 @@ func (p *Parser) parseLine(line string) (s *dsl.Statement, err error) {
 	words := strings.Split(line, " ")
 	keyWord := words[0]
 	if !umli.KnownKeyword(keyWord) {
-		return nil, fmt.Errorf("Unrecognized keyword: %s", keyWord)
+		return nil, errorAt(keyWord, "Unrecognized keyword: %s", keyWord)
 	}
 	requiredNumberOfWords := p.minWordsRequiredFor(keyWord)
 	if len(words) < requiredNumberOfWords {
-		return nil, fmt.Errorf(
+		return nil, errorAt("",
 			"A <%s> line, must have at least %d words",
 			keyWord, requiredNumberOfWords)
 	}
+	p.warnIfRepeated(keyWord)
 	switch keyWord {
 	case umli.Title:
 		s, err = p.parseTitle(line, words)
@@ -77,14 +138,32 @@ func (p *Parser) parseLine(line string) (s *dsl.Statement, err error) {
 		s, err = p.parseTextSize(line, words)
 	case umli.ShowLetters:
 		s, err = p.parseShowLetters(line, words)
+	case umli.Spacing:
+		s, err = p.parseSpacing(line, words)
+	case umli.Replies:
+		s, err = p.parseReplies(line, words)
+	case umli.Size:
+		s, err = p.parseSize(line, words)
+	case umli.Theme:
+		s, err = p.parseTheme(line, words)
+	case umli.Autonumber:
+		s, err = p.parseAutonumber(line, words)
 	case umli.Life:
 		s, err = p.parseLife(line, words)
-	case umli.Full, umli.Dash:
-		s, err = p.parseFullOrDash(line, words)
-	case umli.Self:
-		s, err = p.parseSelf(line, words)
-	case umli.Stop:
-		s, err = p.parseStop(line, words)
+	case umli.Full, umli.Async, umli.Dash, umli.Create:
+		s, err = p.parseInteraction(line, words)
+	case umli.Self, umli.Found, umli.Lost:
+		s, err = p.parseOneLifelineInteraction(line, words)
+	case umli.Stop, umli.Activate, umli.Deactivate, umli.Destroy:
+		s, err = p.parseStopOrActivation(line, words)
+	case umli.Note:
+		s, err = p.parseNote(line, words)
+	case umli.Alt, umli.Opt, umli.Loop, umli.Par, umli.Break, umli.Critical:
+		s, err = p.parseFragmentStart(line, words)
+	case umli.Else:
+		s, err = p.parseElse(line, words)
+	case umli.End:
+		s, err = p.parseEnd(line, words)
 	default:
 		panic(fmt.Sprintf(
 			"Developer has registered keyword <%s> but forgotten to call handler",
@@ -93,9 +172,44 @@ func (p *Parser) parseLine(line string) (s *dsl.Statement, err error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := p.checkLifetimes(s); err != nil {
+		return nil, err
+	}
+	p.addToOpenFragments(s.ReferencedLifelines)
+	p.number(s)
 	return s, nil
 }
 
+/*
+checkLifetimes makes sure that statement s does not refer to a lifeline
+outside of its lifetime. I.e. that a lifeline is not created after it has been
+used already, and is not used after it has been destroyed.
+*/
+func (p *Parser) checkLifetimes(s *dsl.Statement) error {
+	for _, lifeline := range s.ReferencedLifelines {
+		if p.destroyed[lifeline] {
+			return errorAt(lifeline.LifelineName,
+				"Lifeline %s has been destroyed already",
+				lifeline.LifelineName)
+		}
+	}
+	if s.Keyword == umli.Create {
+		created := s.ReferencedLifelines[1]
+		if p.used[created] {
+			return errorAt(created.LifelineName,
+				"Lifeline %s cannot be created, because it has been used already",
+				created.LifelineName)
+		}
+	}
+	for _, lifeline := range s.ReferencedLifelines {
+		p.used[lifeline] = true
+	}
+	if s.Keyword == umli.Destroy {
+		p.destroyed[s.ReferencedLifelines[0]] = true
+	}
+	return nil
+}
+
 func (p *Parser) parseTitle(line string, words []string) (
 	s *dsl.Statement, err error) {
 	label := p.removeStrings(line, umli.Title)
@@ -109,12 +223,12 @@ func (p *Parser) parseTextSize(line string, words []string) (
 	s *dsl.Statement, err error) {
 	var textSize float64
 	if textSize, err = strconv.ParseFloat(words[1], 64); err != nil {
-		return nil, errors.New("Text size must be a number")
+		return nil, errorAt(words[1], "Text size must be a number")
 	}
 	const minTextSize = 5
 	const maxTextSize = 20
 	if textSize < minTextSize || textSize > maxTextSize {
-		return nil, fmt.Errorf("Text size must be between %v and %v",
+		return nil, errorAt(words[1], "Text size must be between %v and %v",
 			minTextSize, maxTextSize)
 	}
 	return &dsl.Statement{
@@ -132,7 +246,7 @@ func (p *Parser) parseShowLetters(line string, words []string) (
 	case "false":
 		show = false
 	default:
-		return nil, errors.New("showletters expects <true> or <false>")
+		return nil, errorAt(words[1], "showletters expects <true> or <false>")
 	}
 	return &dsl.Statement{
 		Keyword:     umli.ShowLetters,
@@ -140,42 +254,122 @@ func (p *Parser) parseShowLetters(line string, words []string) (
 	}, nil
 }
 
+func (p *Parser) parseSpacing(line string, words []string) (
+	s *dsl.Statement, err error) {
+	var variable bool
+	switch words[1] {
+	case "uniform":
+		variable = false
+	case "variable":
+		variable = true
+	default:
+		return nil, errorAt(words[1], "spacing expects <uniform> or <variable>")
+	}
+	return &dsl.Statement{
+		Keyword:         umli.Spacing,
+		VariableSpacing: variable,
+	}, nil
+}
+
+// parseReplies parses a statement that chooses how the arrow heads of replies
+// are drawn. E.g. "replies open".
+func (p *Parser) parseReplies(line string, words []string) (
+	s *dsl.Statement, err error) {
+	var open bool
+	switch words[1] {
+	case "filled":
+		open = false
+	case "open":
+		open = true
+	default:
+		return nil, errorAt(words[1], "replies expects <filled> or <open>")
+	}
+	return &dsl.Statement{
+		Keyword:     umli.Replies,
+		OpenReplies: open,
+	}, nil
+}
+
+// parseSize parses a statement that overrides one of the sizes used to lay
+// out the diagram. E.g. "size SelfLoopHeight 4".
+func (p *Parser) parseSize(line string, words []string) (
+	s *dsl.Statement, err error) {
+	key := words[1]
+	if !sizer.KnownKey(key) {
+		return nil, errorAt(key, "Unknown size: %s", key)
+	}
+	value, err := strconv.ParseFloat(words[2], 64)
+	if err != nil {
+		return nil, errorAt(words[2], "Size must be a number")
+	}
+	if err := sizer.ValidateOverride(key, value); err != nil {
+		return nil, errorAt(words[2], "%v", err)
+	}
+	return &dsl.Statement{
+		Keyword:   umli.Size,
+		SizeKey:   key,
+		SizeValue: value,
+	}, nil
+}
+
+// parseTheme parses a statement that chooses the diagram's theme. E.g.
+// "theme dark".
+func (p *Parser) parseTheme(line string, words []string) (
+	s *dsl.Statement, err error) {
+	name := words[1]
+	if _, ok := theme.Named(name); !ok {
+		return nil, errorAt(name, "Unknown theme: %s, expected one of: %s",
+			name, strings.Join(theme.Names(), ", "))
+	}
+	return &dsl.Statement{
+		Keyword:   umli.Theme,
+		ThemeName: name,
+	}, nil
+}
+
+// parseLife parses a lifeline declaration. E.g. "life A Client", or with a
+// participant kind, "life A <<actor>> Client".
 func (p *Parser) parseLife(line string, words []string) (
 	s *dsl.Statement, err error) {
-	if !singleUCLetter.MatchString(words[1]) {
-		return nil, fmt.Errorf(
-			"Lifeline name (%s) must be a single, upper case letter", words[1])
+	if err := p.checkLifelineName(words[1]); err != nil {
+		return nil, err
 	}
 	lifelineName := words[1]
 	if p.model.LifelineIsKnown(lifelineName) {
-		return nil, fmt.Errorf(
+		return nil, errorAt(lifelineName,
 			"Lifeline (%s) has already been used", lifelineName)
 	}
 	label := p.removeStrings(line, umli.Life, lifelineName)
+	kind := ""
+	if strings.HasPrefix(words[2], "<<") && strings.HasSuffix(words[2], ">>") {
+		kind = strings.TrimSuffix(strings.TrimPrefix(words[2], "<<"), ">>")
+		if !umli.KnownParticipantKind(kind) {
+			return nil, errorAt(words[2],
+				"Unknown participant kind: %s, expected one of: %s",
+				kind, strings.Join(umli.ParticipantKinds, ", "))
+		}
+		label = p.removeStrings(label, words[2])
+	}
 	s = &dsl.Statement{
-		Keyword:       umli.Life,
-		LifelineName:  lifelineName,
-		LabelSegments: p.isolateLabelConstituentLines(label),
+		Keyword:         umli.Life,
+		LifelineName:    lifelineName,
+		ParticipantKind: kind,
+		LabelSegments:   p.isolateLabelConstituentLines(label),
 	}
 	return s, nil
 }
 
-func (p *Parser) parseFullOrDash(line string, words []string) (
+func (p *Parser) parseInteraction(line string, words []string) (
 	s *dsl.Statement, err error) {
-	if !twoUCLetters.MatchString(words[1]) {
-		return nil, errors.New(
-			"Lifelines specified must be two, upper case letters")
-	}
-	lifelineLetters := strings.Split(words[1], "")
-	if lifelineLetters[0] == lifelineLetters[1] {
-		return nil, fmt.Errorf(
-			"Lifeline letters must be different:(%s)", words[1])
+	lifelineNames, err := p.splitInteractionOperand(words[1])
+	if err != nil {
+		return nil, err
 	}
 	lifelines := []*dsl.Statement{}
-	for _, letter := range lifelineLetters {
-		lifeline, ok := p.model.LifelineStatementByName(letter)
+	for _, name := range lifelineNames {
+		lifeline, ok := p.model.LifelineStatementByName(name)
 		if !ok {
-			return nil, fmt.Errorf("Unknown lifeline: %s", letter)
+			return nil, unknownLifeline(name)
 		}
 		lifelines = append(lifelines, lifeline)
 	}
@@ -187,40 +381,265 @@ func (p *Parser) parseFullOrDash(line string, words []string) (
 	}, nil
 }
 
-func (p *Parser) parseStop(line string, words []string) (
+func (p *Parser) parseStopOrActivation(line string, words []string) (
 	s *dsl.Statement, err error) {
-	if !singleUCLetter.MatchString(words[1]) {
-		return nil, fmt.Errorf(
-			"Lifeline name (%s) must be a single, upper case letter", words[1])
+	if err := p.checkLifelineName(words[1]); err != nil {
+		return nil, err
 	}
 	lifeline, ok := p.model.LifelineStatementByName(words[1])
 	if !ok {
-		return nil, fmt.Errorf("Unknown lifeline: %s", words[1])
+		return nil, unknownLifeline(words[1])
 	}
 	return &dsl.Statement{
-		Keyword:             umli.Stop,
+		Keyword:             words[0],
 		ReferencedLifelines: []*dsl.Statement{lifeline},
 	}, nil
 }
 
-func (p *Parser) parseSelf(line string, words []string) (
+/*
+parseOneLifelineInteraction parses the interactions that involve only one
+lifeline. Which can take the following forms:
+
+	self A label    (a loop from A back to itself)
+	found A label   (from the edge of the diagram to A)
+	lost A label    (from A to a dot near the other edge of the diagram)
+*/
+func (p *Parser) parseOneLifelineInteraction(line string, words []string) (
 	s *dsl.Statement, err error) {
-	if !singleUCLetter.MatchString(words[1]) {
-		return nil, fmt.Errorf(
-			"Lifeline name (%s) must be a single, upper case letter", words[1])
+	if err := p.checkLifelineName(words[1]); err != nil {
+		return nil, err
 	}
 	lifeline, ok := p.model.LifelineStatementByName(words[1])
 	if !ok {
-		return nil, fmt.Errorf("Unknown lifeline: %s", words[1])
+		return nil, unknownLifeline(words[1])
 	}
-	label := p.removeStrings(line, umli.Self, words[1])
+	label := p.removeStrings(line, words[0], words[1])
 	return &dsl.Statement{
-		Keyword:             umli.Self,
+		Keyword:             words[0],
 		ReferencedLifelines: []*dsl.Statement{lifeline},
 		LabelSegments:       p.isolateLabelConstituentLines(label),
 	}, nil
 }
 
+/*
+parseNote parses a note statement. Which can take the following forms:
+
+	note A text           (beside lifeline A)
+	note AB text          (over lifelines A and B)
+	note over A..C text   (over lifelines A to C inclusive)
+	note over A text      (over lifeline A)
+*/
+func (p *Parser) parseNote(line string, words []string) (
+	s *dsl.Statement, err error) {
+	operand := words[1]
+	over := false
+	var names []string
+	switch {
+	case operand == noteOver:
+		if len(words) < 4 {
+			return nil, errorAt("",
+				"A <%s %s> line, must have at least 4 words",
+				umli.Note, noteOver)
+		}
+		over = true
+		operand = words[2]
+		names = strings.Split(operand, noteRange)
+		if len(names) > 2 {
+			return nil, errorAt(operand,
+				"Lifelines specified must be of the form <from>%s<to>:(%s)",
+				noteRange, operand)
+		}
+	case p.model.LifelineIsKnown(operand):
+		names = []string{operand}
+	case twoUCLetters.MatchString(operand):
+		over = true
+		names = strings.Split(operand, "")
+	default:
+		names = []string{operand}
+	}
+	lifelines := []*dsl.Statement{}
+	for _, name := range names {
+		if err := p.checkLifelineName(name); err != nil {
+			return nil, err
+		}
+		lifeline, ok := p.model.LifelineStatementByName(name)
+		if !ok {
+			return nil, unknownLifeline(name)
+		}
+		lifelines = append(lifelines, lifeline)
+	}
+	label := p.removeStrings(line, umli.Note, operand)
+	if words[1] == noteOver {
+		label = p.removeStrings(line, umli.Note, noteOver, operand)
+	}
+	return &dsl.Statement{
+		Keyword:             umli.Note,
+		ReferencedLifelines: lifelines,
+		LabelSegments:       p.isolateLabelConstituentLines(label),
+		NoteOver:            over,
+	}, nil
+}
+
+// checkLifelineName makes sure that name is a well formed lifeline name.
+func (p *Parser) checkLifelineName(name string) error {
+	if !lifelineName.MatchString(name) {
+		return errorAt(name,
+			"Lifeline name (%s) must start with a letter, and contain only "+
+				"letters, digits or underscores", name)
+	}
+	return nil
+}
+
+/*
+splitInteractionOperand isolates the names of the two lifelines from the
+operand of an interaction line statement. It can be written either in the
+long-form "from->to", (e.g. "api->userStore"), or in the original short-form
+that is limited to single-letter lifeline names (e.g. "AB").
+*/
+func (p *Parser) splitInteractionOperand(operand string) ([]string, error) {
+	var names []string
+	switch {
+	case strings.Contains(operand, arrow):
+		names = strings.Split(operand, arrow)
+		if len(names) != 2 {
+			return nil, errorAt(operand,
+				"Lifelines specified must be of the form <from>-><to>:(%s)",
+				operand)
+		}
+		for _, name := range names {
+			if err := p.checkLifelineName(name); err != nil {
+				return nil, err
+			}
+		}
+	case twoUCLetters.MatchString(operand):
+		names = strings.Split(operand, "")
+	default:
+		return nil, errorAt(operand, "Lifelines specified must be two, "+
+			"upper case letters, or of the form <from>-><to>")
+	}
+	if names[0] == names[1] {
+		return nil, errorAt(operand,
+			"Lifelines specified must be different:(%s)", operand)
+	}
+	return names, nil
+}
+
+// stripComment returns a copy of line from which any comment has been removed.
+// A comment is introduced by "#" or "//" and runs to the end of the line. The
+// comment marker must be at the start of the line, or preceded by whitespace,
+// so that labels such as "http://host" or "item#3" are left intact.
+func (p *Parser) stripComment(line string) string {
+	for i := 0; i < len(line); i++ {
+		if i > 0 && line[i-1] != ' ' && line[i-1] != '\t' {
+			continue
+		}
+		rest := line[i:]
+		if strings.HasPrefix(rest, "#") || strings.HasPrefix(rest, "//") {
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// parseFragmentStart parses a statement that opens a combined fragment,
+// such as "alt [valid user]". The label is the (optional) guard text.
+func (p *Parser) parseFragmentStart(line string, words []string) (
+	s *dsl.Statement, err error) {
+	label := p.removeStrings(line, words[0])
+	s = &dsl.Statement{
+		Keyword:             words[0],
+		ReferencedLifelines: []*dsl.Statement{},
+		LabelSegments:       p.isolateLabelConstituentLines(label),
+	}
+	p.openFragments = append(p.openFragments, openFragment{s, p.source})
+	return s, nil
+}
+
+// parseElse parses an else statement, which separates the operands of an
+// alt or par fragment. The label is the (optional) guard text.
+func (p *Parser) parseElse(line string, words []string) (
+	s *dsl.Statement, err error) {
+	if len(p.openFragments) == 0 {
+		return nil, errorAt(umli.Else,
+			"There is no fragment open for this <else>")
+	}
+	fragment := p.openFragments[len(p.openFragments)-1].statement
+	if fragment.Keyword != umli.Alt && fragment.Keyword != umli.Par {
+		return nil, errorAt(umli.Else,
+			"An <else> can only be used in an <alt> or <par>, not <%s>",
+			fragment.Keyword)
+	}
+	label := p.removeStrings(line, umli.Else)
+	return &dsl.Statement{
+		Keyword:       umli.Else,
+		LabelSegments: p.isolateLabelConstituentLines(label),
+	}, nil
+}
+
+// parseEnd parses the statement that closes the most recently opened
+// combined fragment.
+func (p *Parser) parseEnd(line string, words []string) (
+	s *dsl.Statement, err error) {
+	if len(p.openFragments) == 0 {
+		return nil, errorAt(umli.End, "There is no fragment open for this <end>")
+	}
+	fragment := p.openFragments[len(p.openFragments)-1].statement
+	p.openFragments = p.openFragments[:len(p.openFragments)-1]
+	if len(fragment.ReferencedLifelines) == 0 {
+		return nil, errorAt(umli.End,
+			"The <%s> being ended, does not contain any interactions",
+			fragment.Keyword)
+	}
+	return &dsl.Statement{
+		Keyword: umli.End,
+	}, nil
+}
+
+/*
+warnIfRepeated records a warning when a statement that should appear only once
+in a script has appeared already, because only the first is heeded.
+*/
+func (p *Parser) warnIfRepeated(keyWord string) {
+	switch keyWord {
+	case umli.Title, umli.TextSize, umli.ShowLetters, umli.Spacing,
+		umli.Replies, umli.Theme:
+	default:
+		return
+	}
+	if _, ok := p.model.FirstStatementOfType(keyWord); ok {
+		p.addProblem(p.source, errorAt(keyWord,
+			"Only the first <%s> line is used, so this one has no effect",
+			keyWord), umli.SeverityWarning)
+	}
+}
+
+/*
+addToOpenFragments registers the given lifelines with every combined fragment
+that is currently open. This is how a fragment statement's ReferencedLifelines
+come to hold the lifelines it must span.
+*/
+func (p *Parser) addToOpenFragments(lifelines []*dsl.Statement) {
+	for _, open := range p.openFragments {
+		fragment := open.statement
+		for _, lifeline := range lifelines {
+			if !p.contains(fragment.ReferencedLifelines, lifeline) {
+				fragment.ReferencedLifelines = append(
+					fragment.ReferencedLifelines, lifeline)
+			}
+		}
+	}
+}
+
+// contains returns true if s is in statements.
+func (p *Parser) contains(statements []*dsl.Statement, s *dsl.Statement) bool {
+	for _, candidate := range statements {
+		if candidate == s {
+			return true
+		}
+	}
+	return false
+}
+
 // isolateLabelConstituentLines takes the label text from a DSL line and
 // splits it into the constituent lines according to its author's intent.
 // I.e. by splitting it at "|" delimiters. Note the removal of whitespace
@@ -243,9 +662,15 @@ func (p *Parser) isolateLabelConstituentLines(labelText string) []string {
 // of a line starting with the given keyword.
 func (p *Parser) minWordsRequiredFor(keyWord string) int {
 	switch keyWord {
-	case umli.Title, umli.TextSize, umli.ShowLetters, umli.Stop:
+	case umli.Alt, umli.Opt, umli.Loop, umli.Par, umli.Break, umli.Critical,
+		umli.Else, umli.End, umli.Autonumber:
+		return 1
+	case umli.Title, umli.TextSize, umli.ShowLetters, umli.Spacing,
+		umli.Replies, umli.Stop, umli.Activate, umli.Deactivate, umli.Create,
+		umli.Destroy, umli.Theme:
 		return 2
-	case umli.Life, umli.Full, umli.Dash, umli.Self:
+	case umli.Life, umli.Full, umli.Async, umli.Dash, umli.Self, umli.Found,
+		umli.Lost, umli.Note, umli.Size:
 		return 3
 	default:
 		return 999
@@ -275,5 +700,15 @@ func (p *Parser) addOptionalLifelineLetters() {
 	p.model.AddLifelineLetters()
 }
 
-var singleUCLetter = re.MustCompile(`^[A-Z]$`)
+var lifelineName = re.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
 var twoUCLetters = re.MustCompile(`^[A-Z][A-Z]$`)
+
+// arrow is the separator between lifeline names in the long-form operand
+// of interaction line statements. E.g. "full api->db".
+const arrow = "->"
+
+// These are used in the operands of note statements. E.g. "note over A..C".
+const (
+	noteOver  = "over"
+	noteRange = ".."
+)
@@ -1,9 +1,13 @@
 package parser
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/peterhoward42/umli"
+	"github.com/peterhoward42/umli/dsl"
 )
 
 // Start by testing the lower-level parsing helper functions
@@ -64,7 +68,13 @@ func TestErrorMsgWhenNoInput(t *testing.T) {
         
 
     `).Parse()
-	assert.EqualError(err, "There is no input text")
+	assert.EqualError(err,
+		"Error on this line <> (line: 1): There is no input text")
+	var problems umli.DSLErrorList
+	assert.True(errors.As(err, &problems))
+	assert.Len(problems, 1)
+	assert.Equal(1, problems[0].Line)
+	assert.True(errors.Is(err, ErrNoInput))
 }
 
 func TestErrorMsgWhenTooFewWords(t *testing.T) {
@@ -83,40 +93,219 @@ func TestErrorMsgWhenKeywordIsUnrecognized(t *testing.T) {
 		"Error on this line <foo bar> (line: 1): Unrecognized keyword: foo")
 }
 
-func TestErrorWhenSingleLetterLifelineExpected(t *testing.T) {
+func TestErrorWhenLifelineNameIsMalformed(t *testing.T) {
 	assert := assert.New(t)
 
 	// Few cases to look at details of error message.
-	_, err := NewParser("life AB foo").Parse()
+	_, err := NewParser("life 3A foo").Parse()
 	assert.EqualError(err,
-		"Error on this line <life AB foo> (line: 1): Lifeline name (AB) must "+
-			"be a single, upper case letter")
-	_, err = NewParser("life a foo").Parse()
+		"Error on this line <life 3A foo> (line: 1): Lifeline name (3A) must "+
+			"start with a letter, and contain only letters, digits or underscores")
+	_, err = NewParser("life a-b foo").Parse()
 	assert.NotNil(err)
 	assert.EqualError(err,
-		"Error on this line <life a foo> (line: 1): Lifeline name (a) must "+
-			"be a single, upper case letter")
-
-	// Make sure it behaves the same way with the only other keywords that
-	// requires a single lifeline to be specified: "stop".
-	_, err = NewParser("stop a").Parse()
-	assert.EqualError(err,
-		"Error on this line <stop a> (line: 1): Lifeline name (a) must be "+
-			"a single, upper case letter")
+		"Error on this line <life a-b foo> (line: 1): Lifeline name (a-b) must "+
+			"start with a letter, and contain only letters, digits or underscores")
 
 	// Make sure it behaves the same way with the other keywords that
 	// requires a single lifeline to be specified: "stop".
-	_, err = NewParser("stop a").Parse()
+	_, err = NewParser("stop _a").Parse()
 	assert.EqualError(err,
-		"Error on this line <stop a> (line: 1): Lifeline name (a) must be "+
-			"a single, upper case letter")
+		"Error on this line <stop _a> (line: 1): Lifeline name (_a) must "+
+			"start with a letter, and contain only letters, digits or underscores")
 
 	// Make sure it behaves the same way with the other keywords that
 	// requires a single lifeline to be specified: "self".
-	_, err = NewParser("self a foo").Parse()
+	_, err = NewParser("self a.b foo").Parse()
 	assert.EqualError(err,
-		"Error on this line <self a foo> (line: 1): Lifeline name (a) "+
-			"must be a single, upper case letter")
+		"Error on this line <self a.b foo> (line: 1): Lifeline name (a.b) "+
+			"must start with a letter, and contain only letters, digits or underscores")
+}
+
+func TestMultiCharacterLifelineNames(t *testing.T) {
+	assert := assert.New(t)
+	model, err := NewParser(`
+		life api   The API
+		life userStore  User Store
+		life Auth_Svc2  Auth
+		full api->userStore  fetch user
+		dash userStore->api  user
+		self Auth_Svc2  check
+		stop userStore
+	`).Parse()
+	assert.NoError(err)
+	statements := model.Statements()
+
+	full := statements[3]
+	assert.Equal("api", full.ReferencedLifelines[0].LifelineName)
+	assert.Equal("userStore", full.ReferencedLifelines[1].LifelineName)
+	assert.Equal([]string{"fetch user"}, full.LabelSegments)
+
+	dash := statements[4]
+	assert.Equal("userStore", dash.ReferencedLifelines[0].LifelineName)
+	assert.Equal("api", dash.ReferencedLifelines[1].LifelineName)
+
+	self := statements[5]
+	assert.Equal("Auth_Svc2", self.ReferencedLifelines[0].LifelineName)
+	assert.Equal([]string{"check"}, self.LabelSegments)
+
+	stop := statements[6]
+	assert.Equal("userStore", stop.ReferencedLifelines[0].LifelineName)
+}
+
+func TestAsync(t *testing.T) {
+	assert := assert.New(t)
+	model, err := NewParser(`
+		life A foo
+		life B bar
+		async AB  notify | listeners
+	`).Parse()
+	assert.NoError(err)
+	s := model.Statements()[2]
+	assert.Equal(umli.Async, s.Keyword)
+	assert.Equal("A", s.ReferencedLifelines[0].LifelineName)
+	assert.Equal("B", s.ReferencedLifelines[1].LifelineName)
+	assert.Equal([]string{"notify", "listeners"}, s.LabelSegments)
+}
+
+func TestFoundAndLost(t *testing.T) {
+	assert := assert.New(t)
+	model, err := NewParser(`
+		life A foo
+		found A  request | from outside
+		lost A  gone
+	`).Parse()
+	assert.NoError(err)
+	found := model.Statements()[1]
+	assert.Equal(umli.Found, found.Keyword)
+	assert.Equal("A", found.ReferencedLifelines[0].LifelineName)
+	assert.Equal([]string{"request", "from outside"}, found.LabelSegments)
+	lost := model.Statements()[2]
+	assert.Equal(umli.Lost, lost.Keyword)
+	assert.Equal([]string{"gone"}, lost.LabelSegments)
+}
+
+func TestCreateAndDestroy(t *testing.T) {
+	assert := assert.New(t)
+	model, err := NewParser(`
+		life A foo
+		life B bar
+		create AB  new
+		destroy B
+	`).Parse()
+	assert.NoError(err)
+	statements := model.Statements()
+	create := statements[2]
+	assert.Equal(umli.Create, create.Keyword)
+	assert.Equal("A", create.ReferencedLifelines[0].LifelineName)
+	assert.Equal("B", create.ReferencedLifelines[1].LifelineName)
+	assert.Equal([]string{"new"}, create.LabelSegments)
+	destroy := statements[3]
+	assert.Equal(umli.Destroy, destroy.Keyword)
+	assert.Equal("B", destroy.ReferencedLifelines[0].LifelineName)
+	assert.Equal(map[*dsl.Statement]bool{statements[1]: true},
+		model.CreatedLifelines())
+
+	// The label is optional.
+	_, err = NewParser("life A foo\nlife B bar\ncreate AB").Parse()
+	assert.NoError(err)
+}
+
+func TestLifelinesCannotBeUsedOutsideTheirLifetime(t *testing.T) {
+	assert := assert.New(t)
+	_, err := NewParser(`life A foo
+		life B bar
+		full AB hello
+		create AB new
+	`).Parse()
+	assert.EqualError(err, "Error on this line <create AB new> (line: 4): "+
+		"Lifeline B cannot be created, because it has been used already")
+
+	_, err = NewParser(`life A foo
+		life B bar
+		destroy B
+		full A->B hello
+	`).Parse()
+	assert.EqualError(err, "Error on this line <full A->B hello> (line: 4): "+
+		"Lifeline B has been destroyed already")
+}
+
+func TestParticipantKinds(t *testing.T) {
+	assert := assert.New(t)
+	model, err := NewParser(`
+		life A <<actor>> Shopper | (online)
+		life B Basket
+	`).Parse()
+	assert.NoError(err)
+	statements := model.Statements()
+	assert.Equal(umli.Actor, statements[0].ParticipantKind)
+	assert.Equal([]string{"Shopper", "(online)", "", "A"},
+		statements[0].LabelSegments)
+	assert.Equal("", statements[1].ParticipantKind)
+
+	_, err = NewParser("life A <<robot>> foo").Parse()
+	assert.EqualError(err, "Error on this line <life A <<robot>> foo> (line: 1): "+
+		"Unknown participant kind: robot, expected one of: "+
+		"actor, boundary, control, entity, database, queue")
+}
+
+func TestActivateAndDeactivate(t *testing.T) {
+	assert := assert.New(t)
+	model, err := NewParser(`
+		life A foo
+		activate A
+		deactivate A
+	`).Parse()
+	assert.NoError(err)
+	statements := model.Statements()
+	assert.Equal(umli.Activate, statements[1].Keyword)
+	assert.Equal("A", statements[1].ReferencedLifelines[0].LifelineName)
+	assert.Equal(umli.Deactivate, statements[2].Keyword)
+	assert.Equal("A", statements[2].ReferencedLifelines[0].LifelineName)
+
+	_, err = NewParser("life A foo\nactivate B").Parse()
+	assert.Error(err)
+}
+
+func TestLongFormOperandAlsoWorksForSingleLetterNames(t *testing.T) {
+	assert := assert.New(t)
+	model, err := NewParser(`
+		life A foo
+		life B bar
+		full A->B baz
+	`).Parse()
+	assert.NoError(err)
+	s := model.Statements()[2]
+	assert.Equal("A", s.ReferencedLifelines[0].LifelineName)
+	assert.Equal("B", s.ReferencedLifelines[1].LifelineName)
+	assert.Equal([]string{"baz"}, s.LabelSegments)
+}
+
+func TestErrorWhenLongFormOperandIsMalformed(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := NewParser("full a->b->c foo").Parse()
+	assert.EqualError(err, "Error on this line <full a->b->c foo> (line: 1): "+
+		"Lifelines specified must be of the form <from>-><to>:(a->b->c)")
+
+	_, err = NewParser("full a-> foo").Parse()
+	assert.EqualError(err, "Error on this line <full a-> foo> (line: 1): "+
+		"Lifeline name () must start with a letter, and contain only letters, "+
+		"digits or underscores")
+
+	_, err = NewParser(`
+		life api foo
+		full api->api bar
+	`).Parse()
+	assert.EqualError(err, "Error on this line <full api->api bar> (line: 3): "+
+		"Lifelines specified must be different:(api->api)")
+
+	_, err = NewParser(`
+		life api foo
+		full api->db bar
+	`).Parse()
+	assert.EqualError(err, "Error on this line <full api->db bar> (line: 3): "+
+		"Unknown lifeline: db")
 }
 
 func TestErrorWhenTwoLetterLifelinesExpected(
@@ -128,17 +317,20 @@ func TestErrorWhenTwoLetterLifelinesExpected(
 	// Upper case letter but only one of them, <full> keyword
 	_, err := NewParser("full A foo").Parse()
 	assert.EqualError(err, "Error on this line <full A foo> (line: 1): "+
-		"Lifelines specified must be two, upper case letters")
+		"Lifelines specified must be two, upper case letters, or of the "+
+		"form <from>-><to>")
 
 	// Two letters but wrong case - dash keyword
 	_, err = NewParser("dash ab foo").Parse()
 	assert.EqualError(err, "Error on this line <dash ab foo> (line: 1): "+
-		"Lifelines specified must be two, upper case letters")
+		"Lifelines specified must be two, upper case letters, or of the "+
+		"form <from>-><to>")
 
 	// Two characters but one is not a letter - dash keyword
 	_, err = NewParser("dash A3 foo").Parse()
 	assert.EqualError(err, "Error on this line <dash A3 foo> (line: 1): "+
-		"Lifelines specified must be two, upper case letters")
+		"Lifelines specified must be two, upper case letters, or of the "+
+		"form <from>-><to>")
 
 	// Using the same letter twice
 	_, err = NewParser(`
@@ -146,7 +338,7 @@ func TestErrorWhenTwoLetterLifelinesExpected(
 		full AA bar
 	`).Parse()
 	assert.EqualError(err, "Error on this line <full AA bar> (line: 3): "+
-		"Lifeline letters must be different:(AA)")
+		"Lifelines specified must be different:(AA)")
 }
 
 func TestItIgnoresBlankLines(t *testing.T) {
@@ -269,6 +461,64 @@ func TestWellFormedShowLettersIsParsedCorrectly(t *testing.T) {
 	assert.False(s.ShowLetters)
 }
 
+func TestSpacingStatementIsParsedCorrectly(t *testing.T) {
+	assert := assert.New(t)
+
+	model, err := NewParser("spacing variable").Parse()
+	assert.NoError(err)
+	assert.True(model.Statements()[0].VariableSpacing)
+	assert.True(model.VariableSpacingRequested())
+
+	model, err = NewParser("spacing uniform").Parse()
+	assert.NoError(err)
+	assert.False(model.VariableSpacingRequested())
+
+	_, err = NewParser("spacing garbage").Parse()
+	assert.EqualError(err,
+		"Error on this line <spacing garbage> (line: 1): spacing expects <uniform> or <variable>")
+}
+
+func TestRepliesStatementIsParsedCorrectly(t *testing.T) {
+	assert := assert.New(t)
+
+	model, err := NewParser("replies open").Parse()
+	assert.NoError(err)
+	assert.True(model.Statements()[0].OpenReplies)
+	assert.True(model.OpenRepliesRequested())
+
+	model, err = NewParser("replies filled").Parse()
+	assert.NoError(err)
+	assert.False(model.OpenRepliesRequested())
+
+	_, err = NewParser("replies garbage").Parse()
+	assert.EqualError(err,
+		"Error on this line <replies garbage> (line: 1): replies expects <filled> or <open>")
+}
+
+func TestThemeStatementIsParsedCorrectly(t *testing.T) {
+	assert := assert.New(t)
+
+	model, err := NewParser("theme high-contrast").Parse()
+	assert.NoError(err)
+	assert.Equal("high-contrast", model.Statements()[0].ThemeName)
+	name, ok := model.ThemeName()
+	assert.True(ok)
+	assert.Equal("high-contrast", name)
+
+	model, err = NewParser("life A foo").Parse()
+	assert.NoError(err)
+	_, ok = model.ThemeName()
+	assert.False(ok)
+
+	_, err = NewParser("theme neon").Parse()
+	assert.EqualError(err,
+		"Error on this line <theme neon> (line: 1): Unknown theme: neon, "+
+			"expected one of: dark, default, high-contrast, monochrome-print, pastel")
+	var problems umli.DSLErrorList
+	assert.True(errors.As(err, &problems))
+	assert.Equal("neon", problems[0].Token)
+}
+
 func TestLifelineTitlesGetLettersWhenShowLettersIsTrue(t *testing.T) {
 	assert := assert.New(t)
 	model, err := NewParser(`
@@ -297,3 +547,360 @@ func TestLifelineTitlesGetLettersWhenShowLettersIsOmitted(t *testing.T) {
 	lifeline := model.Statements()[0]
 	assert.Equal("A", lifeline.LabelSegments[2])
 }
+
+func TestItIgnoresFullLineComments(t *testing.T) {
+	assert := assert.New(t)
+	model, err := NewParser(`
+		# A comment using a hash
+		life A foo
+		// A comment using slashes
+		life B bar
+	`).Parse()
+	assert.NoError(err)
+	assert.Len(model.Statements(), 2)
+}
+
+func TestItIgnoresTrailingComments(t *testing.T) {
+	assert := assert.New(t)
+	model, err := NewParser(`
+		life A foo   # The client
+		life B bar // The server
+		showletters false
+		full AB fibble // The request
+	`).Parse()
+	assert.NoError(err)
+	statements := model.Statements()
+	assert.Len(statements, 4)
+	assert.Equal([]string{"foo"}, statements[0].LabelSegments)
+	assert.Equal([]string{"bar"}, statements[1].LabelSegments)
+	assert.Equal([]string{"fibble"}, statements[3].LabelSegments)
+}
+
+func TestCommentMarkersInsideWordsAreNotComments(t *testing.T) {
+	assert := assert.New(t)
+	model, err := NewParser(`
+		life A http://host
+		life B item#3
+		showletters false
+	`).Parse()
+	assert.NoError(err)
+	statements := model.Statements()
+	assert.Equal("http://host", statements[0].LabelSegments[0])
+	assert.Equal("item#3", statements[1].LabelSegments[0])
+}
+
+func TestErrorLineNumbersCountCommentLines(t *testing.T) {
+	assert := assert.New(t)
+	_, err := NewParser(`# comment
+		// comment
+		life A foo
+		nonsense line # comment
+	`).Parse()
+	assert.EqualError(err,
+		"Error on this line <nonsense line> (line: 4): Unrecognized keyword: nonsense")
+}
+
+func TestFragmentsRecordTheLifelinesTheySpan(t *testing.T) {
+	assert := assert.New(t)
+	model, err := NewParser(`
+		life A foo
+		life B bar
+		life C baz
+		alt [valid]
+		  full AB fibble
+		  loop [each item]
+		    self C process
+		  end
+		else [invalid]
+		  dash BA error
+		end
+	`).Parse()
+	assert.NoError(err)
+	statements := model.Statements()
+	lifelines := model.LifelineStatements()
+
+	alt := statements[3]
+	assert.Equal("alt", alt.Keyword)
+	assert.Equal([]string{"[valid]"}, alt.LabelSegments)
+	assert.Equal(
+		[]*dsl.Statement{lifelines[0], lifelines[1], lifelines[2]},
+		alt.ReferencedLifelines)
+
+	loop := statements[5]
+	assert.Equal("loop", loop.Keyword)
+	assert.Equal([]*dsl.Statement{lifelines[2]}, loop.ReferencedLifelines)
+
+	elseStatement := statements[8]
+	assert.Equal("else", elseStatement.Keyword)
+	assert.Equal([]string{"[invalid]"}, elseStatement.LabelSegments)
+
+	assert.Equal("end", statements[10].Keyword)
+}
+
+func TestGuardIsOptionalForFragments(t *testing.T) {
+	assert := assert.New(t)
+	model, err := NewParser(`
+		life A foo
+		critical
+		  self A bar
+		end
+	`).Parse()
+	assert.NoError(err)
+	assert.Len(model.Statements()[1].LabelSegments, 0)
+}
+
+func TestErrorsForMalformedFragments(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := NewParser(`
+		life A foo
+		opt
+		  self A bar
+	`).Parse()
+	assert.EqualError(err, "Error on this line <opt> (line: 3): "+
+		"This <opt> has no matching <end>")
+
+	_, err = NewParser(`
+		life A foo
+		self A bar
+		end
+	`).Parse()
+	assert.EqualError(err, "Error on this line <end> (line: 4): "+
+		"There is no fragment open for this <end>")
+
+	_, err = NewParser(`
+		life A foo
+		else
+	`).Parse()
+	assert.EqualError(err, "Error on this line <else> (line: 3): "+
+		"There is no fragment open for this <else>")
+
+	_, err = NewParser(`
+		life A foo
+		loop
+		  self A bar
+		else
+		end
+	`).Parse()
+	assert.EqualError(err, "Error on this line <else> (line: 5): "+
+		"An <else> can only be used in an <alt> or <par>, not <loop>")
+
+	_, err = NewParser(`
+		life A foo
+		break
+		end
+	`).Parse()
+	assert.EqualError(err, "Error on this line <end> (line: 4): "+
+		"The <break> being ended, does not contain any interactions")
+}
+
+func TestNoteStatementsOfEachForm(t *testing.T) {
+	assert := assert.New(t)
+	dslModel, err := NewParser(`
+		life A foo
+		life B bar
+		life api baz
+		note A beside | A
+		note AB over A and B
+		note over A..api over all three
+		note over api over api
+		note api beside api
+	`).Parse()
+	assert.NoError(err)
+	statements := dslModel.Statements()
+	a, b, api := statements[0], statements[1], statements[2]
+
+	beside := statements[3]
+	assert.Equal(umli.Note, beside.Keyword)
+	assert.False(beside.NoteOver)
+	assert.Equal([]*dsl.Statement{a}, beside.ReferencedLifelines)
+	assert.Equal([]string{"beside", "A"}, beside.LabelSegments)
+
+	assert.True(statements[4].NoteOver)
+	assert.Equal([]*dsl.Statement{a, b}, statements[4].ReferencedLifelines)
+	assert.Equal([]string{"over A and B"}, statements[4].LabelSegments)
+
+	assert.True(statements[5].NoteOver)
+	assert.Equal([]*dsl.Statement{a, api}, statements[5].ReferencedLifelines)
+	assert.Equal([]string{"over all three"}, statements[5].LabelSegments)
+
+	assert.True(statements[6].NoteOver)
+	assert.Equal([]*dsl.Statement{api}, statements[6].ReferencedLifelines)
+	assert.Equal([]string{"over api"}, statements[6].LabelSegments)
+
+	assert.False(statements[7].NoteOver)
+	assert.Equal([]*dsl.Statement{api}, statements[7].ReferencedLifelines)
+}
+
+func TestErrorsForMalformedNotes(t *testing.T) {
+	assert := assert.New(t)
+	_, err := NewParser(`
+		life A foo
+		note C text
+	`).Parse()
+	assert.EqualError(err, "Error on this line <note C text> (line: 3): "+
+		"Unknown lifeline: C")
+
+	_, err = NewParser(`
+		life A foo
+		note over A
+	`).Parse()
+	assert.EqualError(err, "Error on this line <note over A> (line: 3): "+
+		"A <note over> line, must have at least 4 words")
+
+	_, err = NewParser(`
+		life A foo
+		note over A..B..C text
+	`).Parse()
+	assert.EqualError(err,
+		"Error on this line <note over A..B..C text> (line: 3): "+
+			"Lifelines specified must be of the form <from>..<to>:(A..B..C)")
+}
+
+func TestParseReportsEveryProblemWithItsPosition(t *testing.T) {
+	assert := assert.New(t)
+	_, err := NewParser("life A foo\n" +
+		"full AC bar\n" +
+		"  textsize big # comment\n" +
+		"life A again\n").Parse()
+
+	problems, ok := err.(umli.DSLErrorList)
+	assert.True(ok)
+	assert.Len(problems, 3)
+
+	assert.Equal(&umli.DSLProblem{
+		Line:      2,
+		StartCol:  7,
+		EndCol:    7,
+		Token:     "C",
+		Message:   "Unknown lifeline: C",
+		Severity:  umli.SeverityError,
+		Source:    "full AC bar",
+		Statement: "full AC bar",
+		Err:       &umli.UnknownLifelineError{Name: "C"},
+	}, problems[0])
+
+	assert.Equal(3, problems[1].Line)
+	assert.Equal("big", problems[1].Token)
+	assert.Equal(12, problems[1].StartCol)
+	assert.Equal(14, problems[1].EndCol)
+	assert.Equal("Text size must be a number", problems[1].Message)
+
+	assert.Equal(4, problems[2].Line)
+	assert.Equal("A", problems[2].Token)
+	assert.Equal(6, problems[2].StartCol)
+	assert.Equal(6, problems[2].EndCol)
+
+	assert.Equal(
+		"Error on this line <full AC bar> (line: 2): Unknown lifeline: C\n"+
+			"Error on this line <textsize big> (line: 3): Text size must be a number\n"+
+			"Error on this line <life A again> (line: 4): Lifeline (A) has "+
+			"already been used", err.Error())
+}
+
+func TestProblemsWithoutATokenSpanTheWholeStatement(t *testing.T) {
+	assert := assert.New(t)
+	_, err := NewParser("\tlife A").Parse()
+	problems := err.(umli.DSLErrorList)
+	assert.Len(problems, 1)
+	assert.Equal("life A", problems[0].Token)
+	assert.Equal(2, problems[0].StartCol)
+	assert.Equal(7, problems[0].EndCol)
+}
+
+func TestRepeatedStatementsAreOnlyWarnings(t *testing.T) {
+	assert := assert.New(t)
+	parser := NewParser(`
+		title first
+		life A foo
+		title second
+	`)
+	model, err := parser.Parse()
+	assert.NoError(err)
+	assert.Equal([]string{"first"}, model.Title())
+
+	warnings := parser.Warnings()
+	assert.Len(warnings, 1)
+	assert.Equal(umli.SeverityWarning, warnings[0].Severity)
+	assert.Equal(4, warnings[0].Line)
+	assert.Equal("title", warnings[0].Token)
+	assert.EqualError(warnings[0], "Warning on this line <title second> "+
+		"(line: 4): Only the first <title> line is used, so this one has no effect")
+
+	// But they are reported along with the errors, when there are some.
+	_, err = NewParser(`
+		title first
+		title second
+		life A
+	`).Parse()
+	assert.Len(err.(umli.DSLErrorList), 2)
+}
+
+func TestParseErrorsCanBeInspected(t *testing.T) {
+	assert := assert.New(t)
+	_, err := NewParser(`
+		life A foo
+		textsize big
+		stop B
+	`).Parse()
+
+	var unknown *umli.UnknownLifelineError
+	assert.True(errors.As(err, &unknown))
+	assert.Equal("B", unknown.Name)
+
+	var syntax *umli.SyntaxError
+	assert.True(errors.As(err, &syntax))
+	assert.Equal("big", syntax.Token)
+
+	var problems umli.DSLErrorList
+	assert.True(errors.As(err, &problems))
+	assert.Len(problems, 2)
+}
+
+func TestSizeStatementsAreParsedAndValidated(t *testing.T) {
+	assert := assert.New(t)
+	model, err := NewParser(`
+		size SelfLoopHeight 4
+		size NotePadT 0.25
+		size SelfLoopHeight 5
+	`).Parse()
+	assert.NoError(err)
+	s := model.Statements()[0]
+	assert.Equal(umli.Size, s.Keyword)
+	assert.Equal("SelfLoopHeight", s.SizeKey)
+	assert.Equal(4.0, s.SizeValue)
+	assert.Equal(map[string]float64{
+		"SelfLoopHeight": 5,
+		"NotePadT":       0.25,
+	}, model.SizeOverrides())
+
+	_, err = NewParser("size Bogus 4").Parse()
+	assert.EqualError(err,
+		"Error on this line <size Bogus 4> (line: 1): Unknown size: Bogus")
+	_, err = NewParser("size ArrowLen big").Parse()
+	assert.EqualError(err,
+		"Error on this line <size ArrowLen big> (line: 1): Size must be a number")
+	_, err = NewParser("size ArrowLen 99").Parse()
+	problems := err.(umli.DSLErrorList)
+	assert.Equal("99", problems[0].Token)
+	assert.Equal(
+		"Size <ArrowLen> must be more than 0 and at most 50 (font heights)",
+		problems[0].Message)
+}
+
+func TestStatementsKnowTheirLineNumbers(t *testing.T) {
+	assert := assert.New(t)
+	model, err := NewParser(`
+		life A foo
+
+		# A comment
+		life B bar
+		full AB fibble
+	`).Parse()
+	assert.NoError(err)
+	lineNumbers := []int{}
+	for _, s := range model.Statements() {
+		lineNumbers = append(lineNumbers, s.LineNo)
+	}
+	assert.Equal([]int{2, 5, 6}, lineNumbers)
+}
@@ -0,0 +1,101 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/peterhoward42/umli"
+)
+
+// errorAt makes a umli.SyntaxError about the given token. An empty token
+// means the statement as a whole is at fault.
+func errorAt(token string, format string, args ...interface{}) error {
+	return &umli.SyntaxError{Token: token, Msg: fmt.Sprintf(format, args...)}
+}
+
+// unknownLifeline makes the error for a reference to an undeclared lifeline.
+func unknownLifeline(name string) error {
+	return &umli.UnknownLifelineError{Name: name}
+}
+
+// lineSource holds a line of the script, both as written, and reduced to
+// the statement it contains.
+type lineSource struct {
+	lineNo    int
+	raw       string
+	statement string
+}
+
+/*
+addProblem records a problem with the given line. The problem is positioned at
+the token a umli.SyntaxError names, or at the name in a
+umli.UnknownLifelineError. Any other error is attributed to the whole
+statement.
+*/
+func (p *Parser) addProblem(source lineSource, err error,
+	severity umli.Severity) {
+	token := ""
+	var syntaxErr *umli.SyntaxError
+	var unknownErr *umli.UnknownLifelineError
+	switch {
+	case errors.As(err, &syntaxErr):
+		token = syntaxErr.Token
+	case errors.As(err, &unknownErr):
+		token = unknownErr.Name
+	}
+	problem := &umli.DSLProblem{
+		Line:      source.lineNo,
+		Message:   err.Error(),
+		Severity:  severity,
+		Source:    source.raw,
+		Statement: source.statement,
+		Err:       err,
+	}
+	problem.Token, problem.StartCol, problem.EndCol = locate(source, token)
+	p.problems = append(p.problems, problem)
+}
+
+/*
+locate finds the first occurrence of token in the statement part of the line,
+and provides its column range in the line as written. When the token is
+empty, or cannot be found, it provides the range of the whole statement
+instead.
+*/
+func locate(source lineSource, token string) (
+	found string, startCol int, endCol int) {
+	statementStart := strings.Index(source.raw, source.statement)
+	if statementStart < 0 {
+		statementStart = 0
+	}
+	offset := -1
+	if token != "" {
+		offset = wordIndex(source.statement, token)
+	}
+	if offset < 0 {
+		token = source.statement
+		offset = 0
+	}
+	start := statementStart + offset
+	startCol = utf8.RuneCountInString(source.raw[:start]) + 1
+	endCol = startCol + utf8.RuneCountInString(token) - 1
+	return token, startCol, endCol
+}
+
+// wordIndex provides the index of the first occurrence of token in s that
+// starts a word, or failing that, of the first occurrence of any kind. (Or -1).
+func wordIndex(s string, token string) int {
+	for from := 0; from < len(s); {
+		i := strings.Index(s[from:], token)
+		if i < 0 {
+			break
+		}
+		i += from
+		if i == 0 || s[i-1] == ' ' {
+			return i
+		}
+		from = i + 1
+	}
+	return strings.Index(s, token)
+}
@@ -6,10 +6,13 @@ This module provides the ImageFileCreator type and its methods.
 
 import (
 	"fmt"
+	"image/jpeg"
+	"image/png"
+	"io"
+
 	"github.com/fogleman/gg"
 	"github.com/golang/freetype/truetype"
 	"github.com/peterhoward42/umli/graphics"
-	"golang.org/x/image/colornames"
 )
 
 // Encoding specifies image file encoding formats
@@ -39,43 +42,74 @@ func NewImageFileCreator(font *truetype.Font) *ImageFileCreator {
 // Create renders a graphics model into an image file.
 func (cr *ImageFileCreator) Create(
 	filePath string, encoding Encoding, mdl *graphics.Model) error {
-	// Initialise the Creator's state.
+	cr.render(mdl)
+	err := cr.save(filePath, encoding)
+	if err != nil {
+		return fmt.Errorf("Create(): %v", err)
+	}
+	return nil
+}
+
+// Encode renders a graphics model into an image, and writes the encoded
+// image to w. It is the counterpart of Create for when the destination is not
+// a file - for example a network connection or stdout.
+func (cr *ImageFileCreator) Encode(
+	w io.Writer, encoding Encoding, mdl *graphics.Model) error {
+	cr.render(mdl)
+	err := cr.encode(w, encoding)
+	if err != nil {
+		return fmt.Errorf("Encode(): %v", err)
+	}
+	return nil
+}
+
+// render initialises the Creator's state and draws the model.
+func (cr *ImageFileCreator) render(mdl *graphics.Model) {
 	cr.mdl = mdl
 	cr.dc = gg.NewContext(int(mdl.Width), int(mdl.Height))
 
-	// Now do the rendering and saving.
 	cr.paintBackground()
-	cr.renderLines()
+	// Fills first, so that the lines outlining them stay visible.
 	cr.renderPolygons()
+	cr.renderLines()
+	cr.renderPolylines()
 	cr.renderText()
-	err := cr.save(filePath, encoding)
-	if err != nil {
-		return fmt.Errorf("Create(): %v", err)
-	}
-	return nil
 }
 
 func (cr ImageFileCreator) paintBackground() {
-	cr.dc.SetColor(colornames.White)
+	cr.dc.SetColor(rgba(background(cr.mdl)))
 	cr.dc.DrawRectangle(0, 0, float64(cr.mdl.Width), float64(cr.mdl.Height))
 	cr.dc.Fill()
 }
 
 func (cr ImageFileCreator) renderLines() {
-	cr.dc.SetColor(colornames.Black)
 	for _, line := range cr.mdl.Primitives.Lines {
 		cr.setDashStyle(&line)
+		cr.dc.SetColor(rgba(line.Style.StrokeColour(defaultInk)))
+		cr.dc.SetLineWidth(line.Style.StrokeWidth(defaultLineWidth))
 		cr.dc.DrawLine(line.P1.X, line.P1.Y, line.P2.X, line.P2.Y)
 		cr.dc.Stroke()
 	}
 }
 
+func (cr ImageFileCreator) renderPolylines() {
+	cr.dc.SetDash()
+	for _, polyline := range cr.mdl.Primitives.Polylines {
+		cr.dc.SetColor(rgba(polyline.Style.StrokeColour(defaultInk)))
+		cr.dc.SetLineWidth(polyline.Style.StrokeWidth(defaultLineWidth))
+		for _, vertex := range polyline.Vertices {
+			cr.dc.LineTo(vertex.X, vertex.Y)
+		}
+		cr.dc.Stroke()
+	}
+}
+
 func (cr ImageFileCreator) renderPolygons() {
-	cr.dc.SetColor(colornames.Black)
 	for _, poly := range cr.mdl.Primitives.FilledPolys {
-		start := poly[0]
+		cr.dc.SetColor(rgba(poly.Style.FillColour(defaultInk)))
+		start := poly.Vertices[0]
 		cr.dc.MoveTo(start.X, start.Y)
-		for _, vertex := range poly {
+		for _, vertex := range poly.Vertices {
 			cr.dc.LineTo(vertex.X, vertex.Y)
 		}
 		cr.dc.ClosePath()
@@ -94,7 +128,6 @@ var ggJustification = map[graphics.Justification]float64{
 }
 
 func (cr ImageFileCreator) renderText() {
-	cr.dc.SetColor(colornames.Black)
 	for _, label := range cr.mdl.Primitives.Labels {
 		// truetype.Options takes its Size parameter in point units.
 		// With typical image DPI/PPI this a 1:1 correletion to the
@@ -102,9 +135,26 @@ func (cr ImageFileCreator) renderText() {
 		face := truetype.NewFace(cr.font,
 			&truetype.Options{Size: label.FontHeight})
 		cr.dc.SetFontFace(face)
-		cr.dc.DrawStringAnchored(
-			label.TheString, label.Anchor.X, label.Anchor.Y,
-			ggJustification[label.HJust], ggJustification[label.VJust])
+		cr.dc.SetColor(rgba(label.Style.TextColour(defaultInk)))
+		cr.dc.Push()
+		if label.Style.IsItalic() {
+			// Shear about the baseline, so that it stays put.
+			baseline := label.Anchor.Y +
+				ggJustification[label.VJust]*label.FontHeight
+			cr.dc.ShearAbout(-syntheticItalicSlope, 0, label.Anchor.X, baseline)
+		}
+		// Bold text is drawn repeatedly, spread across the extra weight.
+		offsets := []float64{0}
+		if label.Style.IsBold() {
+			weight := syntheticBoldWeight * label.FontHeight
+			offsets = []float64{-0.5 * weight, 0, 0.5 * weight}
+		}
+		for _, dx := range offsets {
+			cr.dc.DrawStringAnchored(
+				label.TheString, label.Anchor.X+dx, label.Anchor.Y,
+				ggJustification[label.HJust], ggJustification[label.VJust])
+		}
+		cr.dc.Pop()
 	}
 }
 
@@ -117,6 +167,8 @@ func (cr ImageFileCreator) setDashStyle(line *graphics.Line) {
 	}
 }
 
+const jpgQuality = 92
+
 func (cr ImageFileCreator) save(
 	filePath string, encoding Encoding) error {
 	var err error
@@ -124,8 +176,7 @@ func (cr ImageFileCreator) save(
 	case PNG:
 		err = cr.dc.SavePNG(filePath)
 	case JPG:
-		quality := 92
-		err = cr.dc.SaveJPG(filePath, quality)
+		err = cr.dc.SaveJPG(filePath, jpgQuality)
 	default:
 		return fmt.Errorf(
 			"save(): Not implemented encoding value: %v", encoding)
@@ -135,3 +186,15 @@ func (cr ImageFileCreator) save(
 	}
 	return nil
 }
+
+func (cr ImageFileCreator) encode(w io.Writer, encoding Encoding) error {
+	switch encoding {
+	case PNG:
+		return png.Encode(w, cr.dc.Image())
+	case JPG:
+		return jpeg.Encode(w, cr.dc.Image(), &jpeg.Options{Quality: jpgQuality})
+	default:
+		return fmt.Errorf(
+			"encode(): Not implemented encoding value: %v", encoding)
+	}
+}
@@ -1,6 +1,9 @@
 package render
 
 import (
+	"bytes"
+	"image/color"
+	"image/png"
 	"path/filepath"
 	"testing"
 
@@ -66,6 +69,13 @@ func fullCoverageModel() *graphics.Model {
 		graphics.NewPoint(right, bot),
 	})
 
+	// An open arrow head pointing left at the left hand end of the lines.
+	prims.AddPolyline([]graphics.Point{
+		graphics.NewPoint(left, top),
+		graphics.NewPoint(left-fh, midY),
+		graphics.NewPoint(left, bot),
+	})
+
 	prims.AddLabel("LeftBot", fh, left, bot, graphics.Left, graphics.Bottom)
 	prims.AddLabel("CtrCtr", fh, midX, midY, graphics.Centre, graphics.Centre)
 	prims.AddLabel("RightTop", fh, right, top, graphics.Right, graphics.Top)
@@ -73,6 +83,27 @@ func fullCoverageModel() *graphics.Model {
 	return graphicsModel
 }
 
+// Colours used by styledModel.
+var (
+	red   = graphics.Colour{R: 255}
+	green = graphics.Colour{G: 128}
+	blue  = graphics.Colour{B: 255}
+)
+
+// styledModel is a DRY test helper that makes the full coverage model, and
+// then styles some of its primitives.
+func styledModel() *graphics.Model {
+	mdl := fullCoverageModel()
+	prims := mdl.Primitives
+	prims.Lines[0].Style = &graphics.LineStyle{Colour: &red, Width: 5}
+	prims.FilledPolys[0].Style = &graphics.FillStyle{Colour: &blue}
+	prims.Polylines[0].Style = &graphics.LineStyle{Colour: &green, Width: 5}
+	prims.Labels[0].Style = &graphics.TextStyle{Colour: &green, Bold: true}
+	prims.Labels[1].Style = &graphics.TextStyle{Italic: true}
+	prims.Labels[2].Style = &graphics.TextStyle{Bold: true, Italic: true}
+	return mdl
+}
+
 var fileExtensions = map[Encoding]string{
 	PNG: ".png",
 	JPG: "jpg",
@@ -93,3 +124,62 @@ func createAndsaveImageFileForExampleModel(t *testing.T, encoding Encoding,
 	assert.NoError(err)
 	return saveAs
 }
+
+func TestImageHonoursStyles(t *testing.T) {
+	assert := assert.New(t)
+	font, err := truetype.Parse(goregular.TTF)
+	assert.NoError(err)
+	var buf bytes.Buffer
+	err = NewImageFileCreator(font).Encode(&buf, PNG, styledModel())
+	assert.NoError(err)
+	img, err := png.Decode(&buf)
+	assert.NoError(err)
+
+	// The first (top) line is red and 5 wide, so is solid red even a
+	// pixel away from its centre line.
+	assert.Equal(rgba(red), color.RGBAModel.Convert(img.At(300, 101)))
+	assert.Equal(rgba(defaultBackground),
+		color.RGBAModel.Convert(img.At(300, 104)))
+
+	// Whereas the second (bottom) line is unstyled, so is grey where it
+	// is anti-aliased.
+	bottom := color.RGBAModel.Convert(img.At(300, 145)).(color.RGBA)
+	assert.True(bottom.R < 255)
+	assert.True(bottom.R == bottom.G && bottom.G == bottom.B)
+
+	// Inside the polygon.
+	assert.Equal(rgba(blue), color.RGBAModel.Convert(img.At(1010, 140)))
+
+	// On the polyline, which is green and 5 wide, but not filled.
+	assert.Equal(rgba(green), color.RGBAModel.Convert(img.At(77, 111)))
+	assert.Equal(rgba(defaultBackground),
+		color.RGBAModel.Convert(img.At(95, 122)))
+}
+
+// boxFillModel provides a model with a filled box, and a line drawn across
+// the middle of it, (as the edges of activity boxes are drawn over their
+// fills).
+func boxFillModel() *graphics.Model {
+	mdl := graphics.NewModel(200, 10, 5, 1)
+	mdl.Height = 200
+	prims := mdl.Primitives
+	prims.AddFilledPoly([]graphics.Point{
+		{X: 50, Y: 50}, {X: 150, Y: 50}, {X: 150, Y: 150}, {X: 50, Y: 150}})
+	prims.FilledPolys[0].Style = &graphics.FillStyle{Colour: &blue}
+	prims.AddLine(50, 100, 150, 100, false)
+	prims.Lines[0].Style = &graphics.LineStyle{Colour: &red, Width: 5}
+	return mdl
+}
+
+func TestImageDrawsLinesOverFills(t *testing.T) {
+	assert := assert.New(t)
+	font, err := truetype.Parse(goregular.TTF)
+	assert.NoError(err)
+	var buf bytes.Buffer
+	err = NewImageFileCreator(font).Encode(&buf, PNG, boxFillModel())
+	assert.NoError(err)
+	img, err := png.Decode(&buf)
+	assert.NoError(err)
+	assert.Equal(rgba(red), color.RGBAModel.Convert(img.At(100, 100)))
+	assert.Equal(rgba(blue), color.RGBAModel.Convert(img.At(100, 75)))
+}
@@ -0,0 +1,507 @@
+package render
+
+/*
+This module provides the PDFCreator type and its methods.
+
+The PDF document is written directly, without the help of any PDF library or
+external tool. It is a single page document containing one content stream, and
+one embedded TrueType font. The PDF specification reference used is ISO
+32000-1 (PDF 1.7), but only features from PDF 1.4 are used.
+*/
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/peterhoward42/umli/graphics"
+)
+
+// PageSize is the size of a PDF page in points (1/72 inch), when it is in
+// portrait orientation.
+type PageSize struct {
+	Name   string
+	Width  float64
+	Height float64
+}
+
+// Values for type PageSize.
+var (
+	A4     = PageSize{"A4", 595.28, 841.89}
+	A3     = PageSize{"A3", 841.89, 1190.55}
+	Letter = PageSize{"Letter", 612, 792}
+	Legal  = PageSize{"Legal", 612, 1008}
+)
+
+// PageSizes provides the available page sizes keyed on their lower case names.
+var PageSizes = map[string]PageSize{
+	"a4":     A4,
+	"a3":     A3,
+	"letter": Letter,
+	"legal":  Legal,
+}
+
+// Orientation specifies which way up the page is.
+type Orientation int
+
+// Values for type Orientation
+const (
+	Portrait Orientation = iota
+	Landscape
+)
+
+// pdfMargin is the blank border left around the diagram, in points.
+const pdfMargin = 36.0
+
+// PDFCreator is able to render a graphics.Model into a single page PDF
+// document. The diagram is scaled to fit the page (inside a margin),
+// preserving its aspect ratio. The font used for the labels is embedded in
+// the document.
+type PDFCreator struct {
+	fontData    []byte
+	font        *truetype.Font
+	page        PageSize
+	orientation Orientation
+	widths      [256]int // Glyph advance widths in 1/1000 em, by character code.
+
+	mdl     *graphics.Model
+	xform   pdfTransform
+	content *bytes.Buffer
+}
+
+// NewPDFCreator provides a PDFCreator ready to use. The fontData must be
+// the contents of a TrueType font file (e.g. goregular.TTF), because it is
+// that which is embedded in the PDF documents created.
+func NewPDFCreator(fontData []byte, page PageSize,
+	orientation Orientation) (*PDFCreator, error) {
+	font, err := truetype.Parse(fontData)
+	if err != nil {
+		return nil, fmt.Errorf("truetype.Parse: %v", err)
+	}
+	cr := &PDFCreator{
+		fontData:    fontData,
+		font:        font,
+		page:        page,
+		orientation: orientation,
+	}
+	for code := range cr.widths {
+		index := font.Index(winAnsiRune(byte(code)))
+		cr.widths[code] = int(font.HMetric(fixed.Int26_6(1000), index).AdvanceWidth)
+	}
+	return cr, nil
+}
+
+// Create renders a graphics model as a PDF document, and writes it to w.
+func (cr *PDFCreator) Create(w io.Writer, mdl *graphics.Model) error {
+	// Initialise the Creator's state.
+	cr.mdl = mdl
+	pageWidth, pageHeight := cr.pageDimensions()
+	cr.xform = newPDFTransform(mdl.Width, mdl.Height, pageWidth, pageHeight)
+	cr.content = &bytes.Buffer{}
+
+	cr.paintBackground()
+	cr.renderPolygons()
+	cr.renderLines()
+	cr.renderPolylines()
+	cr.renderText()
+
+	doc, err := cr.assembleDocument(pageWidth, pageHeight)
+	if err != nil {
+		return fmt.Errorf("assembleDocument: %v", err)
+	}
+	if _, err := w.Write(doc); err != nil {
+		return fmt.Errorf("Create(): %v", err)
+	}
+	return nil
+}
+
+// pageDimensions provides the width and height of the page, having taken
+// the orientation into account.
+func (cr *PDFCreator) pageDimensions() (width, height float64) {
+	if cr.orientation == Landscape {
+		return cr.page.Height, cr.page.Width
+	}
+	return cr.page.Width, cr.page.Height
+}
+
+func (cr *PDFCreator) paintBackground() {
+	x, y := cr.xform.point(graphics.NewPoint(0, cr.mdl.Height))
+	fmt.Fprintf(cr.content, "%s rg %s %s %s %s re f\n",
+		pdfColour(background(cr.mdl)), pdfNum(x), pdfNum(y),
+		pdfNum(cr.xform.length(cr.mdl.Width)),
+		pdfNum(cr.xform.length(cr.mdl.Height)))
+}
+
+func (cr *PDFCreator) renderLines() {
+	// The colour and width are only set when they change, to keep the
+	// content stream small.
+	colour := defaultInk
+	width := defaultLineWidth
+	fmt.Fprintf(cr.content, "%s RG %s w\n",
+		pdfColour(colour), pdfNum(cr.xform.length(width)))
+	dashed := false
+	for _, line := range cr.mdl.Primitives.Lines {
+		if line.Dashed != dashed {
+			cr.setDashStyle(line.Dashed)
+			dashed = line.Dashed
+		}
+		if c := line.Style.StrokeColour(defaultInk); c != colour {
+			colour = c
+			fmt.Fprintf(cr.content, "%s RG\n", pdfColour(colour))
+		}
+		if w := line.Style.StrokeWidth(defaultLineWidth); w != width {
+			width = w
+			fmt.Fprintf(cr.content, "%s w\n", pdfNum(cr.xform.length(width)))
+		}
+		x1, y1 := cr.xform.point(line.P1)
+		x2, y2 := cr.xform.point(line.P2)
+		fmt.Fprintf(cr.content, "%s %s m %s %s l S\n",
+			pdfNum(x1), pdfNum(y1), pdfNum(x2), pdfNum(y2))
+	}
+	if dashed {
+		cr.setDashStyle(false)
+	}
+}
+
+func (cr *PDFCreator) renderPolylines() {
+	colour := defaultInk
+	width := defaultLineWidth
+	fmt.Fprintf(cr.content, "%s RG %s w\n",
+		pdfColour(colour), pdfNum(cr.xform.length(width)))
+	for _, polyline := range cr.mdl.Primitives.Polylines {
+		if c := polyline.Style.StrokeColour(defaultInk); c != colour {
+			colour = c
+			fmt.Fprintf(cr.content, "%s RG\n", pdfColour(colour))
+		}
+		if w := polyline.Style.StrokeWidth(defaultLineWidth); w != width {
+			width = w
+			fmt.Fprintf(cr.content, "%s w\n", pdfNum(cr.xform.length(width)))
+		}
+		cr.addPath(polyline.Vertices)
+		cr.content.WriteString("S\n")
+	}
+}
+
+func (cr *PDFCreator) setDashStyle(dashed bool) {
+	if !dashed {
+		cr.content.WriteString("[] 0 d\n")
+		return
+	}
+	fmt.Fprintf(cr.content, "[%s %s] 0 d\n",
+		pdfNum(cr.xform.length(cr.mdl.DashLineDashLen)),
+		pdfNum(cr.xform.length(cr.mdl.DashLineGapLen)))
+}
+
+func (cr *PDFCreator) renderPolygons() {
+	colour := defaultInk
+	fmt.Fprintf(cr.content, "%s rg\n", pdfColour(colour))
+	for _, poly := range cr.mdl.Primitives.FilledPolys {
+		if c := poly.Style.FillColour(defaultInk); c != colour {
+			colour = c
+			fmt.Fprintf(cr.content, "%s rg\n", pdfColour(colour))
+		}
+		cr.addPath(poly.Vertices)
+		cr.content.WriteString("h f\n")
+	}
+}
+
+// addPath writes the operators that move to the first of the vertices and
+// then draw lines through the rest, (leaving the path to be painted).
+func (cr *PDFCreator) addPath(vertices []graphics.Point) {
+	for i, vertex := range vertices {
+		operator := "l"
+		if i == 0 {
+			operator = "m"
+		}
+		x, y := cr.xform.point(vertex)
+		fmt.Fprintf(cr.content, "%s %s %s ", pdfNum(x), pdfNum(y), operator)
+	}
+}
+
+func (cr *PDFCreator) renderText() {
+	colour := defaultInk
+	fmt.Fprintf(cr.content, "%s rg\n", pdfColour(colour))
+	for _, label := range cr.mdl.Primitives.Labels {
+		encoded := winAnsiEncode(label.TheString)
+		// PDF text is positioned by the left hand end of its baseline, so
+		// we justify it ourselves. Vertically we use the same continuum as
+		// the gg library does (see ggJustification), so that all the
+		// renderers position text identically.
+		width := cr.textWidth(encoded, label.FontHeight)
+		left := label.Anchor.X - ggJustification[label.HJust]*width
+		baseline := label.Anchor.Y + ggJustification[label.VJust]*label.FontHeight
+		x, y := cr.xform.point(graphics.NewPoint(left, baseline))
+		if c := label.Style.TextColour(defaultInk); c != colour {
+			colour = c
+			fmt.Fprintf(cr.content, "%s rg\n", pdfColour(colour))
+		}
+		bold := label.Style.IsBold()
+		if bold {
+			// Bold text is outlined as well as filled, (rendering mode
+			// 2), inside a saved graphics state.
+			fmt.Fprintf(cr.content, "q %s RG %s w ", pdfColour(colour),
+				pdfNum(cr.xform.length(syntheticBoldWeight*label.FontHeight)))
+		}
+		fmt.Fprintf(cr.content, "BT /F1 %s Tf ",
+			pdfNum(cr.xform.length(label.FontHeight)))
+		if bold {
+			cr.content.WriteString("2 Tr ")
+		}
+		if label.Style.IsItalic() {
+			// A text matrix that shears the text.
+			fmt.Fprintf(cr.content, "1 0 %s 1 %s %s Tm ",
+				pdfNum(syntheticItalicSlope), pdfNum(x), pdfNum(y))
+		} else {
+			fmt.Fprintf(cr.content, "%s %s Td ", pdfNum(x), pdfNum(y))
+		}
+		fmt.Fprintf(cr.content, "(%s) Tj ET", pdfEscape(encoded))
+		if bold {
+			cr.content.WriteString(" Q")
+		}
+		cr.content.WriteString("\n")
+	}
+}
+
+// pdfColour provides the operands for the PDF colour operators (e.g. rg)
+// that select colour c.
+func pdfColour(c graphics.Colour) string {
+	return fmt.Sprintf("%s %s %s", pdfNum(float64(c.R)/255),
+		pdfNum(float64(c.G)/255), pdfNum(float64(c.B)/255))
+}
+
+// textWidth provides the width (in model units) that the WinAnsi encoded
+// string s occupies when rendered at the given font height.
+func (cr *PDFCreator) textWidth(s []byte, fontHeight float64) float64 {
+	total := 0
+	for _, code := range s {
+		total += cr.widths[code]
+	}
+	return float64(total) / 1000 * fontHeight
+}
+
+// Object numbers for the objects in the document. The document is small
+// and always has the same structure, so we can allocate them statically.
+const (
+	objCatalog = iota + 1
+	objPages
+	objPage
+	objContent
+	objFont
+	objFontDescriptor
+	objFontFile
+	objInfo
+	objCount = objInfo
+)
+
+// assembleDocument wraps the content stream, and the font into a complete
+// PDF document.
+func (cr *PDFCreator) assembleDocument(
+	pageWidth, pageHeight float64) ([]byte, error) {
+	doc := &pdfDocument{}
+	doc.buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	doc.addObject(objCatalog, fmt.Sprintf(
+		"<< /Type /Catalog /Pages %d 0 R >>", objPages))
+	doc.addObject(objPages, fmt.Sprintf(
+		"<< /Type /Pages /Kids [%d 0 R] /Count 1 >>", objPage))
+	doc.addObject(objPage, fmt.Sprintf(
+		"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] "+
+			"/Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>",
+		objPages, pdfNum(pageWidth), pdfNum(pageHeight), objFont, objContent))
+	if err := doc.addStream(objContent, "", cr.content.Bytes()); err != nil {
+		return nil, fmt.Errorf("addStream: %v", err)
+	}
+
+	fontName := cr.fontName()
+	widths := make([]string, 0, 256-firstCharCode)
+	for _, w := range cr.widths[firstCharCode:] {
+		widths = append(widths, strconv.Itoa(w))
+	}
+	doc.addObject(objFont, fmt.Sprintf(
+		"<< /Type /Font /Subtype /TrueType /BaseFont /%s "+
+			"/FirstChar %d /LastChar 255 /Widths [%s] "+
+			"/Encoding /WinAnsiEncoding /FontDescriptor %d 0 R >>",
+		fontName, firstCharCode, strings.Join(widths, " "), objFontDescriptor))
+
+	bounds := cr.font.Bounds(fixed.Int26_6(1000))
+	doc.addObject(objFontDescriptor, fmt.Sprintf(
+		"<< /Type /FontDescriptor /FontName /%s /Flags 32 "+
+			"/FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d "+
+			"/CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		fontName, bounds.Min.X, bounds.Min.Y, bounds.Max.X, bounds.Max.Y,
+		bounds.Max.Y, bounds.Min.Y, bounds.Max.Y, objFontFile))
+	if err := doc.addStream(objFontFile, fmt.Sprintf("/Length1 %d",
+		len(cr.fontData)), cr.fontData); err != nil {
+		return nil, fmt.Errorf("addStream: %v", err)
+	}
+
+	doc.addObject(objInfo, "<< /Producer (umli) >>")
+	doc.finish()
+	return doc.buf.Bytes(), nil
+}
+
+// fontName provides the font's PostScript name, reduced to characters that
+// are safe to use in a PDF name object.
+func (cr *PDFCreator) fontName() string {
+	name := strings.Map(func(r rune) rune {
+		if r > ' ' && r < 0x7f && !strings.ContainsRune("()<>[]{}/%#", r) {
+			return r
+		}
+		return -1
+	}, cr.font.Name(truetype.NameIDPostscriptName))
+	if name == "" {
+		return "EmbeddedFont"
+	}
+	return name
+}
+
+/*
+pdfTransform maps coordinates from the model's space, (with Y increasing
+downwards), to the PDF page's space, in points, (with Y increasing upwards).
+It scales the model uniformly so that it fits inside the page's margins,
+centres it horizontally, and places it at the top of the page.
+*/
+type pdfTransform struct {
+	scale      float64
+	originX    float64 // Page X coordinate of the model's left edge.
+	originY    float64 // Page Y coordinate of the model's top edge.
+	pageHeight float64
+}
+
+func newPDFTransform(modelWidth, modelHeight,
+	pageWidth, pageHeight float64) pdfTransform {
+	scale := (pageWidth - 2*pdfMargin) / modelWidth
+	if modelHeight > 0 {
+		scale = math.Min(scale, (pageHeight-2*pdfMargin)/modelHeight)
+	}
+	return pdfTransform{
+		scale:      scale,
+		originX:    0.5 * (pageWidth - scale*modelWidth),
+		originY:    pageHeight - pdfMargin,
+		pageHeight: pageHeight,
+	}
+}
+
+// point transforms the model point p to page coordinates.
+func (t pdfTransform) point(p graphics.Point) (x, y float64) {
+	return t.originX + t.scale*p.X, t.originY - t.scale*p.Y
+}
+
+// length transforms a length in model units to a length in points.
+func (t pdfTransform) length(l float64) float64 {
+	return t.scale * l
+}
+
+// pdfDocument accumulates the serialized objects of a PDF document, keeping
+// track of where each starts, so that it can write the cross reference table.
+type pdfDocument struct {
+	buf     bytes.Buffer
+	offsets [objCount + 1]int
+}
+
+func (d *pdfDocument) addObject(num int, dict string) {
+	d.offsets[num] = d.buf.Len()
+	fmt.Fprintf(&d.buf, "%d 0 obj\n%s\nendobj\n", num, dict)
+}
+
+// addStream adds a stream object, compressing the data given. The extraEntries
+// are added to the stream's dictionary.
+func (d *pdfDocument) addStream(num int, extraEntries string,
+	data []byte) error {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	if _, err := zw.Write(data); err != nil {
+		return fmt.Errorf("zw.Write: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("zw.Close: %v", err)
+	}
+	entries := fmt.Sprintf("/Length %d /Filter /FlateDecode", compressed.Len())
+	if extraEntries != "" {
+		entries += " " + extraEntries
+	}
+	d.offsets[num] = d.buf.Len()
+	fmt.Fprintf(&d.buf, "%d 0 obj\n<< %s >>\nstream\n", num, entries)
+	d.buf.Write(compressed.Bytes())
+	d.buf.WriteString("\nendstream\nendobj\n")
+	return nil
+}
+
+// finish writes the cross reference table and the trailer.
+func (d *pdfDocument) finish() {
+	xrefOffset := d.buf.Len()
+	fmt.Fprintf(&d.buf, "xref\n0 %d\n0000000000 65535 f \n", objCount+1)
+	for _, offset := range d.offsets[1:] {
+		fmt.Fprintf(&d.buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&d.buf, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\n"+
+		"startxref\n%d\n%%%%EOF\n", objCount+1, objCatalog, objInfo, xrefOffset)
+}
+
+// firstCharCode is the first character code for which the font has a width.
+const firstCharCode = 32
+
+// winAnsiSpecials are the characters of the WinAnsiEncoding that do not share
+// their code with Unicode. (Those in the range 0x80 to 0x9f).
+var winAnsiSpecials = map[byte]rune{
+	0x80: '€', 0x82: '‚', 0x83: 'ƒ', 0x84: '„', 0x85: '…', 0x86: '†',
+	0x87: '‡', 0x88: 'ˆ', 0x89: '‰', 0x8a: 'Š', 0x8b: '‹', 0x8c: 'Œ',
+	0x8e: 'Ž', 0x91: '‘', 0x92: '’', 0x93: '“', 0x94: '”', 0x95: '•',
+	0x96: '–', 0x97: '—', 0x98: '˜', 0x99: '™', 0x9a: 'š', 0x9b: '›',
+	0x9c: 'œ', 0x9e: 'ž', 0x9f: 'Ÿ',
+}
+
+// winAnsiRune provides the rune that a WinAnsiEncoding character code
+// represents.
+func winAnsiRune(code byte) rune {
+	if r, ok := winAnsiSpecials[code]; ok {
+		return r
+	}
+	return rune(code)
+}
+
+// winAnsiEncode encodes s using the WinAnsiEncoding, substituting a question
+// mark for any characters that the encoding cannot represent.
+func winAnsiEncode(s string) []byte {
+	encoded := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r >= firstCharCode && r < 0x7f, r >= 0xa0 && r <= 0xff:
+			encoded = append(encoded, byte(r))
+		default:
+			code := byte('?')
+			for c, special := range winAnsiSpecials {
+				if special == r {
+					code = c
+				}
+			}
+			encoded = append(encoded, code)
+		}
+	}
+	return encoded
+}
+
+// pdfEscape makes s safe to use inside a PDF literal string.
+func pdfEscape(s []byte) string {
+	var sb strings.Builder
+	for _, c := range s {
+		if c == '(' || c == ')' || c == '\\' {
+			sb.WriteByte('\\')
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+// pdfNum formats a coordinate or length, to a precision of a thousandth of
+// a point - which is plenty.
+func pdfNum(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1000)/1000, 'f', -1, 64)
+}
@@ -0,0 +1,164 @@
+package render
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/image/font/gofont/goregular"
+
+	"github.com/peterhoward42/umli/graphics"
+)
+
+func TestThatSavesExampleModelAsPDFForVisualInspection(t *testing.T) {
+	assert := assert.New(t)
+	cr, err := NewPDFCreator(goregular.TTF, A4, Landscape)
+	assert.NoError(err)
+	var buf bytes.Buffer
+	err = cr.Create(&buf, fullCoverageModel())
+	assert.NoError(err)
+	saveAs := filepath.Join(testResultsDir, "example.pdf")
+	err = ioutil.WriteFile(saveAs, buf.Bytes(), 0644)
+	assert.NoError(err)
+}
+
+/*
+Given a PDF document made from the full coverage model...
+Then it should have the header and trailer PDF readers require, and every
+entry in the cross reference table should point to the object it claims to.
+*/
+func TestPDFIsWellFormed(t *testing.T) {
+	assert := assert.New(t)
+	cr, err := NewPDFCreator(goregular.TTF, A4, Landscape)
+	assert.NoError(err)
+	var buf bytes.Buffer
+	err = cr.Create(&buf, fullCoverageModel())
+	assert.NoError(err)
+	doc := buf.String()
+
+	assert.True(strings.HasPrefix(doc, "%PDF-1.4\n"))
+	assert.True(strings.HasSuffix(doc, "%%EOF\n"))
+
+	match := regexp.MustCompile(`startxref\n(\d+)\n`).FindStringSubmatch(doc)
+	assert.Len(match, 2)
+	xrefOffset, _ := strconv.Atoi(match[1])
+	assert.True(strings.HasPrefix(doc[xrefOffset:], "xref\n0 9\n"))
+
+	entries := regexp.MustCompile(`(\d{10}) 00000 n \n`).FindAllStringSubmatch(
+		doc[xrefOffset:], -1)
+	assert.Len(entries, objCount)
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(entry[1])
+		prefix := strconv.Itoa(i+1) + " 0 obj\n"
+		assert.True(strings.HasPrefix(doc[offset:], prefix))
+	}
+
+	// Landscape A4, with the font embedded.
+	assert.Contains(doc, "/MediaBox [0 0 841.89 595.28]")
+	assert.Contains(doc, "/BaseFont /GoRegular")
+	assert.Contains(doc, "/FontFile2 7 0 R")
+	assert.Contains(doc, "/Length1 "+strconv.Itoa(len(goregular.TTF)))
+}
+
+/*
+Given a PDFCreator...
+When it renders the full coverage model...
+Then its content stream should draw every primitive.
+*/
+func TestPDFContentContainsEveryPrimitive(t *testing.T) {
+	assert := assert.New(t)
+	cr, err := NewPDFCreator(goregular.TTF, A4, Portrait)
+	assert.NoError(err)
+	mdl := fullCoverageModel()
+	err = cr.Create(&bytes.Buffer{}, mdl)
+	assert.NoError(err)
+	content := cr.content.String()
+
+	prims := mdl.Primitives
+	// Lines and polylines are both stroked paths.
+	assert.Equal(len(prims.Lines)+len(prims.Polylines),
+		strings.Count(content, " l S\n"))
+	assert.Equal(len(prims.FilledPolys), strings.Count(content, " h f\n"))
+	assert.Equal(len(prims.Labels), strings.Count(content, ") Tj ET\n"))
+	assert.Contains(content, "(CtrCtr) Tj")
+}
+
+func TestPDFTransformFitsModelInsideMargins(t *testing.T) {
+	assert := assert.New(t)
+
+	// A wide model is limited by the page width.
+	xform := newPDFTransform(2000, 500, 636, 400)
+	assert.InDelta(0.282, xform.scale, 0.0001)
+	x, y := xform.point(graphics.NewPoint(0, 0))
+	assert.InDelta(36.0, x, 0.0001)
+	assert.InDelta(364.0, y, 0.0001)
+	x, y = xform.point(graphics.NewPoint(2000, 500))
+	assert.InDelta(600.0, x, 0.0001)
+	assert.InDelta(364.0-141.0, y, 0.0001)
+
+	// Whereas a tall one is limited by the page height, and is centred
+	// horizontally.
+	xform = newPDFTransform(2000, 4000, 636, 400)
+	assert.InDelta(0.082, xform.scale, 0.0001)
+	x, _ = xform.point(graphics.NewPoint(0, 0))
+	assert.InDelta(0.5*(636-164), x, 0.0001)
+	_, y = xform.point(graphics.NewPoint(0, 4000))
+	assert.InDelta(36.0, y, 0.0001)
+}
+
+func TestPDFTextIsJustifiedUsingFontWidths(t *testing.T) {
+	assert := assert.New(t)
+	cr, err := NewPDFCreator(goregular.TTF, A4, Portrait)
+	assert.NoError(err)
+
+	// The Go fonts' glyphs are not all the same width, but the digits are.
+	w := cr.textWidth([]byte("0"), 10)
+	assert.True(w > 0)
+	assert.InDelta(3*w, cr.textWidth([]byte("123"), 10), 0.0001)
+
+	mdl := graphics.NewModel(1000, 10, 5, 5)
+	mdl.Height = 1000
+	mdl.Primitives.AddLabel("123", 10, 500, 100, graphics.Right, graphics.Top)
+	err = cr.Create(&bytes.Buffer{}, mdl)
+	assert.NoError(err)
+	x, y := cr.xform.point(graphics.NewPoint(500-3*w, 110))
+	assert.Contains(cr.content.String(),
+		pdfNum(x)+" "+pdfNum(y)+" Td (123) Tj")
+}
+
+func TestWinAnsiEncodingAndEscaping(t *testing.T) {
+	assert := assert.New(t)
+	encoded := winAnsiEncode("f(x) \\ é € ✓")
+	assert.Equal([]byte("f(x) \\ \xe9 \x80 ?"), encoded)
+	assert.Equal("f\\(x\\) \\\\ \xe9 \x80 ?", pdfEscape(encoded))
+}
+
+func TestPDFHonoursStyles(t *testing.T) {
+	assert := assert.New(t)
+	cr, err := NewPDFCreator(goregular.TTF, A4, Portrait)
+	assert.NoError(err)
+	err = cr.Create(&bytes.Buffer{}, styledModel())
+	assert.NoError(err)
+	content := cr.content.String()
+
+	// The red line, and its width, which then revert for the next line.
+	assert.Contains(content, "1 0 0 RG\n"+pdfNum(cr.xform.length(5))+" w\n")
+	assert.Contains(content, "0 0 0 RG\n"+pdfNum(cr.xform.length(1))+" w\n")
+	// The blue polygon.
+	assert.Contains(content, "0 0 1 rg\n")
+	// The green bold label is outlined as well as filled.
+	assert.Contains(content, "0 0.502 0 rg\nq 0 0.502 0 RG ")
+	assert.Contains(content, "2 Tr ")
+	assert.Contains(content, "(LeftBot) Tj ET Q\n")
+	// The italic labels are sheared, but the others are not.
+	assert.Equal(2, strings.Count(content, "1 0 0.2 1 "))
+	assert.Contains(content, " Td (LeftBot)")
+
+	prims := styledModel().Primitives
+	assert.Equal(len(prims.Labels), strings.Count(content, ") Tj ET"))
+}
@@ -0,0 +1,46 @@
+package render
+
+/*
+This module provides what the renderers share, to honour the optional styles
+in a graphics model.
+*/
+
+import (
+	"image/color"
+
+	"github.com/peterhoward42/umli/graphics"
+)
+
+// The colours used for anything the model does not style.
+var (
+	defaultBackground = graphics.White
+	defaultInk        = graphics.Black
+)
+
+// defaultLineWidth is the width (in model units) of lines that the model
+// does not style.
+const defaultLineWidth = 1.0
+
+/*
+The image and PDF renderers have only the one (regular) font, so they
+synthesise bold and italic text. Bold text is thickened by a proportion of
+the font height, and italic text is sheared, so that it leans to the right by
+the given amount per unit of height.
+*/
+const (
+	syntheticBoldWeight  = 0.03
+	syntheticItalicSlope = 0.2
+)
+
+// background provides the colour to paint the background of mdl.
+func background(mdl *graphics.Model) graphics.Colour {
+	if mdl.Background == nil {
+		return defaultBackground
+	}
+	return *mdl.Background
+}
+
+// rgba converts a model colour into an opaque image colour.
+func rgba(c graphics.Colour) color.RGBA {
+	return color.RGBA{R: c.R, G: c.G, B: c.B, A: 255}
+}
@@ -0,0 +1,222 @@
+package render
+
+/*
+This module provides the SVGCreator type and its methods.
+*/
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/peterhoward42/umli/graphics"
+)
+
+// SVGCreator is able to render a graphics.Model into an SVG document.
+type SVGCreator struct {
+	mdl *graphics.Model
+	buf *strings.Builder
+}
+
+// NewSVGCreator provides an SVGCreator ready to use.
+func NewSVGCreator() *SVGCreator {
+	return &SVGCreator{}
+}
+
+// Create renders a graphics model as an SVG document, and writes it to w.
+// The SVG coordinate system is identical to that of the model, so the
+// document's width and height are those of the model.
+func (cr *SVGCreator) Create(w io.Writer, mdl *graphics.Model) error {
+	// Initialise the Creator's state.
+	cr.mdl = mdl
+	cr.buf = &strings.Builder{}
+
+	// Accumulate the document in memory, so that the writer sees a single
+	// write, and we do not need to error-check every fragment.
+	cr.openDocument()
+	cr.paintBackground()
+	// The polygons go first, so that lines, (like the edges of filled activity
+	// boxes), are drawn over them, rather than hidden beneath them.
+	cr.renderPolygons()
+	cr.renderLines()
+	cr.renderPolylines()
+	cr.renderText()
+	cr.closeDocument()
+
+	if _, err := io.WriteString(w, cr.buf.String()); err != nil {
+		return fmt.Errorf("Create(): %v", err)
+	}
+	return nil
+}
+
+func (cr SVGCreator) openDocument() {
+	w := num(cr.mdl.Width)
+	h := num(cr.mdl.Height)
+	fmt.Fprintf(cr.buf,
+		`<svg xmlns="http://www.w3.org/2000/svg" version="1.1" `+
+			`width="%s" height="%s" viewBox="0 0 %s %s">`+"\n", w, h, w, h)
+}
+
+func (cr SVGCreator) closeDocument() {
+	cr.buf.WriteString("</svg>\n")
+}
+
+func (cr SVGCreator) paintBackground() {
+	fmt.Fprintf(cr.buf, `<rect x="0" y="0" width="%s" height="%s" fill="%s"/>`+"\n",
+		num(cr.mdl.Width), num(cr.mdl.Height), background(cr.mdl).Hex())
+}
+
+func (cr SVGCreator) renderLines() {
+	cr.buf.WriteString(`<g stroke="black" stroke-width="1" fill="none">` + "\n")
+	for _, line := range cr.mdl.Primitives.Lines {
+		fmt.Fprintf(cr.buf, `<line x1="%s" y1="%s" x2="%s" y2="%s"%s%s%s/>`+"\n",
+			num(line.P1.X), num(line.P1.Y), num(line.P2.X), num(line.P2.Y),
+			cr.dashAttribute(&line), lineStyleAttributes(line.Style),
+			originAttributes(line.Origin))
+	}
+	cr.buf.WriteString("</g>\n")
+}
+
+func (cr SVGCreator) renderPolylines() {
+	cr.buf.WriteString(`<g stroke="black" stroke-width="1" fill="none">` + "\n")
+	for _, polyline := range cr.mdl.Primitives.Polylines {
+		fmt.Fprintf(cr.buf, `<polyline points="%s"%s%s/>`+"\n",
+			points(polyline.Vertices), lineStyleAttributes(polyline.Style),
+			originAttributes(polyline.Origin))
+	}
+	cr.buf.WriteString("</g>\n")
+}
+
+func (cr SVGCreator) renderPolygons() {
+	cr.buf.WriteString(`<g fill="black" stroke="none">` + "\n")
+	for _, poly := range cr.mdl.Primitives.FilledPolys {
+		fmt.Fprintf(cr.buf, `<polygon points="%s"%s%s/>`+"\n",
+			points(poly.Vertices), fillStyleAttributes(poly.Style),
+			originAttributes(poly.Origin))
+	}
+	cr.buf.WriteString("</g>\n")
+}
+
+// svgTextAnchor maps horizontal justifications to the values for the SVG
+// text-anchor attribute.
+var svgTextAnchor = map[graphics.Justification]string{
+	graphics.Left:   "start",
+	graphics.Centre: "middle",
+	graphics.Right:  "end",
+}
+
+func (cr SVGCreator) renderText() {
+	cr.buf.WriteString(`<g fill="black" font-family="Go, sans-serif">` + "\n")
+	for _, label := range cr.mdl.Primitives.Labels {
+		// SVG text is positioned by its baseline, whereas the model's anchor
+		// is justified vertically using the font height. We use the same
+		// continuum as the gg library does (see ggJustification), so that
+		// both renderers position text identically.
+		baseline := label.Anchor.Y + ggJustification[label.VJust]*label.FontHeight
+		fmt.Fprintf(cr.buf,
+			`<text x="%s" y="%s" font-size="%s" text-anchor="%s"%s%s>%s</text>`+"\n",
+			num(label.Anchor.X), num(baseline), num(label.FontHeight),
+			svgTextAnchor[label.HJust], textStyleAttributes(label.Style),
+			originAttributes(label.Origin), escape(label.TheString))
+	}
+	cr.buf.WriteString("</g>\n")
+}
+
+// dashAttribute provides the SVG attribute required to make a line dashed,
+// or an empty string for a solid line.
+func (cr SVGCreator) dashAttribute(line *graphics.Line) string {
+	if !line.Dashed {
+		return ""
+	}
+	return fmt.Sprintf(` stroke-dasharray="%s %s"`,
+		num(cr.mdl.DashLineDashLen), num(cr.mdl.DashLineGapLen))
+}
+
+/*
+The style attribute functions provide the SVG attributes that override the
+defaults set on the enclosing group, for the parts of a primitive's style that
+are set. Or an empty string when there are none.
+*/
+
+func lineStyleAttributes(style *graphics.LineStyle) string {
+	if style == nil {
+		return ""
+	}
+	attributes := ""
+	if style.Colour != nil {
+		attributes += fmt.Sprintf(` stroke="%s"`, style.Colour.Hex())
+	}
+	if style.Width != 0 {
+		attributes += fmt.Sprintf(` stroke-width="%s"`, num(style.Width))
+	}
+	return attributes
+}
+
+func fillStyleAttributes(style *graphics.FillStyle) string {
+	if style == nil || style.Colour == nil {
+		return ""
+	}
+	return fmt.Sprintf(` fill="%s"`, style.Colour.Hex())
+}
+
+func textStyleAttributes(style *graphics.TextStyle) string {
+	if style == nil {
+		return ""
+	}
+	attributes := ""
+	if style.Colour != nil {
+		attributes += fmt.Sprintf(` fill="%s"`, style.Colour.Hex())
+	}
+	if style.Bold {
+		attributes += ` font-weight="bold"`
+	}
+	if style.Italic {
+		attributes += ` font-style="italic"`
+	}
+	return attributes
+}
+
+/*
+originAttributes provides data attributes that say where a primitive came
+from, so that scripts in an interactive viewer can find the DSL line for an
+element the user clicks on. Or an empty string when the origin is unknown.
+*/
+func originAttributes(origin graphics.Origin) string {
+	attributes := ""
+	if origin.Kind != "" {
+		attributes += fmt.Sprintf(` data-kind="%s"`, origin.Kind)
+	}
+	if origin.SourceLine != 0 {
+		attributes += fmt.Sprintf(` data-source-line="%d"`, origin.SourceLine)
+	}
+	if origin.SequenceNumber != "" {
+		attributes += fmt.Sprintf(` data-sequence-number="%s"`,
+			escape(origin.SequenceNumber))
+	}
+	return attributes
+}
+
+// num formats a coordinate or length, using the fewest digits that
+// represent the value exactly.
+func num(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// points formats vertices as the value of an SVG points attribute.
+func points(vertices []graphics.Point) string {
+	formatted := []string{}
+	for _, vertex := range vertices {
+		formatted = append(formatted, num(vertex.X)+","+num(vertex.Y))
+	}
+	return strings.Join(formatted, " ")
+}
+
+// escape makes s safe to use as XML character data.
+func escape(s string) string {
+	var sb strings.Builder
+	// EscapeText only fails when the writer does, which a Builder does not.
+	_ = xml.EscapeText(&sb, []byte(s))
+	return sb.String()
+}
@@ -0,0 +1,197 @@
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/peterhoward42/umli/graphics"
+)
+
+func TestThatSavesExampleModelAsSVGForVisualInspection(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	err := NewSVGCreator().Create(&buf, fullCoverageModel())
+	assert.NoError(err)
+	saveAs := filepath.Join(testResultsDir, "example.svg")
+	err = ioutil.WriteFile(saveAs, buf.Bytes(), 0644)
+	assert.NoError(err)
+}
+
+// svgElement is a generic representation of any element in an SVG
+// document, sufficient for the test to inspect what has been rendered.
+type svgElement struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr   `xml:",any,attr"`
+	Text     string       `xml:",chardata"`
+	Children []svgElement `xml:",any"`
+}
+
+func (e svgElement) attr(name string) string {
+	for _, a := range e.Attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// flatten provides e and all its descendants, keyed on element name.
+func (e svgElement) flatten(into map[string][]svgElement) {
+	into[e.XMLName.Local] = append(into[e.XMLName.Local], e)
+	for _, child := range e.Children {
+		child.flatten(into)
+	}
+}
+
+func TestSVGIsWellFormedAndContainsEveryPrimitive(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	err := NewSVGCreator().Create(&buf, fullCoverageModel())
+	assert.NoError(err)
+
+	root := svgElement{}
+	err = xml.Unmarshal(buf.Bytes(), &root)
+	assert.NoError(err)
+	assert.Equal("svg", root.XMLName.Local)
+	assert.Equal("2000", root.attr("width"))
+	assert.Equal("1000", root.attr("height"))
+
+	elements := map[string][]svgElement{}
+	root.flatten(elements)
+	assert.Len(elements["line"], 4)
+	assert.Len(elements["polygon"], 1)
+	assert.Len(elements["polyline"], 1)
+	assert.Len(elements["text"], 3)
+
+	// The example model's third line is dashed, and should adopt the
+	// model's dash settings.
+	assert.Equal("", elements["line"][0].attr("stroke-dasharray"))
+	assert.Equal("45 20", elements["line"][2].attr("stroke-dasharray"))
+
+	assert.Equal("1000,100 1045,145 1000,145",
+		elements["polygon"][0].attr("points"))
+	assert.Equal("100,100 55,122.5 100,145",
+		elements["polyline"][0].attr("points"))
+}
+
+func TestSVGTextJustification(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	err := NewSVGCreator().Create(&buf, fullCoverageModel())
+	assert.NoError(err)
+
+	root := svgElement{}
+	err = xml.Unmarshal(buf.Bytes(), &root)
+	assert.NoError(err)
+	elements := map[string][]svgElement{}
+	root.flatten(elements)
+	texts := elements["text"]
+
+	// The example model's font height is 45, so the baseline should sit
+	// on the anchor for bottom justification, and be offset below it by
+	// a half, or whole font height, for centre and top justification.
+
+	// LeftBot at (100, 145)
+	assert.Equal("LeftBot", texts[0].Text)
+	assert.Equal("start", texts[0].attr("text-anchor"))
+	assert.Equal("100", texts[0].attr("x"))
+	assert.Equal("145", texts[0].attr("y"))
+
+	// CtrCtr at (550, 122.5)
+	assert.Equal("middle", texts[1].attr("text-anchor"))
+	assert.Equal("145", texts[1].attr("y"))
+
+	// RightTop at (1000, 100)
+	assert.Equal("end", texts[2].attr("text-anchor"))
+	assert.Equal("145", texts[2].attr("y"))
+	assert.Equal("45", texts[2].attr("font-size"))
+}
+
+func TestSVGEscapesLabelText(t *testing.T) {
+	assert := assert.New(t)
+	mdl := fullCoverageModel()
+	mdl.Primitives.Labels[0].TheString = "a < b & c"
+	var buf bytes.Buffer
+	err := NewSVGCreator().Create(&buf, mdl)
+	assert.NoError(err)
+	assert.True(strings.Contains(buf.String(), "a &lt; b &amp; c"))
+}
+
+func TestSVGHonoursStyles(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	err := NewSVGCreator().Create(&buf, styledModel())
+	assert.NoError(err)
+
+	root := svgElement{}
+	err = xml.Unmarshal(buf.Bytes(), &root)
+	assert.NoError(err)
+	elements := map[string][]svgElement{}
+	root.flatten(elements)
+
+	lines := elements["line"]
+	assert.Equal("#ff0000", lines[0].attr("stroke"))
+	assert.Equal("5", lines[0].attr("stroke-width"))
+	assert.Equal("", lines[1].attr("stroke"))
+
+	assert.Equal("#0000ff", elements["polygon"][0].attr("fill"))
+	assert.Equal("#008000", elements["polyline"][0].attr("stroke"))
+	assert.Equal("5", elements["polyline"][0].attr("stroke-width"))
+
+	texts := elements["text"]
+	assert.Equal("#008000", texts[0].attr("fill"))
+	assert.Equal("bold", texts[0].attr("font-weight"))
+	assert.Equal("", texts[0].attr("font-style"))
+	assert.Equal("", texts[1].attr("fill"))
+	assert.Equal("italic", texts[1].attr("font-style"))
+	assert.Equal("bold", texts[2].attr("font-weight"))
+	assert.Equal("italic", texts[2].attr("font-style"))
+}
+
+func TestSVGCarriesTheOriginsOfPrimitives(t *testing.T) {
+	assert := assert.New(t)
+	mdl := fullCoverageModel()
+	prims := mdl.Primitives
+	prims.Lines[0].Origin = graphics.Origin{
+		Kind: graphics.InteractionLine, SourceLine: 7, SequenceNumber: "[02]"}
+	prims.FilledPolys[0].Origin = graphics.Origin{Kind: graphics.ArrowHead}
+	prims.Labels[0].Origin = graphics.Origin{SourceLine: 3}
+	var buf bytes.Buffer
+	err := NewSVGCreator().Create(&buf, mdl)
+	assert.NoError(err)
+
+	root := svgElement{}
+	err = xml.Unmarshal(buf.Bytes(), &root)
+	assert.NoError(err)
+	elements := map[string][]svgElement{}
+	root.flatten(elements)
+
+	assert.Equal("InteractionLine", elements["line"][0].attr("data-kind"))
+	assert.Equal("7", elements["line"][0].attr("data-source-line"))
+	assert.Equal("[02]", elements["line"][0].attr("data-sequence-number"))
+	assert.Equal("", elements["line"][1].attr("data-kind"))
+	assert.Equal("ArrowHead", elements["polygon"][0].attr("data-kind"))
+	assert.Equal("", elements["polygon"][0].attr("data-source-line"))
+	assert.Equal("3", elements["text"][0].attr("data-source-line"))
+	assert.NotContains(buf.String(), `data-source-line="0"`)
+}
+
+func TestSVGDrawsLinesOverFills(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	err := NewSVGCreator().Create(&buf, boxFillModel())
+	assert.NoError(err)
+
+	// Later elements are painted over earlier ones.
+	document := buf.String()
+	polygon := strings.Index(document, "<polygon")
+	line := strings.Index(document, "<line")
+	assert.True(polygon >= 0 && line >= 0)
+	assert.True(polygon < line)
+}
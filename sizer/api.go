@@ -11,10 +11,17 @@ package sizer
 // Sizer defines the contract for a thing that can provide sizes for diagram
 // elements. E.g. the width of an activity box, or the padding required below
 // and interaction line label.
+//
+// Get is for use once a Sizer has been checked with Validate, and so panics
+// when asked for a size it cannot provide. Lookup does not.
 type Sizer interface {
 
 	// Get returns the size specified by propertyName, or panics
 	// if the property is not recognized. (It is by definition a programming
 	// error if this happens)
 	Get(propertyName string) (size float64)
+
+	// Lookup returns the size specified by propertyName, or ok false
+	// if the property is not recognized.
+	Lookup(propertyName string) (size float64, ok bool)
 }
@@ -1,6 +1,9 @@
 package sizer
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+)
 
 /*
 CompleteSizer implements the Sizer interface by providing an implementation
@@ -19,6 +22,7 @@ Where
 */
 type CompleteSizer struct {
 	fontHeight float64
+	overrides  Overrides
 }
 
 // Make sure CompleteSizer implements Sizer at compile time.
@@ -33,15 +37,61 @@ func NewCompleteSizer(fontHeight float64) *CompleteSizer {
 	}
 }
 
+// NewCompleteSizerWithOverrides provides a CompleteSizer that uses the
+// overrides given in place of its built in values. It returns an error if any of
+// the overrides are invalid. (See ValidateOverride).
+func NewCompleteSizerWithOverrides(fontHeight float64,
+	overrides Overrides) (*CompleteSizer, error) {
+	if err := overrides.Validate(); err != nil {
+		return nil, fmt.Errorf("overrides.Validate: %w", err)
+	}
+	return &CompleteSizer{
+		fontHeight: fontHeight,
+		overrides:  overrides,
+	}, nil
+}
+
 // Get returns the size specified by propertyName, or panics
 // if the property is not recognized.
 func (s CompleteSizer) Get(propertyName string) (size float64) {
-	v, ok := table[propertyName]
+	v, ok := s.Lookup(propertyName)
 	if !ok {
 		msg := fmt.Sprintf("Sizer could not look up the key: <%s>", propertyName)
 		panic(msg)
 	}
-	return v * s.fontHeight
+	return v
+}
+
+// Lookup returns the size specified by propertyName, or ok false
+// if the property is not recognized.
+func (s CompleteSizer) Lookup(propertyName string) (size float64, ok bool) {
+	v, isProportion := proportions[propertyName]
+	if !isProportion {
+		if v, ok = table[propertyName]; !ok {
+			return 0, false
+		}
+	}
+	if override, ok := s.overrides[propertyName]; ok {
+		v = override
+	}
+	if isProportion {
+		return v, true
+	}
+	return v * s.fontHeight, true
+}
+
+// RequiredKeys provides the keys the diag package requires a Sizer to
+// provide. (Which are the keys CompleteSizer provides). They are sorted.
+func RequiredKeys() []string {
+	keys := []string{}
+	for key := range table {
+		keys = append(keys, key)
+	}
+	for key := range proportions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
 }
 
 var table = map[string]float64{
@@ -56,21 +106,31 @@ var table = map[string]float64{
 	"FrameTitleTextPadT": 0.5,
 	"FrameTitleTextPadB": 1.0,
 	"FrameTitleTextPadL": 1.0,
+	"FrameTitleTextPadR": 1.0,
 	"FrameTitleRectPadB": 1.0,
 
 	// Lifeline title boxes
 	"TitleBoxLabelPadT":          0.25,
 	"TitleBoxLabelPadB":          1.0,
+	"TitleBoxLabelPadLR":         1.0,
 	"IdealLifelineTitleBoxWidth": 15.0,
 	"TitleBoxPadB":               1.5,
+	"ParticipantIconSize":        3.0,
+	"ParticipantIconPadB":        0.5,
+
+	// Lifeline destruction
+	"DestroyMarkSize": 1.5,
+	"DestroyMarkPadB": 0.5,
 
 	// Interaction lines
 	"ArrowLen":                1.5,
 	"ArrowWidth":              0.5,
 	"InteractionLinePadB":     0.5,
 	"InteractionLineTextPadB": 0.5,
+	"InteractionLabelPadLR":   1.0, // for variable lifeline spacing
 	"SelfLoopHeight":          3.0,
-	"SelfLoopWidthFactor":     0.7, // proportion of lifeline pitch
+	"FoundLostDotRadius":      0.4,
+	"FoundLostDotPadLR":       1.0, // from the frame
 
 	// Dashes
 	"DashLineDashLen": 0.5,
@@ -85,4 +145,36 @@ var table = map[string]float64{
 	// Lifelines
 	"LifelinePadB":         0.5,
 	"MinLifelineSegLength": 0.5,
+
+	// Notes
+	"NotePadT":      0.5,
+	"NotePadB":      0.5,
+	"NoteTextPadTB": 0.5,
+	"NoteTextPadLR": 0.5,
+	"NoteDogEar":    1.0,
+	"NoteOverhang":  1.5, // beyond the outermost lifelines spanned
+	"NoteBesideGap": 0.5, // from the activity box
+
+	// Combined fragments (alt, loop etc.)
+	"FragmentPadT":          0.5,
+	"FragmentPadB":          0.5,
+	"FragmentBottomPadB":    1.0,
+	"FragmentPadLR":         2.0, // beyond the outermost lifelines spanned
+	"FragmentNestingInset":  0.5,
+	"FragmentTabWidth":      5.0,
+	"FragmentTabCornerCut":  0.5,
+	"FragmentTabLabelPadL":  0.5,
+	"FragmentTabLabelPadTB": 0.25,
+	"FragmentHeaderPadB":    0.5,
+	"FragmentGuardPadL":     0.5,
+	"FragmentElsePadT":      0.5,
+	"FragmentElsePadB":      0.5,
+	"FragmentSelfLoopPadR":  0.5,
+}
+
+// proportions holds the values that are not lengths, and therefore must not
+// be scaled by the font height.
+var proportions = map[string]float64{
+	"SelfLoopWidthFactor": 0.7, // proportion of lifeline pitch
+	"NoteWidthFactor":     0.6, // proportion of lifeline pitch
 }
@@ -0,0 +1,94 @@
+package sizer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLengthsAreScaledByFontHeightButProportionsAreNot(t *testing.T) {
+	assert := assert.New(t)
+	sizer := NewCompleteSizer(20)
+	assert.InDelta(60.0, sizer.Get("SelfLoopHeight"), 0.001)
+	assert.InDelta(0.7, sizer.Get("SelfLoopWidthFactor"), 0.001)
+}
+
+func TestLookupReportsUnknownKeysInsteadOfPanicking(t *testing.T) {
+	assert := assert.New(t)
+	sizer := NewCompleteSizer(20)
+	v, ok := sizer.Lookup("SelfLoopHeight")
+	assert.True(ok)
+	assert.InDelta(60.0, v, 0.001)
+	_, ok = sizer.Lookup("NoSuchKey")
+	assert.False(ok)
+}
+
+func TestCompleteSizerProvidesEveryRequiredKey(t *testing.T) {
+	assert := assert.New(t)
+	assert.NoError(Validate(NewCompleteSizer(20), RequiredKeys()))
+	assert.Contains(RequiredKeys(), "SelfLoopWidthFactor")
+}
+
+func TestValidateNamesEveryMissingKey(t *testing.T) {
+	assert := assert.New(t)
+	sizer := NewLiteralSizer(map[string]float64{"B": 1})
+	err := Validate(sizer, []string{"A", "B", "C"})
+	assert.EqualError(err, "The sizer cannot provide these sizes: A, C")
+	missing, ok := err.(*MissingKeysError)
+	assert.True(ok)
+	assert.Equal([]string{"A", "C"}, missing.Keys)
+}
+
+func TestOverridesReplaceBuiltInSizes(t *testing.T) {
+	assert := assert.New(t)
+	sizer, err := NewCompleteSizerWithOverrides(20, Overrides{
+		"SelfLoopHeight":      4,
+		"SelfLoopWidthFactor": 0.5,
+	})
+	assert.NoError(err)
+	// Overridden lengths are still multiples of the font height.
+	assert.InDelta(80.0, sizer.Get("SelfLoopHeight"), 0.001)
+	assert.InDelta(0.5, sizer.Get("SelfLoopWidthFactor"), 0.001)
+	assert.InDelta(30.0, sizer.Get("ArrowLen"), 0.001)
+}
+
+func TestOverridesAreValidated(t *testing.T) {
+	assert := assert.New(t)
+	_, err := NewCompleteSizerWithOverrides(20, Overrides{"Bogus": 1})
+	assert.EqualError(err, "overrides.Validate: Unknown size: Bogus")
+
+	assert.EqualError(ValidateOverride("NoteDogEar", -1),
+		"Size <NoteDogEar> must be between 0 and 50 (font heights)")
+	assert.EqualError(ValidateOverride("NoteDogEar", 51),
+		"Size <NoteDogEar> must be between 0 and 50 (font heights)")
+	assert.EqualError(ValidateOverride("NoteWidthFactor", 1.5),
+		"Size <NoteWidthFactor> is a proportion, so must be more than 0 "+
+			"and at most 1")
+	assert.NoError(ValidateOverride("NoteDogEar", 0))
+	assert.NoError(ValidateOverride("ArrowLen", 50))
+}
+
+func TestPaddingOverridesHaveTighterBounds(t *testing.T) {
+	assert := assert.New(t)
+	assert.EqualError(ValidateOverride("TitleBoxLabelPadLR", 50),
+		"Size <TitleBoxLabelPadLR> must be between 0 and 5 (font heights)")
+	assert.EqualError(ValidateOverride("NoteTextPadLR", -0.5),
+		"Size <NoteTextPadLR> must be between 0 and 5 (font heights)")
+	assert.NoError(ValidateOverride("TitleBoxLabelPadLR", 5))
+	assert.NoError(ValidateOverride("NoteTextPadLR", 0))
+	for key := range maxOverrides {
+		assert.True(KnownKey(key), key)
+	}
+}
+
+func TestSizesOfDrawnThingsMustBeMoreThanZero(t *testing.T) {
+	assert := assert.New(t)
+	for _, key := range []string{"ArrowLen", "ArrowWidth", "ActivityBoxWidth"} {
+		assert.EqualError(ValidateOverride(key, 0),
+			"Size <"+key+"> must be more than 0 and at most 50 (font heights)")
+		assert.NoError(ValidateOverride(key, 0.1))
+	}
+	for key := range mustBePositive {
+		assert.True(KnownKey(key), key)
+	}
+}
@@ -24,10 +24,17 @@ func NewLiteralSizer(m map[string]float64) *LiteralSizer {
 // Get returns the size specified by propertyName, or panics
 // if the property is not recognized.
 func (s LiteralSizer) Get(propertyName string) (size float64) {
-	v, ok := s.m[propertyName]
+	v, ok := s.Lookup(propertyName)
 	if !ok {
 		msg := fmt.Sprintf("Sizer could not look up the key: <%s>", propertyName)
 		panic(msg)
 	}
 	return v
 }
+
+// Lookup returns the size specified by propertyName, or ok false
+// if the property is not recognized.
+func (s LiteralSizer) Lookup(propertyName string) (size float64, ok bool) {
+	v, ok := s.m[propertyName]
+	return v, ok
+}
@@ -0,0 +1,105 @@
+package sizer
+
+import (
+	"fmt"
+	"sort"
+)
+
+/*
+Overrides holds values to use in place of some of CompleteSizer's built in
+sizes, keyed on the same names. They are expressed in the same way as the built
+in sizes, i.e. lengths are multiples of the font height, and proportions are
+proportions.
+*/
+type Overrides map[string]float64
+
+// maxLengthOverride is the largest length an override may specify, in
+// font heights. It is generous, but prevents absurd diagrams.
+const maxLengthOverride = 50.0
+
+// maxOverrides are tighter limits than maxLengthOverride, for the paddings
+// either side of text in a box of limited width. Larger paddings would leave
+// no room for the text.
+var maxOverrides = map[string]float64{
+	"FragmentGuardPadL":     5.0,
+	"FragmentTabLabelPadL":  5.0,
+	"FrameTitleTextPadL":    5.0,
+	"FrameTitleTextPadR":    5.0,
+	"InteractionLabelPadLR": 5.0,
+	"NoteTextPadLR":         5.0,
+	"TitleBoxLabelPadLR":    5.0,
+}
+
+// mustBePositive are the sizes of things that are drawn, and which would be
+// degenerate if they were zero.
+var mustBePositive = map[string]bool{
+	"ActivityBoxWidth":           true,
+	"ArrowLen":                   true,
+	"ArrowWidth":                 true,
+	"DashLineDashLen":            true,
+	"DestroyMarkSize":            true,
+	"FoundLostDotRadius":         true,
+	"FragmentTabWidth":           true,
+	"IdealLifelineTitleBoxWidth": true,
+	"ParticipantIconSize":        true,
+	"SelfLoopHeight":             true,
+}
+
+// KnownKey returns true if key is one of the sizes CompleteSizer provides,
+// and which can therefore be overridden.
+func KnownKey(key string) bool {
+	_, isLength := table[key]
+	_, isProportion := proportions[key]
+	return isLength || isProportion
+}
+
+// ValidateOverride checks that key can be overridden, and that value is in
+// range for it. Proportions must be more than 0 and at most 1. Lengths may be
+// from 0 to maxLengthOverride, (or the tighter limit in maxOverrides), except
+// that those in mustBePositive may not be 0.
+func ValidateOverride(key string, value float64) error {
+	if _, ok := proportions[key]; ok {
+		if value <= 0 || value > 1 {
+			return fmt.Errorf(
+				"Size <%s> is a proportion, so must be more than 0 and at most 1",
+				key)
+		}
+		return nil
+	}
+	if _, ok := table[key]; !ok {
+		return fmt.Errorf("Unknown size: %s", key)
+	}
+	limit := maxLengthOverride
+	if max, ok := maxOverrides[key]; ok {
+		limit = max
+	}
+	if mustBePositive[key] {
+		if value <= 0 || value > limit {
+			return fmt.Errorf(
+				"Size <%s> must be more than 0 and at most %v (font heights)",
+				key, limit)
+		}
+		return nil
+	}
+	if value < 0 || value > limit {
+		return fmt.Errorf("Size <%s> must be between 0 and %v (font heights)",
+			key, limit)
+	}
+	return nil
+}
+
+// Validate checks every override using ValidateOverride. It reports the
+// first problem, in key order.
+func (o Overrides) Validate() error {
+	keys := []string{}
+	for key := range o {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := ValidateOverride(key, o[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
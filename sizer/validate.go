@@ -0,0 +1,36 @@
+package sizer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MissingKeysError is the error Validate returns when a Sizer cannot
+// provide some of the sizes required of it.
+type MissingKeysError struct {
+	Keys []string
+}
+
+func (e *MissingKeysError) Error() string {
+	return fmt.Sprintf("The sizer cannot provide these sizes: %s",
+		strings.Join(e.Keys, ", "))
+}
+
+/*
+Validate checks that s can provide every one of the keys given. (Typically
+those from RequiredKeys). If it cannot, it returns a *MissingKeysError that
+names all of those missing. Having passed, it is safe to use s.Get for those
+keys without fear of it panicking.
+*/
+func Validate(s Sizer, keys []string) error {
+	missing := []string{}
+	for _, key := range keys {
+		if _, ok := s.Lookup(key); !ok {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) != 0 {
+		return &MissingKeysError{missing}
+	}
+	return nil
+}
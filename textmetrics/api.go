@@ -0,0 +1,29 @@
+/*
+Package textmetrics is concerned with measuring how much space text will
+occupy when it is rendered.
+
+It lets the diag package make layout decisions that respect the real extent of
+labels, (rather than estimating it from the font height), while remaining
+oblivious to which font will actually be used by a renderer.
+*/
+package textmetrics
+
+// Measurer defines the contract for a thing that can measure text.
+type Measurer interface {
+
+	// Width returns the width that the string s will occupy when rendered
+	// at the given font height.
+	Width(s string, fontHeight float64) float64
+}
+
+// MaxWidth returns the width of the widest of the given strings when
+// rendered at the given font height. (Or zero when there are none).
+func MaxWidth(m Measurer, strs []string, fontHeight float64) float64 {
+	widest := 0.0
+	for _, s := range strs {
+		if w := m.Width(s, fontHeight); w > widest {
+			widest = w
+		}
+	}
+	return widest
+}
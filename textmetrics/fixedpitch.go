@@ -0,0 +1,27 @@
+package textmetrics
+
+import "unicode/utf8"
+
+/*
+FixedPitchMeasurer implements the Measurer interface by pretending that every
+character has the same width - a fixed proportion of the font height. It is
+useful for testing because the widths it produces are easy to reason about.
+*/
+type FixedPitchMeasurer struct {
+	charWidthFactor float64
+}
+
+// Make sure FixedPitchMeasurer implements Measurer at compile time.
+var _ Measurer = FixedPitchMeasurer{}
+
+// NewFixedPitchMeasurer provides a FixedPitchMeasurer for which each
+// character is charWidthFactor times the font height wide.
+func NewFixedPitchMeasurer(charWidthFactor float64) *FixedPitchMeasurer {
+	return &FixedPitchMeasurer{charWidthFactor}
+}
+
+// Width returns the width that the string s will occupy when rendered
+// at the given font height.
+func (m FixedPitchMeasurer) Width(s string, fontHeight float64) float64 {
+	return float64(utf8.RuneCountInString(s)) * m.charWidthFactor * fontHeight
+}
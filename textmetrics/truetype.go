@@ -0,0 +1,90 @@
+package textmetrics
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+/*
+TrueTypeMeasurer implements the Measurer interface by measuring text using a
+truetype font. It creates its font faces in the same way as the render
+package, so the widths it reports match those of the rendered text.
+
+It is safe for concurrent use.
+*/
+type TrueTypeMeasurer struct {
+	font  *truetype.Font
+	mutex sync.Mutex
+	faces map[float64]font.Face // Keyed on font height.
+}
+
+// Make sure TrueTypeMeasurer implements Measurer at compile time.
+var _ Measurer = &TrueTypeMeasurer{}
+
+// NewTrueTypeMeasurer provides a TrueTypeMeasurer ready to use.
+func NewTrueTypeMeasurer(f *truetype.Font) *TrueTypeMeasurer {
+	return &TrueTypeMeasurer{
+		font:  f,
+		faces: map[float64]font.Face{},
+	}
+}
+
+// Width returns the width that the string s will occupy when rendered
+// at the given font height.
+func (m *TrueTypeMeasurer) Width(s string, fontHeight float64) float64 {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	face, ok := m.faces[fontHeight]
+	if !ok {
+		// As in the render package, the font size in points is taken to
+		// be the same as the font height in model units.
+		face = truetype.NewFace(m.font, &truetype.Options{Size: fontHeight})
+		m.faces[fontHeight] = face
+	}
+	advance := font.MeasureString(face, s)
+	return float64(advance) / 64
+}
+
+// The default font, and a measurer for it are created lazily and once only,
+// because parsing the font is relatively expensive.
+var (
+	defaultOnce     sync.Once
+	defaultFont     *truetype.Font
+	defaultMeasurer *TrueTypeMeasurer
+	defaultErr      error
+)
+
+func initDefault() {
+	defaultFont, defaultErr = truetype.Parse(goregular.TTF)
+	if defaultErr != nil {
+		defaultErr = fmt.Errorf("truetype.Parse: %v", defaultErr)
+		return
+	}
+	defaultMeasurer = NewTrueTypeMeasurer(defaultFont)
+}
+
+// DefaultFont provides the font that umli uses unless told otherwise. (The Go
+// regular font).
+func DefaultFont() (*truetype.Font, error) {
+	defaultOnce.Do(initDefault)
+	return defaultFont, defaultErr
+}
+
+// DefaultFontData provides the TrueType font file contents that DefaultFont
+// is parsed from. (For renderers that embed the font in their output).
+func DefaultFontData() []byte {
+	return goregular.TTF
+}
+
+// Default provides a Measurer for the DefaultFont.
+func Default() (Measurer, error) {
+	defaultOnce.Do(initDefault)
+	if defaultErr != nil {
+		return nil, defaultErr
+	}
+	return defaultMeasurer, nil
+}
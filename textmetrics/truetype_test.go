@@ -0,0 +1,44 @@
+package textmetrics
+
+import (
+	"testing"
+
+	"github.com/fogleman/gg"
+	"github.com/golang/freetype/truetype"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrueTypeWidthsMatchThoseUsedWhenRendering(t *testing.T) {
+	assert := assert.New(t)
+	f, err := DefaultFont()
+	assert.NoError(err)
+	m, err := Default()
+	assert.NoError(err)
+
+	// The render package uses gg to draw text, with a face made like this.
+	dc := gg.NewContext(100, 100)
+	dc.SetFontFace(truetype.NewFace(f, &truetype.Options{Size: 20}))
+	// (gg rounds its measurements down to whole pixels).
+	for _, s := range []string{"", "i", "Hello World", "WWWW"} {
+		expected, _ := dc.MeasureString(s)
+		assert.InDelta(expected, m.Width(s, 20), 1.0)
+	}
+}
+
+func TestTrueTypeWidthsScaleWithFontHeight(t *testing.T) {
+	assert := assert.New(t)
+	m, err := Default()
+	assert.NoError(err)
+	small := m.Width("Hello World", 10)
+	large := m.Width("Hello World", 40)
+	assert.True(small > 0)
+	assert.InDelta(4.0, large/small, 0.1)
+	assert.True(m.Width("WWWW", 20) > m.Width("iiii", 20))
+}
+
+func TestMaxWidth(t *testing.T) {
+	assert := assert.New(t)
+	m := NewFixedPitchMeasurer(0.5)
+	assert.Equal(15.0, MaxWidth(m, []string{"ab", "abc", "a"}, 10))
+	assert.Equal(0.0, MaxWidth(m, []string{}, 10))
+}
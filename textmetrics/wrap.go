@@ -0,0 +1,70 @@
+package textmetrics
+
+import (
+	"strings"
+	"unicode"
+)
+
+/*
+Wrap breaks each of the given lines of text into as many lines as are needed
+so that none is wider than maxWidth when rendered at the given font height.
+It breaks lines only between words, so a single word that is too wide on its
+own is given a line of its own, (and overruns).
+
+The lines given are always kept separate - i.e. the line breaks they imply are
+honoured. Lines that fit already are left exactly as they are, and the
+spacing between the words that stay together on a line is kept too. So are
+empty lines, and all lines when there is no room at all, (maxWidth <= 0).
+*/
+func Wrap(m Measurer, lines []string, fontHeight float64,
+	maxWidth float64) []string {
+	wrapped := []string{}
+	for _, line := range lines {
+		words := spacedWords(line)
+		if len(words) == 0 || maxWidth <= 0 ||
+			m.Width(line, fontHeight) <= maxWidth {
+			wrapped = append(wrapped, line)
+			continue
+		}
+		current := words[0].space + words[0].word
+		for _, w := range words[1:] {
+			candidate := current + w.space + w.word
+			if w.word != "" && m.Width(candidate, fontHeight) > maxWidth {
+				wrapped = append(wrapped, current)
+				current = w.word
+				continue
+			}
+			current = candidate
+		}
+		wrapped = append(wrapped, current)
+	}
+	return wrapped
+}
+
+// spacedWord is a word, along with the white space that precedes it.
+type spacedWord struct {
+	space string
+	word  string
+}
+
+// spacedWords splits line into its words, keeping the white space before
+// each. Any white space at the end of the line is given an empty word.
+func spacedWords(line string) []spacedWord {
+	words := []spacedWord{}
+	for len(line) > 0 {
+		wordStart := strings.IndexFunc(line, func(r rune) bool {
+			return !unicode.IsSpace(r)
+		})
+		if wordStart < 0 {
+			return append(words, spacedWord{line, ""})
+		}
+		wordEnd := strings.IndexFunc(line[wordStart:], unicode.IsSpace)
+		if wordEnd < 0 {
+			wordEnd = len(line) - wordStart
+		}
+		words = append(words,
+			spacedWord{line[:wordStart], line[wordStart : wordStart+wordEnd]})
+		line = line[wordStart+wordEnd:]
+	}
+	return words
+}
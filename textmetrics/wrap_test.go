@@ -0,0 +1,64 @@
+package textmetrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapBreaksBetweenWordsAndHonoursExistingLines(t *testing.T) {
+	assert := assert.New(t)
+	m := NewFixedPitchMeasurer(1.0)
+
+	// With a font height of one, every character is one unit wide.
+	wrapped := Wrap(m, []string{
+		"the quick brown fox",
+		"",
+		"jumps  over",
+		"extraordinarily lazy dogs",
+	}, 1.0, 10.0)
+	assert.Equal([]string{
+		"the quick",
+		"brown fox",
+		"",
+		"jumps",
+		"over",
+		"extraordinarily",
+		"lazy dogs",
+	}, wrapped)
+}
+
+func TestWrapLeavesLinesThatFitAlone(t *testing.T) {
+	assert := assert.New(t)
+	m := NewFixedPitchMeasurer(0.5)
+	lines := []string{"foo bar", "baz"}
+	assert.Equal(lines, Wrap(m, lines, 10.0, 100.0))
+}
+
+func TestWrapKeepsTheSpacingBetweenWords(t *testing.T) {
+	assert := assert.New(t)
+	m := NewFixedPitchMeasurer(1.0)
+	wrapped := Wrap(m, []string{
+		"a  b\tc",
+		"  indented  text that  is long",
+	}, 1.0, 14.0)
+	assert.Equal([]string{
+		"a  b\tc",
+		"  indented",
+		"text that  is",
+		"long",
+	}, wrapped)
+}
+
+/*
+Regression test. Lifeline titles include an empty line, and are wrapped to a
+width that goes negative when the title boxes get very narrow. Which used to
+make Wrap panic.
+*/
+func TestWrapLeavesLinesAloneWhenThereIsNoRoom(t *testing.T) {
+	assert := assert.New(t)
+	m := NewFixedPitchMeasurer(1.0)
+	lines := []string{"", "foo bar", "  "}
+	assert.Equal(lines, Wrap(m, lines, 1.0, -5.0))
+	assert.Equal(lines, Wrap(m, lines, 1.0, 0.0))
+}
@@ -0,0 +1,118 @@
+/*
+Package theme provides the named colour schemes that a diagram can be drawn
+in. For example "dark".
+
+A Theme says how to style each of the roles that the graphics in a diagram
+play - like the frame, or the activity boxes. It is the diag package that
+knows which role each graphics primitive plays, and so it is the diag package
+that applies a theme. Renderers need know nothing about themes, because they
+simply honour the styles of the primitives they draw, and the model's
+background colour.
+*/
+package theme
+
+import (
+	"sort"
+
+	"github.com/peterhoward42/umli/graphics"
+)
+
+/*
+Theme is a colour scheme for a diagram. Every field is optional, and those
+that are nil leave the corresponding graphics in the renderer's default style.
+Which is black on white.
+
+The styles a theme points to are shared by every diagram drawn with it, so
+treat them as read-only.
+*/
+type Theme struct {
+	Name            string
+	Background      *graphics.Colour
+	Line            *graphics.LineStyle // Lines not covered by those below.
+	Arrow           *graphics.FillStyle // Filled arrow heads.
+	OpenArrow       *graphics.LineStyle // Open arrow heads.
+	ActivityBoxFill *graphics.FillStyle // The inside of activity boxes.
+	Frame           *graphics.LineStyle // The frame and the title box.
+	Text            *graphics.TextStyle // All text.
+}
+
+// The names of the themes.
+const (
+	DefaultName         = "default"
+	DarkName            = "dark"
+	HighContrastName    = "high-contrast"
+	MonochromePrintName = "monochrome-print"
+	PastelName          = "pastel"
+)
+
+// Default is the theme used unless another is asked for. It leaves
+// everything in the renderer's default style.
+var Default = Theme{Name: DefaultName}
+
+// themes holds all the themes, keyed on their name.
+var themes = map[string]Theme{
+	DefaultName: Default,
+	DarkName: {
+		Name:            DarkName,
+		Background:      rgb(0x1e, 0x1e, 0x1e),
+		Line:            &graphics.LineStyle{Colour: rgb(0xc8, 0xc8, 0xc8)},
+		Arrow:           &graphics.FillStyle{Colour: rgb(0xc8, 0xc8, 0xc8)},
+		OpenArrow:       &graphics.LineStyle{Colour: rgb(0xc8, 0xc8, 0xc8)},
+		ActivityBoxFill: &graphics.FillStyle{Colour: rgb(0x3a, 0x3d, 0x41)},
+		Frame:           &graphics.LineStyle{Colour: rgb(0x80, 0x80, 0x80)},
+		Text:            &graphics.TextStyle{Colour: rgb(0xf0, 0xf0, 0xf0)},
+	},
+	HighContrastName: {
+		Name:            HighContrastName,
+		Background:      rgb(0x00, 0x00, 0x00),
+		Line:            &graphics.LineStyle{Colour: rgb(0xff, 0xff, 0xff), Width: 2},
+		Arrow:           &graphics.FillStyle{Colour: rgb(0xff, 0xff, 0x00)},
+		OpenArrow:       &graphics.LineStyle{Colour: rgb(0xff, 0xff, 0x00), Width: 2},
+		ActivityBoxFill: &graphics.FillStyle{Colour: rgb(0x00, 0x00, 0x00)},
+		Frame:           &graphics.LineStyle{Colour: rgb(0xff, 0xff, 0x00), Width: 3},
+		Text: &graphics.TextStyle{
+			Colour: rgb(0xff, 0xff, 0xff), Bold: true},
+	},
+	MonochromePrintName: {
+		Name:            MonochromePrintName,
+		Background:      rgb(0xff, 0xff, 0xff),
+		Line:            &graphics.LineStyle{Colour: rgb(0x00, 0x00, 0x00)},
+		Arrow:           &graphics.FillStyle{Colour: rgb(0x00, 0x00, 0x00)},
+		OpenArrow:       &graphics.LineStyle{Colour: rgb(0x00, 0x00, 0x00)},
+		ActivityBoxFill: &graphics.FillStyle{Colour: rgb(0xe6, 0xe6, 0xe6)},
+		Frame:           &graphics.LineStyle{Colour: rgb(0x00, 0x00, 0x00), Width: 2},
+		Text:            &graphics.TextStyle{Colour: rgb(0x00, 0x00, 0x00)},
+	},
+	PastelName: {
+		Name:            PastelName,
+		Background:      rgb(0xfd, 0xf8, 0xf0),
+		Line:            &graphics.LineStyle{Colour: rgb(0x6b, 0x7a, 0x8f)},
+		Arrow:           &graphics.FillStyle{Colour: rgb(0xd9, 0x8b, 0x9b)},
+		OpenArrow:       &graphics.LineStyle{Colour: rgb(0xd9, 0x8b, 0x9b)},
+		ActivityBoxFill: &graphics.FillStyle{Colour: rgb(0xcf, 0xe6, 0xf5)},
+		Frame:           &graphics.LineStyle{Colour: rgb(0xa8, 0xc5, 0xa0), Width: 2},
+		Text:            &graphics.TextStyle{Colour: rgb(0x4a, 0x4a, 0x5a)},
+	},
+}
+
+// Named provides the theme of the given name, or false if there is no such
+// theme.
+func Named(name string) (Theme, bool) {
+	theme, ok := themes[name]
+	return theme, ok
+}
+
+// Names provides the names of all the themes, in alphabetical order.
+func Names() []string {
+	names := []string{}
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// rgb makes a colour for the theme definitions above.
+func rgb(r, g, b uint8) *graphics.Colour {
+	return &graphics.Colour{R: r, G: g, B: b}
+}
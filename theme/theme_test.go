@@ -0,0 +1,47 @@
+package theme
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamesAreSortedAndEachNamesItsTheme(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal([]string{"dark", "default", "high-contrast",
+		"monochrome-print", "pastel"}, Names())
+	for _, name := range Names() {
+		thm, ok := Named(name)
+		assert.True(ok)
+		assert.Equal(name, thm.Name)
+	}
+	_, ok := Named("neon")
+	assert.False(ok)
+}
+
+func TestTheDefaultThemeLeavesEverythingToTheRenderer(t *testing.T) {
+	assert := assert.New(t)
+	thm, _ := Named(DefaultName)
+	assert.Equal(Theme{Name: "default"}, thm)
+	assert.Equal(Default, thm)
+}
+
+func TestTheOtherThemesDefineEveryRole(t *testing.T) {
+	assert := assert.New(t)
+	for _, name := range Names() {
+		if name == DefaultName {
+			continue
+		}
+		thm, _ := Named(name)
+		assert.NotNil(thm.Background, name)
+		assert.NotNil(thm.Line.Colour, name)
+		assert.NotNil(thm.Arrow.Colour, name)
+		assert.NotNil(thm.OpenArrow.Colour, name)
+		assert.NotNil(thm.ActivityBoxFill.Colour, name)
+		assert.NotNil(thm.Frame.Colour, name)
+		assert.NotNil(thm.Text.Colour, name)
+		// The lines and text must stand out from the background.
+		assert.NotEqual(*thm.Background, *thm.Line.Colour, name)
+		assert.NotEqual(*thm.Background, *thm.Text.Colour, name)
+	}
+}